@@ -1,9 +1,10 @@
 package main
 
 import (
-	"GoBWR/fluid"
-	"GoBWR/reactor"
+	"GoBWR/simulation"
+	"flag"
 	"fmt"
+	"os"
 	"time"
 )
 
@@ -12,12 +13,30 @@ const deltaTime time.Duration = 1 * time.Second / 10 // How often the physics ar
 
 // --- MAIN EVENT LOOP ---
 func main() {
-	fluid.InitializeFluidNodes()
-	reactor.SetupReactor()
+	var modelPath *string = flag.String("model", "", "plant model file to load, the built-in test plant is used if empty")
+	flag.Parse()
+
+	var model simulation.PlantModel = simulation.DefaultPlantModel()
+	if *modelPath != "" {
+		var err error
+		model, err = simulation.LoadPlantModel(*modelPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	var plant, err = simulation.NewFromModel(model)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	for {
-		fluid.SimulateFlow(deltaTime)
-		reactor.SimulateFission()
-		fmt.Println(fluid.FluidNodes)
+		if err := plant.Step(deltaTime); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(plant.Fluid.Nodes)
 		time.Sleep(deltaTime) // Execute the main event loop every deltaTime.
 	}
 }
@@ -2,6 +2,7 @@ package fluid
 
 import (
 	"errors"
+	"maps"
 	"math"
 	"time"
 )
@@ -15,294 +16,406 @@ type FluidNode struct {
 	Enthalpy    float64 // J/kg
 	Entropy     float64 // J/(kg·K)
 	MaxVolume   float64 // max volume in cubic metres. Fluid will not flow into the node if it is full.
+
+	BottomElevation float64 // elevation of the bottom of the node in meters, the water fills it upwards from here
+	TopElevation    float64 // elevation of the top of the node in meters
+
+	Closed              bool    // a sealed vessel: liquid and vapour always fill MaxVolume and the state is resolved from (u, v) instead of (p, h)
+	InternalEnergy      float64 // J/kg
+	Quality             float64 // vapour mass fraction, 0 for subcooled liquid and 1 for superheated vapour
+	VoidFraction        float64 // vapour volume fraction of the fluid in the node
+	LiquidMass          float64 // kg
+	VaporMass           float64 // kg
+	LiquidVolume        float64 // m^3
+	VaporVolume         float64 // m^3
+	VaporGenerationRate float64 // kg/s of vapour produced in the node during the last flow step
+	SurfaceTension      float64 // N/m of the liquid of a saturated mixture, drives the swell of the boiling pool
+}
+
+// FluidHeader is a point without volume where several flow paths meet, like a tee or a distribution header. Its
+// pressure is solved so that the flows into and out of it balance, and the streams leaving it are the mixture of the
+// streams entering it.
+type FluidHeader struct {
+	Temperature float64 // degrees Celsius
+	Pressure    float64 // Pa
+	Enthalpy    float64 // J/kg
+	Entropy     float64 // J/(kg·K)
 }
 
 type FluidJunctionBase struct {
-	SourceType      string // Node/Junction
+	SourceType      string // Node/Header/Junction
 	SourceID        string // e.g. FeedwaterHeader
-	DestinationType string // Node/Junction
+	DestinationType string // Node/Header/Junction
 	DestinationID   string // e.g. ReactorVessel
 }
 
 type FluidPipe struct {
-	JunctionBase FluidJunctionBase // the base junction info
-	PipeDiameter float64           // diameter of the pipe in milimeters
-	PipeLength   float64           // length of the pipe in meters
-	MinorKFactor float64           // the minor K-Factor caused by things like fittings, elbows... in the piping. The major K-Factor is calculated when simulating flow.
+	JunctionBase  FluidJunctionBase // the base junction info
+	PipeDiameter  float64           // diameter of the pipe in milimeters
+	PipeLength    float64           // length of the pipe in meters
+	MinorKFactor  float64           // the minor K-Factor caused by things like fittings, elbows... in the piping. The major K-Factor is calculated when simulating flow.
+	Roughness     float64           // absolute roughness of the pipe wall in meters, see PipeRoughness
+	FrictionModel FrictionModel     // the correlation used for the friction factor, Churchill if empty
+	MassFlowRate  float64           // kg/s, positive from source to destination. All pipes of a flow path carry the same flow.
+
+	InletElevation  float64 // elevation in meters where the pipe leaves its source
+	OutletElevation float64 // elevation in meters where the pipe enters its destination
 }
 
+// FlowPath is a chain of pipes, pumps, valves and jet pumps between two nodes or headers. Flow along it is positive from its source to its
+// destination and negative when it runs backwards.
 type FlowPath struct {
-	SourceNodeID      string
-	DestinationNodeID string
-	JunctionIDs       []string
+	SourceType      string // Node/Header
+	SourceID        string
+	DestinationType string // Node/Header
+	DestinationID   string
+	JunctionIDs     []string
 }
 
-// --- CONSTANT DECLARATIONS ---
-const RPVHeight float32 = 21.3 // In meters, how high the water can fill in the RPV
-
-// --- VARIABLE DECLARATIONS ---
-var FluidNodes map[string]FluidNode = map[string]FluidNode{
-	"Hotwell": FluidNode{
-		20, 101325, 500, 0, 0, 0, 11000, // Enthalpy, Entropy and Mass is calculated, mostly by IAPWS IF97, upon initialization.
-	},
-	"ReactorVessel": FluidNode{
-		35, 230000, 920, 0, 0, 0, 928,
-	},
+// FluidNetwork owns one fluid system: its nodes and headers, the pipes between them and the flow paths derived from
+// the pipes, pumps, valves and jet pumps. Every network is independent, so several plants can be simulated side by side,
+// each with its own equation of state.
+type FluidNetwork struct {
+	Properties PropertyProvider // equation of state of the water and steam, DefaultPropertyProvider if nil
+
+	Nodes     map[string]FluidNode
+	Headers   map[string]FluidHeader
+	Pipes     map[string]FluidPipe
+	Pumps     map[string]FluidPump
+	Valves    map[string]FluidValve
+	JetPumps  map[string]FluidJetPump
+	FlowPaths []FlowPath // Will be initialized automatically
+
+	HeatStructures map[string]HeatStructure // walls that store heat and exchange it with the nodes, see SimulateHeatTransfer
 }
 
-var FluidPipes map[string]FluidPipe = map[string]FluidPipe{
-	"HotwellToTest": FluidPipe{
-		FluidJunctionBase{
-			"Node",
-			"Hotwell",
-			"Junction",
-			"HotwellToTest2",
-		},
-		450,
-		30,
-		3.5,
-	},
-	"HotwellToTest2": FluidPipe{
-		FluidJunctionBase{
-			"Junction",
-			"HotwellToTest",
-			"Junction",
-			"HotwellToTest3",
-		},
-		400,
-		10,
-		4,
-	},
-	"HotwellToTest3": FluidPipe{
-		FluidJunctionBase{
-			"Junction",
-			"HotwellToTest2",
-			"Node",
-			"ReactorVessel",
-		},
-		550,
-		80,
-		2.5,
-	},
+// NewFluidNetwork creates a network from the given nodes, headers and junctions with the default equation of state.
+// Replace its Properties to use another one, then initialize it before simulating.
+func NewFluidNetwork(nodes map[string]FluidNode, headers map[string]FluidHeader, pipes map[string]FluidPipe, pumps map[string]FluidPump, valves map[string]FluidValve) *FluidNetwork {
+	if pumps == nil {
+		pumps = make(map[string]FluidPump)
+	}
+	if valves == nil {
+		valves = make(map[string]FluidValve)
+	}
+	return &FluidNetwork{
+		Properties: DefaultPropertyProvider(),
+
+		Nodes:   nodes,
+		Headers: headers,
+		Pipes:   pipes,
+		Pumps:   pumps,
+		Valves:  valves,
+
+		JetPumps:       make(map[string]FluidJetPump),
+		HeatStructures: make(map[string]HeatStructure),
+	}
 }
 
-var FlowPaths []FlowPath // Will be initialized automatically
+// properties returns the equation of state of the network.
+func (network *FluidNetwork) properties() PropertyProvider {
+	if network.Properties == nil {
+		return DefaultPropertyProvider()
+	}
+	return network.Properties
+}
 
-func FindConnectionToJunction(junctionId string) (nextType string, nextId string, searchError error) {
-	var junction FluidPipe
+// FindConnectionToJunction returns what the given pipe, pump, valve or jet pump discharges into.
+func (network *FluidNetwork) FindConnectionToJunction(junctionId string) (nextType string, nextId string, searchError error) {
+	var found junction
 	var ok bool
-	junction, ok = FluidPipes[junctionId]
+	found, ok = network.junction(junctionId)
 
 	if !ok {
 		return "", "", errors.New("junction not found")
 	}
+	var base FluidJunctionBase = found.base()
 
-	if junction.JunctionBase.DestinationType == "Junction" {
+	if base.DestinationType == "Junction" {
 		var ok bool
-		_, ok = FluidPipes[junction.JunctionBase.DestinationID]
+		_, ok = network.junction(base.DestinationID)
 		if !ok {
 			return "", "", errors.New("destination junction does not exist")
 		}
 	}
 
-	if junction.JunctionBase.DestinationType == "Node" {
+	if base.DestinationType == "Node" {
 		var ok bool
-		_, ok = FluidNodes[junction.JunctionBase.DestinationID]
+		_, ok = network.Nodes[base.DestinationID]
 		if !ok {
 			return "", "", errors.New("destination node does not exist")
 		}
 	}
 
-	return junction.JunctionBase.DestinationType, junction.JunctionBase.DestinationID, nil
+	if base.DestinationType == "Header" {
+		var ok bool
+		_, ok = network.Headers[base.DestinationID]
+		if !ok {
+			return "", "", errors.New("destination header does not exist")
+		}
+	}
+
+	return base.DestinationType, base.DestinationID, nil
 }
 
-func GetJunctionPathToDestination(startJunctionId string) (junctionPath []string, destinationNodeId string, err error) {
+// GetJunctionPathToDestination follows the junctions from the given one until they reach a node or a header.
+func (network *FluidNetwork) GetJunctionPathToDestination(startJunctionId string) (junctionPath []string, destinationType string, destinationId string, err error) {
 	var currentJunctionId string = startJunctionId
+	var visited map[string]bool = map[string]bool{startJunctionId: true}
 	junctionPath = append(junctionPath, currentJunctionId)
 	for {
-		nextStepType, nextStepId, searchError := FindConnectionToJunction(currentJunctionId)
+		nextStepType, nextStepId, searchError := network.FindConnectionToJunction(currentJunctionId)
 		if searchError != nil {
-			return junctionPath, currentJunctionId, searchError
+			return junctionPath, "Junction", currentJunctionId, searchError
 		}
-		if nextStepType == "Node" {
-			return junctionPath, nextStepId, nil
+		if nextStepType == "Node" || nextStepType == "Header" {
+			return junctionPath, nextStepType, nextStepId, nil
 		}
+		if visited[nextStepId] {
+			return junctionPath, "Junction", currentJunctionId, errors.New("junction path loops back into junction " + nextStepId)
+		}
+		visited[nextStepId] = true
 		junctionPath = append(junctionPath, nextStepId)
 		currentJunctionId = nextStepId
 	}
 }
 
-func InitializeFluidNodes() {
-	for name, node := range FluidNodes { // Initialize Enthalpy, Entropy and Mass of all nodes
-		node.Enthalpy = CalculateEnthalpyPt(node.Pressure/1000000, node.Temperature) * 1000 // J/kg
-		node.Mass = CalculateMass(CalculateDensityPt(node.Pressure/1000000, node.Temperature), node.Volume)
-		node.Entropy = CalculateEntropyPt(node.Pressure/1000000, node.Temperature) * 1000
-		FluidNodes[name] = node
+// Initialize validates the layout of the network, fills in the state of every node and header and derives the flow
+// paths from the junctions. An invalid network is left untouched and every problem found is returned as a *TopologyError.
+func (network *FluidNetwork) Initialize() error {
+	if problems := network.Validate(); len(problems) > 0 {
+		return &TopologyError{problems}
 	}
 
-	for pipeName, pipe := range FluidPipes { // Initialize flow paths, fluid will only flow if connected to a junction directly. Never from one node to another.
-		if pipe.JunctionBase.SourceType == "Node" && pipe.JunctionBase.DestinationType == "Junction" {
-			var path, destination, err = GetJunctionPathToDestination(pipeName)
-			var flowPath FlowPath = FlowPath{
-				pipe.JunctionBase.SourceID,
-				destination,
-				path,
-			}
-			if err == nil {
-				FlowPaths = append(FlowPaths, flowPath)
+	network.FlowPaths = nil
+	for name, node := range network.Nodes { // Initialize Enthalpy, Entropy, Mass and the phase split of all nodes
+		network.Nodes[name] = initializeNode(network.properties(), node)
+	}
+
+	for _, junctionId := range network.junctionIDs() { // Initialize flow paths, fluid will only flow if connected to a junction directly. Never from one node to another.
+		var element, _ = network.junction(junctionId)
+		var base FluidJunctionBase = element.base()
+		if base.SourceType == "Node" || base.SourceType == "Header" {
+			var path, destinationType, destination, err = network.GetJunctionPathToDestination(junctionId)
+			if err != nil {
+				return err // unreachable after validation
 			}
+			network.FlowPaths = append(network.FlowPaths, FlowPath{
+				SourceType:      base.SourceType,
+				SourceID:        base.SourceID,
+				DestinationType: destinationType,
+				DestinationID:   destination,
+				JunctionIDs:     path,
+			})
 		}
 	}
+
+	network.initializeHeaders()
+	return nil
 }
 
-func CalculateTotalPipeKAndVelocityMap(flowPath FlowPath, kPipeMap map[string]float64, pressureMagnitude float64, sourceNodeDensity float64) (normalizedTotalK float64, pipeVelocityMap map[string]float64) {
-	pipeVelocityMap = make(map[string]float64)
-	var firstIteration bool = true // get the first pipe of the path to use as a reference for total K-factor
-	var firstPipe FluidPipe
-	for _, pipeId := range flowPath.JunctionIDs { // calculate total normalized K-Factor
-		if firstIteration {
-			normalizedTotalK += kPipeMap[pipeId]
-			firstPipe = FluidPipes[pipeId]
-			firstIteration = false
-			continue
-		}
-		var firstPipeA float64 = math.Pi * math.Pow((firstPipe.PipeDiameter/1000)/2, 2) // cross-sectional area of first and current pipe
-		var currentPipeA float64 = math.Pi * math.Pow((FluidPipes[pipeId].PipeDiameter/1000)/2, 2)
-		normalizedTotalK += kPipeMap[pipeId] * math.Pow(firstPipeA/currentPipeA, 2)
+// CalculatePathInertia returns the inertia of the fluid in a flow path, the sum of L/A over its junctions in 1/m. A
+// pressure difference of dP accelerates the flow by dP/inertia kg/s every second.
+func (network *FluidNetwork) CalculatePathInertia(flowPath FlowPath) (inertia float64) {
+	for _, junctionId := range flowPath.JunctionIDs {
+		var element, _ = network.junction(junctionId)
+		inertia += element.inertia()
 	}
+	return
+}
 
-	var firstPipeVelocity float64 = math.Sqrt(2 * pressureMagnitude / (normalizedTotalK * sourceNodeDensity))
-	for _, pipeId := range flowPath.JunctionIDs { // get velocity in all pipes
-		var firstPipeA float64 = math.Pi * math.Pow((firstPipe.PipeDiameter/1000)/2, 2) // cross-sectional area of first and current pipe
-		var currentPipeA float64 = math.Pi * math.Pow((FluidPipes[pipeId].PipeDiameter/1000)/2, 2)
-		var currentPipeVelocity float64 = firstPipeVelocity * (firstPipeA / currentPipeA) // principle of continuity to derive current pipe velocity from the first (reference) pipe
-		pipeVelocityMap[pipeId] = currentPipeVelocity
+// CalculatePathElevationGain returns how many meters a flow path rises from its source to its destination.
+func (network *FluidNetwork) CalculatePathElevationGain(flowPath FlowPath) (elevationGain float64) {
+	for _, junctionId := range flowPath.JunctionIDs {
+		var element, _ = network.junction(junctionId)
+		elevationGain += element.outletElevation() - element.inletElevation()
 	}
 	return
 }
 
-func CalculateKPipeMapAndFrictionFactorMap(flowPath FlowPath, previousFrictionFactors map[string]float64, pressureMagnitude float64, sourceNode FluidNode) (kPipeMap map[string]float64, pipeFrictionFactorMap map[string]float64) {
-	kPipeMap = make(map[string]float64)
-	var sourceNodeDensity = CalculateDensityPt(sourceNode.Pressure/1000000, sourceNode.Temperature)
-	for _, pipeId := range flowPath.JunctionIDs { // populate kPipeMap
-		kPipeMap[pipeId] = (previousFrictionFactors[pipeId] * (FluidPipes[pipeId].PipeLength) / (FluidPipes[pipeId].PipeDiameter / 1000)) + FluidPipes[pipeId].MinorKFactor // diameter unit conversion mm->m
+// CalculatePathPressureDrop returns the pressure drop in Pa that friction and form losses cause along a flow path at
+// the given mass flow rate of the given fluid, less the head its pumps add. It has the sign of the flow.
+func (network *FluidNetwork) CalculatePathPressureDrop(flowPath FlowPath, massFlowRate float64, stream WaterProperties) (pressureDrop float64) {
+	for _, junctionId := range flowPath.JunctionIDs {
+		var element, _ = network.junction(junctionId)
+		pressureDrop += element.pressureDrop(massFlowRate, stream)
 	}
+	return
+}
 
-	var _, pipeVelocityMap = CalculateTotalPipeKAndVelocityMap(flowPath, kPipeMap, pressureMagnitude, sourceNodeDensity)
+// GetPathMassFlowRate returns the current mass flow rate of a flow path in kg/s.
+func (network *FluidNetwork) GetPathMassFlowRate(flowPath FlowPath) float64 {
+	var element, _ = network.junction(flowPath.JunctionIDs[0])
+	return element.massFlowRate()
+}
 
-	pipeFrictionFactorMap = make(map[string]float64)
-	for _, pipeId := range flowPath.JunctionIDs { // get a better friction factor estimate
-		var reynoldsNumber float64 = (sourceNodeDensity * pipeVelocityMap[pipeId] * (FluidPipes[pipeId].PipeDiameter / 1000)) / CalculateDynamicViscosityPt(sourceNode.Pressure/1000000, sourceNode.Temperature)
-		var pipeAbsoluteRoughness float64 = 0.000045 // meters, this is an estimate
-		var swameeJainFrictionFactor float64 = 0.25 / math.Pow(math.Log10((pipeAbsoluteRoughness/(3.7*(FluidPipes[pipeId].PipeDiameter/1000)))+(5.74/math.Pow(reynoldsNumber, 0.9))), 2)
-		pipeFrictionFactorMap[pipeId] = swameeJainFrictionFactor
+func (network *FluidNetwork) setPathMassFlowRate(flowPath FlowPath, massFlowRate float64) {
+	for _, junctionId := range flowPath.JunctionIDs {
+		network.setJunctionMassFlowRate(junctionId, massFlowRate)
 	}
-	return
 }
 
-func SimulateFlow(deltaTime time.Duration) {
-	var deltaTimeSeconds float64 = deltaTime.Seconds() // convert time.Duration to seconds
-	for _, flowPath := range FlowPaths {
-		var sourceNode FluidNode = FluidNodes[flowPath.SourceNodeID]
-		var destinationNode FluidNode = FluidNodes[flowPath.DestinationNodeID]
-		var actualSourceNode FluidNode = sourceNode
-		var actualSourceNodeId string = flowPath.SourceNodeID
-		var actualDestinationNode FluidNode = destinationNode
-		var actualDestinationNodeId string = flowPath.DestinationNodeID
-		var deltaP float64 = sourceNode.Pressure - destinationNode.Pressure
-
-		if deltaP == 0 {
-			continue // skip this path, pressure is equalized, no flow
-		} else if deltaP < 0.0 {
-			actualSourceNode = destinationNode
-			actualSourceNodeId = flowPath.DestinationNodeID
-			actualDestinationNode = sourceNode
-			actualDestinationNodeId = flowPath.SourceNodeID
+// integrateMomentum advances the mass flow rate of a path over one time step with the momentum equation
+//
+//	inertia * dm/dt = dP - pressureDrop(m)
+//
+// integrated implicitly, so the result uses the pressure difference at the end of the step. The fluid has to be
+// accelerated and decelerated, so flow builds up and coasts down instead of jumping to its steady value. The
+// derivative of the new flow with respect to dP is returned for the network solver.
+func integrateMomentum(inertia float64, pressureDrop func(massFlowRate float64) float64, oldMassFlowRate float64, deltaP float64, deltaTimeSeconds float64) (massFlowRate float64, derivative float64) {
+	var inertiaRate float64 = inertia / deltaTimeSeconds
+	var residual = func(massFlowRate float64) (value float64, slope float64) {
+		var step float64 = 0.000001 * math.Max(math.Abs(massFlowRate), 1)
+		var drop float64 = pressureDrop(massFlowRate)
+		return inertiaRate*(massFlowRate-oldMassFlowRate) + drop - deltaP, inertiaRate + (pressureDrop(massFlowRate+step)-drop)/step
+	}
+	// The residual rises monotonically with the flow, and as the pressure drop does too, the root lies between the old
+	// flow and the old flow accelerated by the whole excess pressure.
+	var value, slope = residual(oldMassFlowRate)
+	var low, high float64 = oldMassFlowRate, oldMassFlowRate - value/inertiaRate
+	if low > high {
+		low, high = high, low
+	}
+	massFlowRate = oldMassFlowRate
+	for i := 0; i < 50 && value != 0; i += 1 {
+		if value > 0 {
+			high = massFlowRate
+		} else {
+			low = massFlowRate
+		}
+		var next float64 = massFlowRate - value/slope
+		if next <= low || next >= high { // keep the Newton step inside the bracket
+			next = (low + high) / 2
 		}
+		var converged bool = math.Abs(next-massFlowRate) <= 1e-12*math.Max(math.Abs(massFlowRate), 1)
+		massFlowRate = next
+		value, slope = residual(massFlowRate)
+		if converged {
+			break
+		}
+	}
+	return massFlowRate, 1 / slope
+}
 
-		var sourceNodeDensity float64 = CalculateDensityPt(actualSourceNode.Pressure/1000000, actualSourceNode.Temperature)
-		var pressureMagnitude float64 = math.Abs(deltaP)
-		var fGuessMap map[string]float64 = make(map[string]float64) // find the darcy friction factor using an iterative loop to get the major K-Factor
-		for _, pipeName := range flowPath.JunctionIDs {
-			fGuessMap[pipeName] = 0.02
+// SimulateFlow moves fluid through the whole network for one time step. The pressures of all headers and closed nodes
+// at the end of the step are solved together, see solveFlows, and the mass and energy moved by every flow path are
+// then applied to all nodes at once, so the result does not depend on the order of the flow paths. A step over which
+// the pressure of a closed node does not follow its inventory as linearly as the solver assumes is split in halves,
+// see simulateFlowStep.
+func (network *FluidNetwork) SimulateFlow(deltaTime time.Duration) {
+	var deltaTimeSeconds float64 = deltaTime.Seconds()                // convert time.Duration to seconds
+	var vaporMassBefore map[string]float64 = make(map[string]float64) // used to derive the vapour generation rate of every node
+	for nodeId, node := range network.Nodes {
+		vaporMassBefore[nodeId] = node.VaporMass
+	}
+
+	network.simulateFlowStep(deltaTimeSeconds, flowStepSplits)
+
+	for nodeId, node := range network.Nodes {
+		node.VaporGenerationRate = max(node.VaporMass-vaporMassBefore[nodeId], 0) / deltaTimeSeconds
+		network.Nodes[nodeId] = node
+	}
+}
+
+// simulateFlowStep solves and applies the flows of one time step. The solver linearizes the pressure of every closed
+// node around its state at the start of the step. When a node ends the step far from the pressure the solver gave it,
+// such as a vessel whose steam dome fills up within the step, the step is undone and taken as two halves instead, at
+// most splits times over.
+func (network *FluidNetwork) simulateFlowStep(deltaTimeSeconds float64, splits int) {
+	var valves map[string]FluidValve = maps.Clone(network.Valves)
+	var headers map[string]FluidHeader = maps.Clone(network.Headers)
+	network.strokeValves(deltaTimeSeconds)                       // the flows are solved at the valve positions at the end of the step
+	var connections [][2]pathConnection = network.connectPaths() // the fluid that actually enters a pipe leaving a node
+	var flows, solvedPressures = network.solveFlows(connections, deltaTimeSeconds)
+	flows = network.limitFlows(network.balanceHeaders(flows), connections, deltaTimeSeconds)
+	network.mixHeaders(flows, connections) // the streams leaving the headers carry what the limited flows bring in
+
+	var massChange map[string]float64 = make(map[string]float64)
+	var energyChange map[string]float64 = make(map[string]float64)
+	for i, flowPath := range network.FlowPaths {
+		var massToMove float64 = flows[i] * deltaTimeSeconds
+		if massToMove == 0 {
+			continue
 		}
-		var pipeFrictionFactorMap map[string]float64 = make(map[string]float64)
-		_, pipeFrictionFactorMap = CalculateKPipeMapAndFrictionFactorMap(flowPath, fGuessMap, pressureMagnitude, actualSourceNode)
-		for i := 0; i < 2; i += 1 {
-			_, pipeFrictionFactorMap = CalculateKPipeMapAndFrictionFactorMap(flowPath, pipeFrictionFactorMap, pressureMagnitude, actualSourceNode)
+		var upstreamType, upstreamId, downstreamType, downstreamId string = flowPath.SourceType, flowPath.SourceID, flowPath.DestinationType, flowPath.DestinationID
+		if massToMove < 0 {
+			upstreamType, upstreamId, downstreamType, downstreamId = downstreamType, downstreamId, upstreamType, upstreamId
+			massToMove = -massToMove
 		}
-		var kMap, _ = CalculateKPipeMapAndFrictionFactorMap(flowPath, pipeFrictionFactorMap, pressureMagnitude, actualSourceNode)
-		var totalFinalK float64 = 0.0
-		var firstIteration bool = true
-		var firstPipe FluidPipe
-		for _, pipeId := range flowPath.JunctionIDs {
-			if firstIteration {
-				totalFinalK += kMap[pipeId]
-				firstPipe = FluidPipes[pipeId]
-				firstIteration = false
-				continue
-			}
-			var firstPipeA float64 = math.Pi * math.Pow((firstPipe.PipeDiameter/1000)/2, 2) // cross-sectional area of first and current pipe
-			var currentPipeA float64 = math.Pi * math.Pow((FluidPipes[pipeId].PipeDiameter/1000)/2, 2)
-			var normalizedK float64 = kMap[pipeId] * math.Pow(firstPipeA/currentPipeA, 2)
-			totalFinalK += normalizedK
+		var stream WaterProperties = network.pathStream(i, flows[i] > 0, connections)
+		if upstreamType == "Node" {
+			massChange[upstreamId] -= massToMove
+			energyChange[upstreamId] -= massToMove * stream.Enthalpy * 1000
 		}
-		var finalReferenceVelocity float64 = math.Sqrt(2 * pressureMagnitude / (totalFinalK * sourceNodeDensity))
-		var finalQ float64 = finalReferenceVelocity * math.Pi * math.Pow((firstPipe.PipeDiameter/1000)/2, 2)     // v_ref * A_ref
-		var finalMassFlowRate float64 = finalQ * sourceNodeDensity                                               // kg/s
-		var potentialMassToMove float64 = finalMassFlowRate * deltaTimeSeconds                                   // multiply by deltaTimeSeconds to get the mass to move in our timestep
-		var sourceLimit float64 = actualSourceNode.Mass                                                          // we can't move more mass than there is in the source
-		var emptySpaceInDestinationNode float64 = actualDestinationNode.MaxVolume - actualDestinationNode.Volume // we can't overfill the destination node
-		var destinationLimit float64 = emptySpaceInDestinationNode * sourceNodeDensity
-		var massToMove float64 = min(potentialMassToMove, sourceLimit, destinationLimit)
-
-		// Energy and entropy flow with the mass
-		var energyToMove float64 = massToMove * actualSourceNode.Enthalpy
-		var entropyToMove float64 = massToMove * actualSourceNode.Entropy
-
-		// Total energy and entropy before transfer
-		var sourceEnergyBefore float64 = actualSourceNode.Mass * actualSourceNode.Enthalpy
-		var sourceEntropyBefore float64 = actualSourceNode.Mass * actualSourceNode.Entropy
-		var destEnergyBefore float64 = actualDestinationNode.Mass * actualDestinationNode.Enthalpy
-		var destEntropyBefore float64 = actualDestinationNode.Mass * actualDestinationNode.Entropy
-
-		// Update masses
-		actualSourceNode.Mass -= massToMove
-		actualDestinationNode.Mass += massToMove
-
-		// Total energy and entropy after transfer
-		var sourceEnergyAfter float64 = sourceEnergyBefore - energyToMove
-		var sourceEntropyAfter float64 = sourceEntropyBefore - entropyToMove
-		var destEnergyAfter float64 = destEnergyBefore + energyToMove
-		var destEntropyAfter float64 = destEntropyBefore + entropyToMove
-
-		// Update specific enthalpy and entropy for source
-		if actualSourceNode.Mass > 0.001 {
-			actualSourceNode.Enthalpy = sourceEnergyAfter / actualSourceNode.Mass
-			actualSourceNode.Entropy = sourceEntropyAfter / actualSourceNode.Mass
-			actualSourceNode.Pressure = CalculatePressureHs(actualSourceNode.Enthalpy/1000, actualSourceNode.Entropy/1000) * 1000000 // MPa to Pa
-			actualSourceNode.Temperature = CalculateTemperatureHs(actualSourceNode.Enthalpy/1000, actualSourceNode.Entropy/1000)
+		if downstreamType == "Node" {
+			massChange[downstreamId] += massToMove
+			energyChange[downstreamId] += massToMove * stream.Enthalpy * 1000
 		}
+	}
 
-		// Update specific enthalpy and entropy for destination
-		if actualDestinationNode.Mass > 0.001 {
-			actualDestinationNode.Enthalpy = destEnergyAfter / actualDestinationNode.Mass
-			actualDestinationNode.Entropy = destEntropyAfter / actualDestinationNode.Mass
-			actualDestinationNode.Pressure = CalculatePressureHs(actualDestinationNode.Enthalpy/1000, actualDestinationNode.Entropy/1000) * 1000000 // MPa to Pa
-			actualDestinationNode.Temperature = CalculateTemperatureHs(actualDestinationNode.Enthalpy/1000, actualDestinationNode.Entropy/1000)
+	var nodes map[string]FluidNode = make(map[string]FluidNode)
+	var linear bool = true
+	for _, nodeId := range sortedKeys(network.Nodes) {
+		var node FluidNode = network.Nodes[nodeId]
+		if massChange[nodeId] == 0 && energyChange[nodeId] == 0 {
+			continue
+		}
+		// Total energy after the transfer. Open nodes account energy as enthalpy, closed nodes as internal energy.
+		var energyAfter float64 = node.Mass*nodeSpecificEnergy(node) + energyChange[nodeId]
+		node.Mass += massChange[nodeId]
+		if node.Mass > 0.001 {
+			node = setNodeSpecificEnergy(node, energyAfter/node.Mass)
+			node = resolveNode(network.properties(), node)
+		}
+		if solved, ok := solvedPressures[nodeId]; ok {
+			var change float64 = math.Max(math.Abs(solved-network.Nodes[nodeId].Pressure), math.Abs(node.Pressure-network.Nodes[nodeId].Pressure))
+			linear = linear && math.Abs(node.Pressure-solved) <= flowStepLinearity*change+flowStepPressureTolerance
 		}
+		nodes[nodeId] = node
+	}
+	if !linear && splits > 0 {
+		network.Valves, network.Headers = valves, headers
+		network.simulateFlowStep(deltaTimeSeconds/2, splits-1)
+		network.simulateFlowStep(deltaTimeSeconds/2, splits-1)
+		return
+	}
+
+	for i, flowPath := range network.FlowPaths {
+		network.setPathMassFlowRate(flowPath, flows[i])
+		network.simulateJunctions(flowPath, network.pathStream(i, flows[i] >= 0, connections), deltaTimeSeconds)
+	}
+	for nodeId, node := range nodes {
+		network.Nodes[nodeId] = node
+	}
+}
 
-		actualSourceNode.Volume = actualSourceNode.Mass / CalculateDensityPt(actualSourceNode.Pressure/1000000, actualSourceNode.Temperature)
-		actualDestinationNode.Volume = actualDestinationNode.Mass / CalculateDensityPt(actualDestinationNode.Pressure/1000000, actualDestinationNode.Temperature)
+// nodeSpecificEnergy is the specific energy a node is accounted in, J/kg: internal energy for closed nodes, enthalpy for open ones.
+func nodeSpecificEnergy(node FluidNode) float64 {
+	if node.Closed {
+		return node.InternalEnergy
+	}
+	return node.Enthalpy
+}
 
-		FluidNodes[actualSourceNodeId] = actualSourceNode
-		FluidNodes[actualDestinationNodeId] = actualDestinationNode
+func setNodeSpecificEnergy(node FluidNode, specificEnergy float64) FluidNode {
+	if node.Closed {
+		node.InternalEnergy = specificEnergy
+	} else {
+		node.Enthalpy = specificEnergy
 	}
+	return node
+}
+
+// GetReactorWaterLevel returns the collapsed water level, the height the liquid inventory alone would fill, and the
+// swollen level, which also counts the steam bubbles held up in the boiling pool. Both are in meters above the bottom of the RPV.
+func (network *FluidNetwork) GetReactorWaterLevel() (collapsedLevel float64, swollenLevel float64) {
+	return network.GetWaterLevel("ReactorVessel")
 }
 
-func GetReactorWaterLevel() float64 {
-	var RPVNode FluidNode = FluidNodes["ReactorVessel"]
-	var density float64 = CalculateDensityPt(RPVNode.Pressure/1000000, RPVNode.Temperature)
-	var currentWaterVolume float64 = RPVNode.Mass / density
-	return (currentWaterVolume / RPVNode.MaxVolume) * float64(RPVHeight)
+// GetWaterLevel returns the collapsed and swollen water levels of any node in meters above its bottom, see
+// GetReactorWaterLevel.
+func (network *FluidNetwork) GetWaterLevel(nodeId string) (collapsedLevel float64, swollenLevel float64) {
+	var node FluidNode = network.Nodes[nodeId]
+	collapsedLevel, swollenLevel = node.WaterLevels()
+	return collapsedLevel - node.BottomElevation, swollenLevel - node.BottomElevation
 }
@@ -0,0 +1,56 @@
+package fluid
+
+import (
+	"math"
+	"testing"
+)
+
+var frictionModels []FrictionModel = []FrictionModel{Churchill, ColebrookWhite, SwameeJain, Laminar}
+
+func TestPressureDropWithoutFlow(t *testing.T) {
+	var stream WaterProperties = WaterProperties{Density: 750, DynamicViscosity: 0.0001}
+	for _, model := range frictionModels {
+		var pipe FluidPipe = FluidPipe{PipeDiameter: 500, PipeLength: 20, Roughness: 0.000045, MinorKFactor: 1.5, FrictionModel: model}
+		if drop := pipe.PressureDrop(0, stream); drop != 0 || math.IsNaN(drop) {
+			t.Errorf("%s: pressure drop without flow = %v Pa, want 0", model, drop)
+		}
+		if forward, backward := pipe.PressureDrop(100, stream), pipe.PressureDrop(-100, stream); forward <= 0 || backward != -forward {
+			t.Errorf("%s: pressure drop at ±100 kg/s = %v and %v Pa, want opposite and positive forward", model, forward, backward)
+		}
+	}
+}
+
+func TestFrictionReynoldsProductIsContinuous(t *testing.T) {
+	// f·Re must not jump where the models switch between their laminar, transition and turbulent forms
+	for _, model := range frictionModels {
+		for _, reynoldsNumber := range []float64{1, laminarReynoldsNumber, turbulentReynoldsNumber} {
+			var below float64 = frictionReynoldsProduct(model, reynoldsNumber*(1-1e-9), 0.0001)
+			var above float64 = frictionReynoldsProduct(model, reynoldsNumber*(1+1e-9), 0.0001)
+			if math.IsNaN(below) || math.IsNaN(above) || math.Abs(above-below) > 1e-6*below {
+				t.Errorf("%s: f·Re jumps from %v to %v at Re = %v", model, below, above, reynoldsNumber)
+			}
+		}
+	}
+}
+
+func TestFrictionFactors(t *testing.T) {
+	var tests = []struct {
+		name                              string
+		model                             FrictionModel
+		reynoldsNumber, relativeRoughness float64
+		want, tolerance                   float64
+	}{
+		{"laminar", Laminar, 1000, 0.0001, 0.064, 1e-12},
+		{"Churchill laminar", Churchill, 500, 0.0001, 0.128, 0.01},
+		{"empty model is Churchill", "", 100000, 0.0001, churchillFrictionFactor(100000, 0.0001), 1e-12},
+		{"Colebrook smooth", ColebrookWhite, 100000, 0, 0.01799, 0.005}, // Moody chart
+		{"Colebrook rough", ColebrookWhite, 1000000, 0.001, 0.01993, 0.005},
+		{"Swamee-Jain against Colebrook", SwameeJain, 100000, 0.0001, colebrookWhiteFrictionFactor(100000, 0.0001), 0.01},
+		{"Churchill against Colebrook", Churchill, 100000, 0.0001, colebrookWhiteFrictionFactor(100000, 0.0001), 0.02},
+	}
+	for _, test := range tests {
+		if got := DarcyFrictionFactor(test.model, test.reynoldsNumber, test.relativeRoughness); math.Abs(got-test.want) > test.tolerance*test.want {
+			t.Errorf("%s: f = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package fluid
+
+import "math"
+
+// --- CONSTANT DECLARATIONS ---
+const closedNodeFullFraction float64 = 0.000001 // share of its volume the vapour of a closed node must exceed for the node to count as not full
+
+// initializeHeaders gives every header a first pressure and stream, the average of the nodes its flow paths connect
+// it to directly. Headers that only connect to other headers start from the average of all nodes.
+func (network *FluidNetwork) initializeHeaders() {
+	for _, headerId := range sortedKeys(network.Headers) {
+		var neighbours []string
+		for _, flowPath := range network.FlowPaths {
+			if flowPath.SourceType == "Header" && flowPath.SourceID == headerId && flowPath.DestinationType == "Node" {
+				neighbours = append(neighbours, flowPath.DestinationID)
+			}
+			if flowPath.DestinationType == "Header" && flowPath.DestinationID == headerId && flowPath.SourceType == "Node" {
+				neighbours = append(neighbours, flowPath.SourceID)
+			}
+		}
+		if len(neighbours) == 0 {
+			neighbours = sortedKeys(network.Nodes)
+		}
+
+		var header FluidHeader
+		for _, nodeId := range neighbours {
+			var node FluidNode = network.Nodes[nodeId]
+			var stream WaterProperties = OutflowProperties(network.properties(), node)
+			header.Pressure += node.Pressure / float64(len(neighbours))
+			header.Enthalpy += stream.Enthalpy * 1000 / float64(len(neighbours))
+		}
+		network.Headers[headerId] = network.resolveHeader(header)
+	}
+}
+
+// pathConnection is where one end of a flow path meets a node, evaluated at the start of a flow step.
+type pathConnection struct {
+	stream    WaterProperties // the fluid the node delivers into the path
+	head      float64         // Pa, weight of the liquid above the connection
+	uncovered bool            // the connection is above the water line of a node without vapour and cannot draw anything
+}
+
+// connectPaths evaluates both ends of every flow path that end at a node: index 0 is the source end, 1 the destination end.
+func (network *FluidNetwork) connectPaths() [][2]pathConnection {
+	var connections [][2]pathConnection = make([][2]pathConnection, len(network.FlowPaths))
+	for k, flowPath := range network.FlowPaths {
+		var first, _ = network.junction(flowPath.JunctionIDs[0])
+		var last, _ = network.junction(flowPath.JunctionIDs[len(flowPath.JunctionIDs)-1])
+		if flowPath.SourceType == "Node" {
+			var node FluidNode = network.Nodes[flowPath.SourceID]
+			var stream, uncovered = ConnectionProperties(network.properties(), node, first.inletElevation())
+			connections[k][0] = pathConnection{stream, node.HydrostaticHead(first.inletElevation()), uncovered}
+		}
+		if flowPath.DestinationType == "Node" {
+			var node FluidNode = network.Nodes[flowPath.DestinationID]
+			var stream, uncovered = ConnectionProperties(network.properties(), node, last.outletElevation())
+			connections[k][1] = pathConnection{stream, node.HydrostaticHead(last.outletElevation()), uncovered}
+		}
+	}
+	return connections
+}
+
+// pathStream returns the fluid that enters flow path k from its source end, or from its destination end when the
+// flow runs backwards.
+func (network *FluidNetwork) pathStream(k int, fromSource bool, connections [][2]pathConnection) WaterProperties {
+	var flowPath FlowPath = network.FlowPaths[k]
+	var terminalType, terminalId, side = flowPath.SourceType, flowPath.SourceID, 0
+	if !fromSource {
+		terminalType, terminalId, side = flowPath.DestinationType, flowPath.DestinationID, 1
+	}
+	if terminalType == "Header" {
+		var header FluidHeader = network.Headers[terminalId]
+		return network.properties().Ph(header.Pressure/1000000, header.Enthalpy/1000)
+	}
+	return connections[k][side].stream
+}
+
+// mixHeaders sets the stream of every header to the mixture of the streams flowing into it. Headers fed by other
+// headers need their upstream mixtures first, so the mixing is repeated once per header.
+func (network *FluidNetwork) mixHeaders(flows []float64, connections [][2]pathConnection) {
+	for pass := 0; pass < len(network.Headers); pass += 1 {
+		var inflow, enthalpyFlow map[string]float64 = make(map[string]float64), make(map[string]float64)
+		for k, flowPath := range network.FlowPaths {
+			var downstreamType, downstreamId string = flowPath.DestinationType, flowPath.DestinationID
+			var flow float64 = flows[k]
+			if flow < 0 {
+				downstreamType, downstreamId = flowPath.SourceType, flowPath.SourceID
+				flow = -flow
+			}
+			if downstreamType != "Header" || flow == 0 {
+				continue
+			}
+			var stream WaterProperties = network.pathStream(k, flows[k] > 0, connections)
+			inflow[downstreamId] += flow
+			enthalpyFlow[downstreamId] += flow * stream.Enthalpy * 1000
+		}
+		for headerId, header := range network.Headers {
+			if inflow[headerId] > 0 { // a header without inflow keeps its previous stream
+				header.Enthalpy = enthalpyFlow[headerId] / inflow[headerId]
+			}
+			network.Headers[headerId] = network.resolveHeader(header)
+		}
+	}
+}
+
+// resolveHeader fills in the temperature and entropy of a header from its pressure and the enthalpy of its stream.
+func (network *FluidNetwork) resolveHeader(header FluidHeader) FluidHeader {
+	var state WaterProperties = network.properties().Ph(header.Pressure/1000000, header.Enthalpy/1000)
+	header.Temperature, header.Entropy = state.Temperature, state.Entropy*1000
+	return header
+}
+
+// balanceHeaders cuts back the flows of every header the flow solver could not balance, the larger side of it down to
+// the smaller. A header holds no mass, but its pressure cannot be solved below the triple point: the throat of a jet
+// pump whose drive jet is too fast for the pressure of its suction would pull more than reaches it, and cavitates,
+// passing only what its inlets deliver. Cutting back one header changes the headers it is chained to, so the balance
+// is repeated once per header.
+func (network *FluidNetwork) balanceHeaders(flows []float64) []float64 {
+	for pass := 0; pass < len(network.Headers); pass += 1 {
+		for _, headerId := range sortedKeys(network.Headers) {
+			var inflow, outflow float64
+			var incoming, outgoing []int
+			for k, flowPath := range network.FlowPaths {
+				var toHeader bool = flowPath.DestinationType == "Header" && flowPath.DestinationID == headerId
+				var fromHeader bool = flowPath.SourceType == "Header" && flowPath.SourceID == headerId
+				if (toHeader && flows[k] > 0) || (fromHeader && flows[k] < 0) {
+					inflow += math.Abs(flows[k])
+					incoming = append(incoming, k)
+				} else if (fromHeader && flows[k] > 0) || (toHeader && flows[k] < 0) {
+					outflow += math.Abs(flows[k])
+					outgoing = append(outgoing, k)
+				}
+			}
+			if math.Abs(inflow-outflow) <= flowSolverTolerance {
+				continue
+			}
+			if outflow > inflow {
+				for _, k := range outgoing {
+					flows[k] *= inflow / outflow
+				}
+			} else {
+				for _, k := range incoming {
+					flows[k] *= outflow / inflow
+				}
+			}
+		}
+	}
+	return flows
+}
+
+// nodeTransfers returns the mass every node would give and the volumes of fluid it would give and take in over the
+// time step at the given flows.
+func (network *FluidNetwork) nodeTransfers(flows []float64, connections [][2]pathConnection, deltaTimeSeconds float64) (massOut map[string]float64, volumeOut map[string]float64, volumeIn map[string]float64) {
+	massOut, volumeOut, volumeIn = make(map[string]float64), make(map[string]float64), make(map[string]float64)
+	for k, flowPath := range network.FlowPaths {
+		var upstreamType, upstreamId, downstreamType, downstreamId string = flowPath.SourceType, flowPath.SourceID, flowPath.DestinationType, flowPath.DestinationID
+		var mass float64 = flows[k] * deltaTimeSeconds
+		if mass < 0 {
+			upstreamType, upstreamId, downstreamType, downstreamId = downstreamType, downstreamId, upstreamType, upstreamId
+			mass = -mass
+		}
+		var stream WaterProperties = network.pathStream(k, flows[k] > 0, connections)
+		if upstreamType == "Node" {
+			massOut[upstreamId] += mass
+			volumeOut[upstreamId] += mass / stream.Density
+		}
+		if downstreamType == "Node" {
+			volumeIn[downstreamId] += mass / stream.Density
+		}
+	}
+	return
+}
+
+// skipsVolumeLimit reports whether a node is a closed node that is already full of liquid. Those are left out of the
+// volume check: the solver has already raised its pressure until the net inflow only compresses the liquid as far as
+// its capacitance allows, and its free volume, zero to within rounding, would otherwise stop all flow into it.
+func skipsVolumeLimit(node FluidNode) bool {
+	return node.Closed && node.FreeVolume() < node.MaxVolume*closedNodeFullFraction
+}
+
+// limitFlows keeps every node from giving more mass than it holds or taking in more than fits in it during the time
+// step. Only the flows of the offending node are cut back, its outflows when it would run dry and its inflows when it
+// would overflow, so a nearly empty node does not hold up loops it has no part in. Cutting back a flow into or out of
+// a header unbalances it, so the headers are balanced again, which can in turn change what other nodes give and take
+// in; this is repeated once per node. Whatever is still left over is then scaled down over all flows together, which
+// keeps the headers balanced.
+func (network *FluidNetwork) limitFlows(flows []float64, connections [][2]pathConnection, deltaTimeSeconds float64) []float64 {
+	for pass := 0; pass < len(network.Nodes); pass += 1 {
+		var massOut, volumeOut, volumeIn = network.nodeTransfers(flows, connections, deltaTimeSeconds)
+		var outScale, inScale map[string]float64 = make(map[string]float64), make(map[string]float64)
+		for nodeId, node := range network.Nodes {
+			if massOut[nodeId] > node.Mass { // we can't move more mass than there is in the source
+				outScale[nodeId] = node.Mass / massOut[nodeId]
+			}
+			if !skipsVolumeLimit(node) && volumeIn[nodeId]-volumeOut[nodeId] > node.FreeVolume() { // we can't overfill the destination node
+				inScale[nodeId] = math.Max(math.Max(node.FreeVolume(), 0)+volumeOut[nodeId], 0) / volumeIn[nodeId]
+			}
+		}
+		if len(outScale) == 0 && len(inScale) == 0 {
+			return flows
+		}
+		for k, flowPath := range network.FlowPaths {
+			var upstreamType, upstreamId, downstreamType, downstreamId string = flowPath.SourceType, flowPath.SourceID, flowPath.DestinationType, flowPath.DestinationID
+			if flows[k] < 0 {
+				upstreamType, upstreamId, downstreamType, downstreamId = downstreamType, downstreamId, upstreamType, upstreamId
+			}
+			if scale, ok := outScale[upstreamId]; ok && upstreamType == "Node" {
+				flows[k] *= scale
+			}
+			if scale, ok := inScale[downstreamId]; ok && downstreamType == "Node" {
+				flows[k] *= scale
+			}
+		}
+		flows = network.balanceHeaders(flows)
+	}
+
+	var massOut, volumeOut, volumeIn = network.nodeTransfers(flows, connections, deltaTimeSeconds)
+	var scale float64 = 1
+	for nodeId, node := range network.Nodes {
+		if massOut[nodeId] > node.Mass {
+			scale = math.Min(scale, node.Mass/massOut[nodeId])
+		}
+		if !skipsVolumeLimit(node) && volumeIn[nodeId]-volumeOut[nodeId] > node.FreeVolume() {
+			scale = math.Min(scale, math.Max(node.FreeVolume(), 0)/(volumeIn[nodeId]-volumeOut[nodeId]))
+		}
+	}
+	for i := range flows {
+		flows[i] *= scale
+	}
+	return flows
+}
@@ -0,0 +1,358 @@
+package fluid
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// --- STRUCT DECLARATIONS ---
+type HeatStructureGeometry string
+
+const (
+	Slab     HeatStructureGeometry = "Slab"     // a flat wall, both faces have the same area
+	Cylinder HeatStructureGeometry = "Cylinder" // a tube wall or a solid rod, heat flows radially
+)
+
+// SolidMaterial holds the thermal properties of a solid, taken as constant over the temperature range of the plant.
+type SolidMaterial struct {
+	Density      float64 // kg/m^3
+	SpecificHeat float64 // J/(kg·K)
+	Conductivity float64 // W/(m·K)
+}
+
+// HeatStructureSurface is one face of a heat structure and what it exchanges heat with: the fluid in a node, an
+// ambient at a fixed temperature, or nothing at all.
+type HeatStructureSurface struct {
+	NodeID            string  // the node wetting the surface, empty for an ambient or adiabatic surface
+	JunctionID        string  // the pipe, pump, valve or jet pump whose flow sweeps the surface, empty for a stagnant pool
+	HydraulicDiameter float64 // m
+	FlowArea          float64 // m^2 the flow of JunctionID passes the surface through
+	BottomElevation   float64 // m, the part of the surface below the water line of the node is wetted by liquid
+	TopElevation      float64 // m
+
+	AmbientTemperature             float64 // °C, used when NodeID is empty
+	AmbientHeatTransferCoefficient float64 // W/(m²·K) to the ambient, 0 for an adiabatic surface
+
+	HeatFlow           float64 // W from the structure into the fluid or ambient during the last step
+	SurfaceTemperature float64 // °C at the end of the last step
+}
+
+// HeatStructure is a solid wall, such as a vessel shell, a pipe or a heat exchanger tube bundle, that stores heat and
+// conducts it between its two surfaces in one dimension.
+type HeatStructure struct {
+	Geometry    HeatStructureGeometry
+	Material    SolidMaterial
+	InnerRadius float64 // m, of a cylinder. 0 for a solid rod, whose inner surface does not exist
+	Thickness   float64 // m
+	Area        float64 // m^2 of each face of a slab
+	Length      float64 // m of a cylinder
+
+	Inner HeatStructureSurface // the face at the inner radius of a cylinder or the first face of a slab
+	Outer HeatStructureSurface
+
+	Temperatures []float64 // °C at the centre of each mesh cell, from the inner to the outer surface
+}
+
+// SolidMaterials holds the thermal properties of common structural materials.
+var SolidMaterials map[string]SolidMaterial = map[string]SolidMaterial{
+	"CarbonSteel":    {7850, 490, 45},
+	"StainlessSteel": {7900, 500, 16},
+	"Inconel":        {8440, 444, 15},
+	"Zircaloy":       {6550, 285, 14},
+	"Copper":         {8960, 385, 400},
+	"Concrete":       {2300, 880, 1.4},
+	"Insulation":     {100, 840, 0.04},
+}
+
+// mesh divides the structure into cells of equal thickness and returns their heat capacities in J/K, the conductances
+// between neighbouring cell centres in W/K, and the conductance from the outermost cells to each surface together with
+// the surface areas.
+func (structure HeatStructure) mesh() (capacities []float64, conductances []float64, innerConductance float64, outerConductance float64, innerArea float64, outerArea float64) {
+	var cells int = len(structure.Temperatures)
+	var material SolidMaterial = structure.Material
+	var width float64 = structure.Thickness / float64(cells)
+	capacities = make([]float64, cells)
+	conductances = make([]float64, cells-1)
+	if structure.Geometry == Slab {
+		for i := range capacities {
+			capacities[i] = material.Density * material.SpecificHeat * structure.Area * width
+		}
+		for i := range conductances {
+			conductances[i] = material.Conductivity * structure.Area / width
+		}
+		var surfaceConductance float64 = 2 * material.Conductivity * structure.Area / width
+		return capacities, conductances, surfaceConductance, surfaceConductance, structure.Area, structure.Area
+	}
+
+	var radius = func(edge float64) float64 { return structure.InnerRadius + edge*width }
+	var shell = func(inner float64, outer float64) float64 { // conductance of a cylindrical shell
+		return 2 * math.Pi * material.Conductivity * structure.Length / math.Log(outer/inner)
+	}
+	for i := range capacities {
+		capacities[i] = material.Density * material.SpecificHeat * math.Pi * (math.Pow(radius(float64(i+1)), 2) - math.Pow(radius(float64(i)), 2)) * structure.Length
+	}
+	for i := range conductances {
+		conductances[i] = shell(radius(float64(i)+0.5), radius(float64(i)+1.5))
+	}
+	if structure.InnerRadius > 0 {
+		innerConductance = shell(structure.InnerRadius, radius(0.5))
+		innerArea = 2 * math.Pi * structure.InnerRadius * structure.Length
+	}
+	outerConductance = shell(radius(float64(cells)-0.5), radius(float64(cells)))
+	outerArea = 2 * math.Pi * radius(float64(cells)) * structure.Length
+	return
+}
+
+// surfaceFluid is what a surface sees during one step, evaluated once at its start.
+type surfaceFluid struct {
+	provider      PropertyProvider // equation of state of the network
+	area          float64
+	wetFraction   float64 // part of the surface below the water line
+	liquid        WaterProperties
+	vapour        WaterProperties
+	hasLiquid     bool
+	hasVapour     bool
+	saturated     bool // saturated liquid and vapour exist at the node pressure
+	satLiquid     WaterProperties
+	satVapour     WaterProperties
+	massFlux      float64 // kg/(m²·s)
+	quality       float64
+	diameter      float64 // m, hydraulic diameter
+	height        float64 // m, of the surface
+	ambient       bool
+	ambientT      float64
+	ambientH      float64
+	referenceLow  float64 // °C, lowest fluid temperature the surface can exchange heat with
+	referenceHigh float64 // °C, highest
+}
+
+func (network *FluidNetwork) surfaceFluid(surface HeatStructureSurface, area float64) surfaceFluid {
+	var fluid surfaceFluid = surfaceFluid{provider: network.properties(), area: area, ambientT: surface.AmbientTemperature, ambientH: surface.AmbientHeatTransferCoefficient}
+	if surface.NodeID == "" {
+		fluid.ambient = true
+		fluid.referenceLow, fluid.referenceHigh = surface.AmbientTemperature, surface.AmbientTemperature
+		return fluid
+	}
+	var node FluidNode = network.Nodes[surface.NodeID]
+	var pressure float64 = node.Pressure / 1000000
+	fluid.diameter = surface.HydraulicDiameter
+	fluid.height = math.Max(surface.TopElevation-surface.BottomElevation, 0.01)
+	if node.Pressure < CriticalPressure {
+		fluid.saturated = true
+		fluid.satLiquid, fluid.satVapour = saturatedPhase(network.properties(), pressure, 0), saturatedPhase(network.properties(), pressure, 1)
+	}
+	switch {
+	case node.IsTwoPhase():
+		fluid.liquid, fluid.vapour = fluid.satLiquid, fluid.satVapour
+		fluid.hasLiquid, fluid.hasVapour = true, true
+		fluid.quality = node.Quality
+	case node.Quality >= 1:
+		fluid.vapour = network.properties().Ph(pressure, node.Enthalpy/1000)
+		fluid.hasVapour = true
+	default:
+		fluid.liquid = network.properties().Ph(pressure, node.Enthalpy/1000)
+		fluid.hasLiquid = true
+	}
+
+	var _, swollenLevel = node.WaterLevels()
+	if !fluid.hasLiquid {
+		fluid.wetFraction = 0
+	} else if node.Closed && !fluid.hasVapour {
+		fluid.wetFraction = 1 // a sealed vessel full of liquid
+	} else if surface.TopElevation > surface.BottomElevation {
+		fluid.wetFraction = math.Min(math.Max((swollenLevel-surface.BottomElevation)/(surface.TopElevation-surface.BottomElevation), 0), 1)
+	} else if swollenLevel >= surface.BottomElevation {
+		fluid.wetFraction = 1
+	}
+	if element, ok := network.junction(surface.JunctionID); ok && surface.FlowArea > 0 {
+		fluid.massFlux = math.Abs(element.massFlowRate()) / surface.FlowArea
+	}
+
+	fluid.referenceLow, fluid.referenceHigh = node.Temperature, node.Temperature
+	for _, state := range []WaterProperties{fluid.liquid, fluid.vapour, fluid.satLiquid} {
+		if state.Density > 0 {
+			fluid.referenceLow = math.Min(fluid.referenceLow, state.Temperature)
+			fluid.referenceHigh = math.Max(fluid.referenceHigh, state.Temperature)
+		}
+	}
+	return fluid
+}
+
+// saturatedPhase returns the properties of saturated liquid (quality 0) or vapour (quality 1). Heat capacity and the
+// properties derived from it are undefined on the saturation line itself, so they are taken just inside the phase.
+func saturatedPhase(provider PropertyProvider, pressureMPa float64, quality float64) WaterProperties {
+	var saturated WaterProperties = provider.Px(pressureMPa, quality)
+	var offset float64 = -0.001 // K into the liquid
+	if quality > 0 {
+		offset = 0.001
+	}
+	var phase WaterProperties = provider.Pt(pressureMPa, saturated.Temperature+offset)
+	phase.Temperature, phase.Enthalpy, phase.Quality = saturated.Temperature, saturated.Enthalpy, quality
+	return phase
+}
+
+// heatFlow returns the heat in W that flows from the surface into the fluid at the given surface temperature. Below
+// the water line the liquid is heated by forced or natural convection, whichever is larger, or boils with Chen once
+// the wall is above saturation and boiling carries more. Above it steam condenses on a wall below saturation and is otherwise heated by convection.
+func (fluid surfaceFluid) heatFlow(wallTemperature float64) float64 {
+	if fluid.ambient {
+		return fluid.ambientH * fluid.area * (wallTemperature - fluid.ambientT)
+	}
+	var convection = func(state WaterProperties) float64 {
+		var reynoldsNumber float64 = fluid.massFlux * fluid.diameter / state.DynamicViscosity
+		var forced float64 = DittusBoelterCoefficient(reynoldsNumber, state.PrandtlNumber, state.ThermalConductivity, fluid.diameter, wallTemperature > state.Temperature)
+		var natural float64 = NaturalConvectionCoefficient(state, wallTemperature-state.Temperature, fluid.height)
+		return math.Max(forced, natural) * (wallTemperature - state.Temperature)
+	}
+
+	var wetted, dry float64
+	if fluid.wetFraction > 0 {
+		wetted = convection(fluid.liquid)
+		if fluid.saturated && wallTemperature > fluid.satLiquid.Temperature {
+			wetted = math.Max(wetted, ChenBoilingHeatFlux(fluid.provider, fluid.satLiquid, fluid.satVapour, fluid.massFlux, fluid.quality, fluid.diameter, wallTemperature, fluid.liquid.Temperature))
+		}
+	}
+	if fluid.wetFraction < 1 && fluid.hasVapour {
+		if fluid.saturated && wallTemperature < fluid.satVapour.Temperature {
+			dry = CondensationCoefficient(fluid.satLiquid, fluid.satVapour, wallTemperature, fluid.height) * (wallTemperature - fluid.satVapour.Temperature)
+		} else {
+			dry = convection(fluid.vapour)
+		}
+	}
+	return fluid.area * (fluid.wetFraction*wetted + (1-fluid.wetFraction)*dry)
+}
+
+// linearizeSurface returns the heat flow from the outermost cell through the surface into the fluid as a linear
+// function offset + slope*T of the cell temperature, together with the current surface temperature. The surface
+// temperature is where the conduction through the half cell matches the heat flow into the fluid, and the heat flow
+// is linearized around it, so the conduction can be solved implicitly with the heat transfer coefficient of the step.
+func linearizeSurface(fluid surfaceFluid, cellTemperature float64, conductance float64) (offset float64, slope float64, surfaceTemperature float64) {
+	if conductance == 0 || fluid.area == 0 {
+		return 0, 0, cellTemperature
+	}
+	var low float64 = math.Min(cellTemperature, fluid.referenceLow) - 1
+	var high float64 = math.Max(cellTemperature, fluid.referenceHigh) + 1
+	for i := 0; i < 60 && high-low > 0.000001; i += 1 { // conduction falls and the heat flow rises with the surface temperature
+		surfaceTemperature = (low + high) / 2
+		if conductance*(cellTemperature-surfaceTemperature) > fluid.heatFlow(surfaceTemperature) {
+			low = surfaceTemperature
+		} else {
+			high = surfaceTemperature
+		}
+	}
+	surfaceTemperature = (low + high) / 2
+	var heatFlow float64 = fluid.heatFlow(surfaceTemperature)
+	var derivative float64 = math.Max((fluid.heatFlow(surfaceTemperature+0.01)-fluid.heatFlow(surfaceTemperature-0.01))/0.02, 0)
+	// q = G*(Tc - Tw) = q0 + q'*(Tw - Tw0), eliminating Tw gives q = G/(G+q') * (q0 + q'*(Tc - Tw0))
+	var weight float64 = conductance / (conductance + derivative)
+	return weight * (heatFlow - derivative*surfaceTemperature), weight * derivative, surfaceTemperature
+}
+
+// SimulateHeatTransfer conducts heat through every heat structure for one time step and exchanges it with the nodes
+// and ambients at their surfaces. The conduction is implicit, so thin walls stay stable at large time steps. The heat
+// is added to the nodes after the flow step, at their current pressure. Structures without mesh cells hold no
+// heat and are skipped.
+func (network *FluidNetwork) SimulateHeatTransfer(deltaTime time.Duration) {
+	var deltaTimeSeconds float64 = deltaTime.Seconds()
+	var energyChange map[string]float64 = make(map[string]float64)
+	for _, structureId := range sortedKeys(network.HeatStructures) {
+		var structure HeatStructure = network.HeatStructures[structureId]
+		var cells int = len(structure.Temperatures)
+		if cells == 0 {
+			continue
+		}
+		var capacities, conductances, innerConductance, outerConductance, innerArea, outerArea = structure.mesh()
+		var innerOffset, innerSlope, _ = linearizeSurface(network.surfaceFluid(structure.Inner, innerArea), structure.Temperatures[0], innerConductance)
+		var outerOffset, outerSlope, _ = linearizeSurface(network.surfaceFluid(structure.Outer, outerArea), structure.Temperatures[cells-1], outerConductance)
+
+		// C_i/dt*(T_i' - T_i) = G_(i-1)*(T_(i-1)' - T_i') + G_i*(T_(i+1)' - T_i') - q_surface, solved with the Thomas algorithm
+		var lower, diagonal, upper, rhs []float64 = make([]float64, cells), make([]float64, cells), make([]float64, cells), make([]float64, cells)
+		for i := 0; i < cells; i += 1 {
+			diagonal[i] = capacities[i] / deltaTimeSeconds
+			rhs[i] = capacities[i] / deltaTimeSeconds * structure.Temperatures[i]
+			if i > 0 {
+				lower[i] = -conductances[i-1]
+				diagonal[i] += conductances[i-1]
+			}
+			if i < cells-1 {
+				upper[i] = -conductances[i]
+				diagonal[i] += conductances[i]
+			}
+		}
+		diagonal[0] += innerSlope
+		rhs[0] -= innerOffset
+		diagonal[cells-1] += outerSlope
+		rhs[cells-1] -= outerOffset
+		for i := 1; i < cells; i += 1 {
+			var factor float64 = lower[i] / diagonal[i-1]
+			diagonal[i] -= factor * upper[i-1]
+			rhs[i] -= factor * rhs[i-1]
+		}
+		structure.Temperatures[cells-1] = rhs[cells-1] / diagonal[cells-1]
+		for i := cells - 2; i >= 0; i -= 1 {
+			structure.Temperatures[i] = (rhs[i] - upper[i]*structure.Temperatures[i+1]) / diagonal[i]
+		}
+
+		structure.Inner.HeatFlow = innerOffset + innerSlope*structure.Temperatures[0]
+		structure.Outer.HeatFlow = outerOffset + outerSlope*structure.Temperatures[cells-1]
+		structure.Inner.SurfaceTemperature = structure.Temperatures[0]
+		if innerConductance > 0 {
+			structure.Inner.SurfaceTemperature -= structure.Inner.HeatFlow / innerConductance
+		}
+		structure.Outer.SurfaceTemperature = structure.Temperatures[cells-1] - structure.Outer.HeatFlow/outerConductance
+		for _, surface := range []HeatStructureSurface{structure.Inner, structure.Outer} {
+			if surface.NodeID != "" {
+				energyChange[surface.NodeID] += surface.HeatFlow * deltaTimeSeconds
+			}
+		}
+		network.HeatStructures[structureId] = structure
+	}
+	network.addHeat(energyChange, deltaTimeSeconds)
+}
+
+// DepositHeat adds heat to a node at the given power in W over one time step, like the fission and decay heat of the
+// core does to the coolant around it.
+func (network *FluidNetwork) DepositHeat(nodeId string, power float64, deltaTime time.Duration) error {
+	if _, ok := network.Nodes[nodeId]; !ok {
+		return fmt.Errorf("node %q does not exist", nodeId)
+	}
+	network.addHeat(map[string]float64{nodeId: power * deltaTime.Seconds()}, deltaTime.Seconds())
+	return nil
+}
+
+// addHeat adds the given energy in J to the nodes, and counts the vapour it produces into their vapour generation
+// rate.
+func (network *FluidNetwork) addHeat(energyChange map[string]float64, deltaTimeSeconds float64) {
+	for _, nodeId := range sortedKeys(energyChange) {
+		var node FluidNode = network.Nodes[nodeId]
+		if energyChange[nodeId] == 0 || node.Mass <= 0.001 {
+			continue
+		}
+		var vaporMassBefore float64 = node.VaporMass
+		node = setNodeSpecificEnergy(node, nodeSpecificEnergy(node)+energyChange[nodeId]/node.Mass)
+		node = resolveNode(network.properties(), node)
+		node.VaporGenerationRate += max(node.VaporMass-vaporMassBefore, 0) / deltaTimeSeconds
+		network.Nodes[nodeId] = node
+	}
+}
+
+// WallHeatTransfer returns the heat transfer coefficient in W/(m²·K) from a wall at the given temperature, spanning
+// the height of a node, into its fluid flowing past at the given mass flux in kg/(m²·s), together with the fluid
+// temperature it refers to. It uses the same correlations as the surfaces of heat structures, for walls the network
+// does not own, such as the fuel rods of the core.
+func (network *FluidNetwork) WallHeatTransfer(nodeId string, hydraulicDiameter float64, massFlux float64, wallTemperature float64) (coefficient float64, fluidTemperature float64, err error) {
+	var node, ok = network.Nodes[nodeId]
+	if !ok {
+		return 0, 0, fmt.Errorf("node %q does not exist", nodeId)
+	}
+	var surface HeatStructureSurface = HeatStructureSurface{NodeID: nodeId, HydraulicDiameter: hydraulicDiameter, BottomElevation: node.BottomElevation, TopElevation: node.TopElevation}
+	var fluid surfaceFluid = network.surfaceFluid(surface, 1)
+	fluid.massFlux = massFlux
+	fluidTemperature = node.Temperature
+	var difference float64 = wallTemperature - fluidTemperature
+	if math.Abs(difference) < 0.01 { // the coefficient at the limit of no temperature difference
+		difference = 0.01
+	}
+	return fluid.heatFlow(fluidTemperature+difference) / difference, fluidTemperature, nil
+}
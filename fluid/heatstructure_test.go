@@ -0,0 +1,127 @@
+package fluid
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// uniformTemperatures returns the cell temperatures of a heat structure at one temperature throughout.
+func uniformTemperatures(cells int, temperature float64) []float64 {
+	var temperatures []float64 = make([]float64, cells)
+	for i := range temperatures {
+		temperatures[i] = temperature
+	}
+	return temperatures
+}
+
+func TestSteadyConduction(t *testing.T) {
+	// between two ambients the wall settles at the heat flow of the three resistances in series
+	var steel SolidMaterial = SolidMaterials["CarbonSteel"]
+	var inner HeatStructureSurface = HeatStructureSurface{AmbientTemperature: 200, AmbientHeatTransferCoefficient: 500}
+	var outer HeatStructureSurface = HeatStructureSurface{AmbientTemperature: 20, AmbientHeatTransferCoefficient: 10}
+	var innerRadius, thickness, length float64 = 0.1, 0.05, 3
+	var outerRadius float64 = innerRadius + thickness
+	var tests = []struct {
+		name       string
+		structure  HeatStructure
+		resistance float64 // K/W
+		outerArea  float64 // m^2
+	}{
+		{
+			"slab",
+			HeatStructure{Geometry: Slab, Material: steel, Thickness: thickness, Area: 2},
+			1/(500*2.0) + thickness/(steel.Conductivity*2) + 1/(10*2.0),
+			2,
+		},
+		{
+			"cylinder",
+			HeatStructure{Geometry: Cylinder, Material: steel, InnerRadius: innerRadius, Thickness: thickness, Length: length},
+			1/(500*2*math.Pi*innerRadius*length) + math.Log(outerRadius/innerRadius)/(2*math.Pi*steel.Conductivity*length) + 1/(10*2*math.Pi*outerRadius*length),
+			2 * math.Pi * outerRadius * length,
+		},
+	}
+	for _, test := range tests {
+		var structure HeatStructure = test.structure
+		structure.Inner, structure.Outer = inner, outer
+		structure.Temperatures = uniformTemperatures(10, 20)
+		var network *FluidNetwork = &FluidNetwork{Nodes: map[string]FluidNode{}, HeatStructures: map[string]HeatStructure{"Wall": structure}}
+		for i := 0; i < 200; i += 1 {
+			network.SimulateHeatTransfer(time.Hour)
+		}
+		structure = network.HeatStructures["Wall"]
+		var want float64 = (inner.AmbientTemperature - outer.AmbientTemperature) / test.resistance
+		if !closeTo(structure.Outer.HeatFlow, want, 1e-4) || !closeTo(-structure.Inner.HeatFlow, want, 1e-4) {
+			t.Errorf("%s: heat flow %v W in and %v W out, want %v W", test.name, -structure.Inner.HeatFlow, structure.Outer.HeatFlow, want)
+		}
+		var surface float64 = outer.AmbientTemperature + want/(outer.AmbientHeatTransferCoefficient*test.outerArea)
+		if math.Abs(structure.Outer.SurfaceTemperature-surface) > 0.01 {
+			t.Errorf("%s: outer surface at %v °C, want %v °C", test.name, structure.Outer.SurfaceTemperature, surface)
+		}
+	}
+}
+
+func TestHeatStructureConservesEnergy(t *testing.T) {
+	// a hot plate in a tank of cold water gives the water exactly the heat it loses, adiabatic on its back
+	var steel SolidMaterial = SolidMaterials["StainlessSteel"]
+	var network *FluidNetwork = &FluidNetwork{
+		Nodes: map[string]FluidNode{"Tank": initializeNode(DefaultPropertyProvider(), FluidNode{
+			Temperature: 30, Pressure: 101325, Volume: 10, MaxVolume: 20, BottomElevation: 0, TopElevation: 4,
+		})},
+		HeatStructures: map[string]HeatStructure{"Plate": {
+			Geometry: Slab, Material: steel, Thickness: 0.02, Area: 1,
+			Inner:        HeatStructureSurface{NodeID: "Tank", HydraulicDiameter: 1, BottomElevation: 0, TopElevation: 1},
+			Temperatures: uniformTemperatures(5, 200),
+		}},
+	}
+	var tank FluidNode = network.Nodes["Tank"]
+	var wallEnergy = func(structure HeatStructure) (energy float64) {
+		var capacities, _, _, _, _, _ = structure.mesh()
+		for i, temperature := range structure.Temperatures {
+			energy += capacities[i] * temperature
+		}
+		return
+	}
+	var before float64 = wallEnergy(network.HeatStructures["Plate"])
+	for i := 0; i < 60; i += 1 {
+		network.SimulateHeatTransfer(time.Second)
+	}
+	var released float64 = before - wallEnergy(network.HeatStructures["Plate"])
+	var absorbed float64 = network.Nodes["Tank"].Mass*network.Nodes["Tank"].Enthalpy - tank.Mass*tank.Enthalpy
+	if released <= 0 || !closeTo(absorbed, released, 1e-6) {
+		t.Errorf("the plate released %v J and the water absorbed %v J", released, absorbed)
+	}
+	if water := network.Nodes["Tank"].Temperature; water <= tank.Temperature {
+		t.Errorf("water at %v °C after the plate cooled, want above %v °C", water, tank.Temperature)
+	}
+}
+
+func TestHeatStructureWithoutCells(t *testing.T) {
+	var structure HeatStructure = HeatStructure{Geometry: Slab, Material: SolidMaterials["CarbonSteel"], Thickness: 0.01, Area: 1,
+		Outer: HeatStructureSurface{AmbientTemperature: 20, AmbientHeatTransferCoefficient: 10}}
+	var network *FluidNetwork = &FluidNetwork{Nodes: map[string]FluidNode{}, HeatStructures: map[string]HeatStructure{"Wall": structure}}
+	network.SimulateHeatTransfer(time.Second) // must not panic
+	if flow := network.HeatStructures["Wall"].Outer.HeatFlow; flow != 0 {
+		t.Errorf("heat flow %v W from a wall without cells, want none", flow)
+	}
+}
+
+func TestDittusBoelterCoefficient(t *testing.T) {
+	var tests = []struct {
+		name              string
+		reynolds, prandtl float64
+		heating           bool
+		nusselt           float64
+	}{
+		{"turbulent, heating", 1e5, 2, true, 230 * math.Pow(2, 0.4)},
+		{"turbulent, cooling", 1e5, 2, false, 230 * math.Pow(2, 0.3)},
+		{"reverse flow", -1e5, 1, true, 230},
+		{"laminar floor", 100, 1, true, laminarNusseltNumber},
+	}
+	for _, test := range tests {
+		var got float64 = DittusBoelterCoefficient(test.reynolds, test.prandtl, 0.6, 0.01, test.heating)
+		if want := test.nusselt * 0.6 / 0.01; !closeTo(got, want, 1e-9) {
+			t.Errorf("%s: h = %v W/(m²·K), want %v", test.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package fluid
+
+import "math"
+
+// --- CONSTANT DECLARATIONS ---
+const laminarNusseltNumber float64 = 4.36 // fully developed laminar flow in a tube at constant heat flux
+
+// DittusBoelterCoefficient returns the heat transfer coefficient in W/(m²·K) of turbulent forced convection,
+// Nu = 0.023·Re^0.8·Pr^n with n = 0.4 when the wall heats the fluid and 0.3 when it cools it. It never falls below the
+// laminar value, so it stays usable down to zero flow.
+func DittusBoelterCoefficient(reynoldsNumber float64, prandtlNumber float64, conductivity float64, hydraulicDiameter float64, heating bool) float64 {
+	var exponent float64 = 0.3
+	if heating {
+		exponent = 0.4
+	}
+	var nusseltNumber float64 = 0.023 * math.Pow(math.Abs(reynoldsNumber), 0.8) * math.Pow(prandtlNumber, exponent)
+	return math.Max(nusseltNumber, laminarNusseltNumber) * conductivity / hydraulicDiameter
+}
+
+// NaturalConvectionCoefficient returns the heat transfer coefficient in W/(m²·K) of free convection along a vertical
+// surface of the given height, using the Churchill-Chu correlation over the whole laminar and turbulent range.
+func NaturalConvectionCoefficient(fluid WaterProperties, temperatureDifference float64, height float64) float64 {
+	var rayleighNumber float64 = gravity * math.Abs(fluid.IsobaricExpansionCoefficient*temperatureDifference) * math.Pow(height, 3) /
+		(fluid.KinematicViscosity * fluid.ThermalDiffusivity)
+	var prandtlFactor float64 = math.Pow(1+math.Pow(0.492/fluid.PrandtlNumber, 9.0/16), 8.0/27)
+	var nusseltNumber float64 = math.Pow(0.825+0.387*math.Pow(rayleighNumber, 1.0/6)/prandtlFactor, 2)
+	return nusseltNumber * fluid.ThermalConductivity / height
+}
+
+// ChenBoilingHeatFlux returns the heat flux in W/m² from a wall into boiling water with the Chen correlation. The
+// macroscopic part is Dittus-Boelter for the liquid fraction of the flow, enhanced by the two-phase factor F; the
+// microscopic part is Forster-Zuber nucleate boiling, suppressed by the factor S as the flow speeds up. The
+// macroscopic part is driven by the liquid temperature, the microscopic part by the wall superheat, so it also covers
+// subcooled boiling. Below saturation the wall does not boil and only the macroscopic part is left. The provider gives
+// the saturation pressure at the wall temperature.
+func ChenBoilingHeatFlux(provider PropertyProvider, liquid WaterProperties, vapour WaterProperties, massFlux float64, quality float64, hydraulicDiameter float64, wallTemperature float64, liquidTemperature float64) float64 {
+	quality = math.Min(math.Max(quality, 0), 0.99)
+	var reynoldsFactor float64 = 1 // F, 1 without vapour in the flow
+	if quality > 0 {
+		var martinelli float64 = math.Pow((1-quality)/quality, 0.9) * math.Sqrt(vapour.Density/liquid.Density) * math.Pow(liquid.DynamicViscosity/vapour.DynamicViscosity, 0.1)
+		if 1/martinelli > 0.1 {
+			reynoldsFactor = 2.35 * math.Pow(1/martinelli+0.213, 0.736)
+		}
+	}
+	var liquidReynolds float64 = math.Abs(massFlux) * (1 - quality) * hydraulicDiameter / liquid.DynamicViscosity
+	var macroscopic float64 = reynoldsFactor * DittusBoelterCoefficient(liquidReynolds, liquid.PrandtlNumber, liquid.ThermalConductivity, hydraulicDiameter, true)
+	var heatFlux float64 = macroscopic * (wallTemperature - liquidTemperature)
+
+	var saturationTemperature float64 = liquid.Temperature
+	var superheat float64 = wallTemperature - saturationTemperature
+	if superheat <= 0 {
+		return heatFlux
+	}
+	var suppression float64 = 1 / (1 + 2.53e-6*math.Pow(liquidReynolds*math.Pow(reynoldsFactor, 1.25), 1.17))
+	var pressureDifference float64 = math.Max(provider.Property(TX, math.Min(wallTemperature, CriticalTemperature), 0, PRESSURE)*1000000-liquid.Pressure*1000000, 0)
+	var latentHeat float64 = (vapour.Enthalpy - liquid.Enthalpy) * 1000
+	var microscopic float64 = 0.00122 * math.Pow(liquid.ThermalConductivity, 0.79) * math.Pow(liquid.IsobaricHeatCapacity*1000, 0.45) * math.Pow(liquid.Density, 0.49) /
+		(math.Sqrt(liquid.SurfaceTension/1000) * math.Pow(liquid.DynamicViscosity, 0.29) * math.Pow(latentHeat, 0.24) * math.Pow(vapour.Density, 0.24)) *
+		math.Pow(superheat, 0.24) * math.Pow(pressureDifference, 0.75)
+	return heatFlux + suppression*microscopic*superheat
+}
+
+// CondensationCoefficient returns the heat transfer coefficient in W/(m²·K) of saturated steam condensing in a laminar
+// film on a vertical wall of the given height that is colder than saturation, after Nusselt, with the latent heat
+// corrected for the subcooling of the film.
+func CondensationCoefficient(liquid WaterProperties, vapour WaterProperties, wallTemperature float64, height float64) float64 {
+	var subcooling float64 = math.Max(liquid.Temperature-wallTemperature, 0.001)
+	var latentHeat float64 = (vapour.Enthalpy-liquid.Enthalpy)*1000 + 0.68*liquid.IsobaricHeatCapacity*1000*subcooling
+	return 0.943 * math.Pow(liquid.Density*(liquid.Density-vapour.Density)*gravity*latentHeat*math.Pow(liquid.ThermalConductivity, 3)/
+		(liquid.DynamicViscosity*height*subcooling), 0.25)
+}
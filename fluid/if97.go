@@ -0,0 +1,410 @@
+package fluid
+
+import "math"
+
+// Backward functions (temperature from pressure and enthalpy, pressure from enthalpy and entropy, ...) are obtained by
+// inverting the IF97 forward equations numerically, starting from the IF97 backward equations where the release gives
+// them, see if97_backward.go. This keeps them consistent with the forward equations to solver precision instead of the
+// few mK tolerance of the backward equations, and covers regions 3 and 5 as well.
+
+// --- STRUCT DECLARATIONS ---
+
+// if97State is a single fully resolved state point. Units follow IF97: MPa, K, kJ/kg, kJ/(kg·K), m^3/kg.
+type if97State struct {
+	region          int     // 1, 2, 3 or 5 for single phase states, 4 for a saturated mixture
+	pressure        float64 // MPa
+	temperature     float64 // K
+	specificVolume  float64 // m^3/kg
+	enthalpy        float64 // kJ/kg
+	entropy         float64 // kJ/(kg·K)
+	internalEnergy  float64 // kJ/kg
+	cp              float64 // kJ/(kg·K), NaN inside the two-phase dome
+	cv              float64 // kJ/(kg·K), NaN inside the two-phase dome
+	speedOfSound    float64 // m/s, NaN inside the two-phase dome
+	expansion       float64 // 1/K, isobaric cubic expansion coefficient, NaN inside the two-phase dome
+	compressibility float64 // 1/MPa, isothermal compressibility, NaN inside the two-phase dome
+	quality         float64 // vapour mass fraction, 0 for liquid-like and 1 for vapour-like single phase states
+	liquidDensity   float64 // kg/m^3, density of the liquid phase of a saturated mixture
+	vapourDensity   float64 // kg/m^3, density of the vapour phase of a saturated mixture
+}
+
+// --- STATE CONSTRUCTION ---
+
+// gibbsState converts the dimensionless Gibbs free energy of region 1, 2 or 5 into a state point.
+func gibbsState(region int, pressureMPa float64, temperatureK float64, pi float64, tau float64, gamma if97Derivatives) (state if97State) {
+	state.region = region
+	state.pressure = pressureMPa
+	state.temperature = temperatureK
+	state.specificVolume = pi * gamma.fA * if97R * temperatureK / pressureMPa / 1000
+	state.enthalpy = if97R * temperatureK * tau * gamma.fT
+	state.entropy = if97R * (tau*gamma.fT - gamma.f)
+	state.internalEnergy = if97R * temperatureK * (tau*gamma.fT - pi*gamma.fA)
+	state.cp = -if97R * tau * tau * gamma.fTT
+	var a float64 = gamma.fA - tau*gamma.fAT
+	state.cv = if97R * (-tau*tau*gamma.fTT + a*a/gamma.fAA)
+	state.speedOfSound = math.Sqrt(1000 * if97R * temperatureK * gamma.fA * gamma.fA / (a*a/(tau*tau*gamma.fTT) - gamma.fAA))
+	state.expansion = (1 - tau*gamma.fAT/gamma.fA) / temperatureK
+	state.compressibility = -pi * gamma.fAA / gamma.fA / pressureMPa
+	if region != 1 {
+		state.quality = 1
+	}
+	return
+}
+
+func region1State(pressureMPa float64, temperatureK float64) if97State {
+	return gibbsState(1, pressureMPa, temperatureK, pressureMPa/16.53, 1386/temperatureK, region1Gibbs(pressureMPa, temperatureK))
+}
+
+func region2State(pressureMPa float64, temperatureK float64) if97State {
+	return gibbsState(2, pressureMPa, temperatureK, pressureMPa, 540/temperatureK, region2Gibbs(pressureMPa, temperatureK))
+}
+
+func region5State(pressureMPa float64, temperatureK float64) if97State {
+	return gibbsState(5, pressureMPa, temperatureK, pressureMPa, 1000/temperatureK, region5Gibbs(pressureMPa, temperatureK))
+}
+
+func region3State(densityKGM3 float64, temperatureK float64) (state if97State) {
+	var phi if97Derivatives = region3Helmholtz(densityKGM3, temperatureK)
+	var delta float64 = densityKGM3 / if97CriticalRho
+	var tau float64 = if97CriticalT / temperatureK
+	state.region = 3
+	state.temperature = temperatureK
+	state.pressure = densityKGM3 * if97R * temperatureK * delta * phi.fA / 1000
+	state.specificVolume = 1 / densityKGM3
+	state.enthalpy = if97R * temperatureK * (tau*phi.fT + delta*phi.fA)
+	state.entropy = if97R * (tau*phi.fT - phi.f)
+	state.internalEnergy = if97R * temperatureK * tau * phi.fT
+	state.cv = -if97R * tau * tau * phi.fTT
+	var a float64 = delta*phi.fA - delta*tau*phi.fAT
+	var b float64 = 2*delta*phi.fA + delta*delta*phi.fAA
+	state.cp = if97R * (-tau*tau*phi.fTT + a*a/b)
+	state.speedOfSound = math.Sqrt(1000 * if97R * temperatureK * (b - a*a/(tau*tau*phi.fTT)))
+	state.compressibility = 1000 / (densityKGM3 * if97R * temperatureK * b)  // 1/(ρ·(∂p/∂ρ)T)
+	state.expansion = state.compressibility * densityKGM3 * if97R * a / 1000 // κT·(∂p/∂T)ρ
+	if densityKGM3 < if97CriticalRho {
+		state.quality = 1
+	}
+	return
+}
+
+// region3Density solves the region 3 equation for the density at the given pressure and temperature.
+// Below the critical temperature the liquid flag picks between the liquid-like and the vapour-like root.
+func region3Density(pressureMPa float64, temperatureK float64, liquid bool) float64 {
+	var liquidEstimate, vapourEstimate = saturatedDensityEstimates(temperatureK)
+	var low, high, guess float64 = 20, 800, if97CriticalRho
+	if temperatureK < if97CriticalT {
+		if liquid {
+			low, guess = liquidEstimate, liquidEstimate
+		} else {
+			high, guess = vapourEstimate, vapourEstimate
+		}
+	}
+	return if97Solve(func(density float64) (float64, float64) {
+		var pressure, slope = region3Pressure(density, temperatureK)
+		return pressure - pressureMPa, slope
+	}, low, high, guess)
+}
+
+// singlePhaseState evaluates the IF97 region that contains the given pressure and temperature.
+// The liquid flag decides which side of the saturation line is meant when the point lies on (or numerically next to) it.
+func singlePhaseState(pressureMPa float64, temperatureK float64, liquid bool) if97State {
+	switch {
+	case temperatureK <= if97Region13T && liquid:
+		return region1State(pressureMPa, temperatureK)
+	case temperatureK <= if97Region13T:
+		return region2State(pressureMPa, temperatureK)
+	case temperatureK > if97Region25T && pressureMPa <= if97Region5MaxP:
+		return region5State(pressureMPa, temperatureK)
+	case temperatureK > if97Region23MaxT || pressureMPa <= regionB23Pressure(temperatureK):
+		return region2State(pressureMPa, temperatureK)
+	default:
+		return region3State(region3Density(pressureMPa, temperatureK, liquid), temperatureK)
+	}
+}
+
+// saturatedStates returns the saturated liquid and vapour states at the given pressure.
+func saturatedStates(pressureMPa float64) (liquid if97State, vapour if97State) {
+	var temperatureK float64 = saturationTemperature(pressureMPa)
+	if temperatureK <= if97Region13T {
+		return region1State(pressureMPa, temperatureK), region2State(pressureMPa, temperatureK)
+	}
+	var liquidEstimate, vapourEstimate = saturatedDensityEstimates(temperatureK)
+	liquid = region3State(region3SaturatedDensity(pressureMPa, temperatureK, liquidEstimate), temperatureK)
+	vapour = region3State(region3SaturatedDensity(pressureMPa, temperatureK, vapourEstimate), temperatureK)
+	liquid.quality = 0
+	vapour.quality = 1
+	return
+}
+
+// region3SaturatedDensity polishes an auxiliary saturated density with Newton steps on the region 3 equation.
+// A bracketing solver can not be used here because the van der Waals loop puts a third, unstable root between the phases.
+func region3SaturatedDensity(pressureMPa float64, temperatureK float64, estimate float64) float64 {
+	var density float64 = estimate
+	for i := 0; i < 50; i += 1 {
+		var pressure, slope = region3Pressure(density, temperatureK)
+		if slope <= 0 {
+			break // past the spinodal, keep the last good estimate
+		}
+		var step float64 = (pressure - pressureMPa) / slope
+		density -= step
+		if math.Abs(step) < 1e-10*density {
+			break
+		}
+	}
+	return density
+}
+
+// mixtureState builds a saturated two-phase state from its saturated liquid and vapour states.
+func mixtureState(liquid if97State, vapour if97State, quality float64) (state if97State) {
+	state.region = 4
+	state.pressure = liquid.pressure
+	state.temperature = liquid.temperature
+	state.quality = quality
+	state.specificVolume = liquid.specificVolume + quality*(vapour.specificVolume-liquid.specificVolume)
+	state.enthalpy = liquid.enthalpy + quality*(vapour.enthalpy-liquid.enthalpy)
+	state.entropy = liquid.entropy + quality*(vapour.entropy-liquid.entropy)
+	state.internalEnergy = liquid.internalEnergy + quality*(vapour.internalEnergy-liquid.internalEnergy)
+	state.cp = math.NaN()
+	state.cv = math.NaN()
+	state.speedOfSound = math.NaN()
+	state.expansion = math.NaN()
+	state.compressibility = math.NaN()
+	state.liquidDensity = 1 / liquid.specificVolume
+	state.vapourDensity = 1 / vapour.specificVolume
+	return
+}
+
+// --- LOOKUPS BY INPUT PAIR ---
+
+func if97StatePT(pressureMPa float64, temperatureK float64) if97State {
+	var liquid bool = temperatureK < if97CriticalT && pressureMPa >= saturationPressure(temperatureK)
+	return singlePhaseState(pressureMPa, temperatureK, liquid)
+}
+
+// if97StateByTemperature resolves a state at fixed pressure from a temperature dependent property that rises
+// monotonically with temperature, such as enthalpy or entropy. estimate, if not nil, returns the temperature of the
+// IF97 backward equation of the liquid or vapour side, from which the iteration starts.
+func if97StateByTemperature(pressureMPa float64, target float64, property func(state if97State) (value float64, slope float64), estimate func(liquid bool) float64) if97State {
+	var low, high float64 = if97MinT, if97MaxT
+	if pressureMPa > if97Region5MaxP {
+		high = if97Region25T // region 5 ends at 50 MPa and region 2 is not valid above 1073.15 K
+	}
+	var liquid bool = true
+	if pressureMPa < if97CriticalP {
+		var saturatedLiquid, saturatedVapour = saturatedStates(pressureMPa)
+		var liquidValue, _ = property(saturatedLiquid)
+		var vapourValue, _ = property(saturatedVapour)
+		switch {
+		case target < liquidValue:
+			high = saturatedLiquid.temperature
+		case target > vapourValue:
+			low = saturatedVapour.temperature
+			liquid = false
+		default:
+			return mixtureState(saturatedLiquid, saturatedVapour, (target-liquidValue)/(vapourValue-liquidValue))
+		}
+	}
+	var guess float64 = (low + high) / 2
+	if estimate != nil {
+		guess = estimate(liquid)
+	}
+	var temperatureK float64 = if97Solve(func(temperature float64) (float64, float64) {
+		var value, slope = property(singlePhaseState(pressureMPa, temperature, liquid))
+		return value - target, slope
+	}, low, high, guess)
+	return singlePhaseState(pressureMPa, temperatureK, liquid)
+}
+
+func if97StatePH(pressureMPa float64, enthalpyKJKG float64) if97State {
+	return if97StateByTemperature(pressureMPa, enthalpyKJKG, func(state if97State) (float64, float64) {
+		return state.enthalpy, state.cp
+	}, func(liquid bool) float64 {
+		if liquid {
+			return region1TemperaturePH(pressureMPa, enthalpyKJKG)
+		}
+		return region2TemperaturePH(pressureMPa, enthalpyKJKG)
+	})
+}
+
+func if97StatePS(pressureMPa float64, entropyKJKGK float64) if97State {
+	return if97StateByTemperature(pressureMPa, entropyKJKGK, func(state if97State) (float64, float64) {
+		return state.entropy, state.cp / state.temperature
+	}, func(liquid bool) float64 {
+		if liquid {
+			return region1TemperaturePS(pressureMPa, entropyKJKGK)
+		}
+		return region2TemperaturePS(pressureMPa, entropyKJKGK)
+	})
+}
+
+// if97StateHS finds the pressure at which the isenthalp crosses the requested entropy.
+// Along an isenthalp ds/dp = -v/T, so entropy falls monotonically with pressure and the search can be done in ln(p).
+// Liquid states start from the region 1 backward equation p(h,s), all others from 1 MPa.
+func if97StateHS(enthalpyKJKG float64, entropyKJKGK float64) if97State {
+	var guess float64 = 0
+	if entropyKJKGK <= backward1MaxS {
+		if pressure := region1PressureHS(enthalpyKJKG, entropyKJKGK); pressure > 0 {
+			guess = math.Log(pressure)
+		}
+	}
+	var logPressure float64 = if97Solve(func(logP float64) (float64, float64) {
+		var state if97State = if97StatePH(math.Exp(logP), enthalpyKJKG)
+		return state.entropy - entropyKJKGK, -state.specificVolume * state.pressure * 1000 / state.temperature
+	}, math.Log(if97TripleP), math.Log(if97MaxP), guess)
+	return if97StatePH(math.Exp(logPressure), enthalpyKJKG)
+}
+
+func if97StatePV(pressureMPa float64, specificVolumeM3KG float64) if97State {
+	return if97StateByTemperature(pressureMPa, specificVolumeM3KG, func(state if97State) (float64, float64) {
+		return state.specificVolume, state.expansion * state.specificVolume
+	}, nil)
+}
+
+// if97StateByPressure resolves a state at fixed temperature from a property that falls with pressure on the vapour
+// side of the saturation line. The solve runs in ln(p) because vapour properties scale with 1/p.
+func if97StateByPressure(temperatureK float64, target float64, property func(state if97State) (value float64, slope float64)) if97State {
+	var low, high float64 = if97TripleP, if97MaxP
+	var liquid bool = true
+	if temperatureK < if97CriticalT {
+		var saturatedLiquid, saturatedVapour = saturatedStates(saturationPressure(temperatureK))
+		var liquidValue, _ = property(saturatedLiquid)
+		var vapourValue, _ = property(saturatedVapour)
+		switch {
+		case target >= math.Min(liquidValue, vapourValue) && target <= math.Max(liquidValue, vapourValue):
+			return mixtureState(saturatedLiquid, saturatedVapour, (target-liquidValue)/(vapourValue-liquidValue))
+		case target > vapourValue:
+			high = saturatedVapour.pressure
+			liquid = false
+		default:
+			low = saturatedLiquid.pressure
+		}
+	}
+	var logPressure float64 = if97Solve(func(logP float64) (float64, float64) {
+		var value, slope = property(singlePhaseState(math.Exp(logP), temperatureK, liquid))
+		return value - target, slope * math.Exp(logP)
+	}, math.Log(low), math.Log(high), (math.Log(low)+math.Log(high))/2)
+	return singlePhaseState(math.Exp(logPressure), temperatureK, liquid)
+}
+
+func if97StateTH(temperatureK float64, enthalpyKJKG float64) if97State {
+	return if97StateByPressure(temperatureK, enthalpyKJKG, func(state if97State) (float64, float64) {
+		return state.enthalpy, (state.specificVolume - state.temperature*state.expansion*state.specificVolume) * 1000 // (∂h/∂p)T
+	})
+}
+
+func if97StateTS(temperatureK float64, entropyKJKGK float64) if97State {
+	return if97StateByPressure(temperatureK, entropyKJKGK, func(state if97State) (float64, float64) {
+		return state.entropy, -state.expansion * state.specificVolume * 1000 // (∂s/∂p)T = -(∂v/∂T)p
+	})
+}
+
+func if97StateTV(temperatureK float64, specificVolumeM3KG float64) if97State {
+	return if97StateByPressure(temperatureK, specificVolumeM3KG, func(state if97State) (float64, float64) {
+		return state.specificVolume, -state.compressibility * state.specificVolume
+	})
+}
+
+func if97StatePX(pressureMPa float64, quality float64) if97State {
+	var saturatedLiquid, saturatedVapour = saturatedStates(math.Min(pressureMPa, if97CriticalP))
+	return mixtureState(saturatedLiquid, saturatedVapour, quality)
+}
+
+func if97StateTX(temperatureK float64, quality float64) if97State {
+	return if97StatePX(saturationPressure(math.Min(temperatureK, if97CriticalT)), quality)
+}
+
+// if97StateBySaturation finds the saturation temperature at which a mixture of the given quality has the requested
+// property value. Saturated vapour enthalpy peaks around 235 °C, so the line is scanned from the triple point upwards
+// and the lowest temperature solution is returned.
+func if97StateBySaturation(target float64, quality float64, property func(state if97State) float64) if97State {
+	var temperatureK float64 = if97Scan(func(temperature float64) float64 {
+		return property(if97StateTX(temperature, quality)) - target
+	}, if97TripleT, if97CriticalT, 64)
+	return if97StateTX(temperatureK, quality)
+}
+
+func if97StateHX(enthalpyKJKG float64, quality float64) if97State {
+	return if97StateBySaturation(enthalpyKJKG, quality, func(state if97State) float64 { return state.enthalpy })
+}
+
+func if97StateSX(entropyKJKGK float64, quality float64) if97State {
+	return if97StateBySaturation(entropyKJKGK, quality, func(state if97State) float64 { return state.entropy })
+}
+
+// --- NUMERICS ---
+
+// if97Solve finds the root of f between low and high. f returns its value and slope; Newton steps are used while they
+// stay inside the shrinking bracket and bisection otherwise. Without a sign change the end closest to zero is returned.
+func if97Solve(f func(x float64) (value float64, slope float64), low float64, high float64, guess float64) float64 {
+	var lowValue, _ = f(low)
+	var highValue, _ = f(high)
+	if lowValue == 0 {
+		return low
+	}
+	if highValue == 0 {
+		return high
+	}
+	if (lowValue > 0) == (highValue > 0) {
+		if math.Abs(lowValue) < math.Abs(highValue) {
+			return low
+		}
+		return high
+	}
+	if lowValue > 0 { // orient the bracket so that f(low) < 0 < f(high)
+		low, high = high, low
+	}
+	var x float64 = guess
+	if !((x-low)*(x-high) < 0) { // outside the bracket, or NaN
+		x = (low + high) / 2
+	}
+	for i := 0; i < 100; i += 1 {
+		var value, slope = f(x)
+		if value == 0 {
+			return x
+		}
+		if value < 0 {
+			low = x
+		} else {
+			high = x
+		}
+		var next float64 = x - value/slope
+		if slope == 0 || math.IsNaN(next) || (next-low)*(next-high) >= 0 {
+			next = (low + high) / 2
+		}
+		if math.Abs(next-x) <= 1e-12*math.Max(1, math.Abs(x)) || math.Abs(high-low) <= 1e-12*math.Max(1, math.Abs(x)) {
+			return next
+		}
+		x = next
+	}
+	return x
+}
+
+// if97Scan samples f at evenly spaced points between low and high, bisects the first interval with a sign change and
+// returns its root. It is used where a function may have several roots and the solution closest to low is wanted.
+func if97Scan(f func(x float64) float64, low float64, high float64, samples int) float64 {
+	var step float64 = (high - low) / float64(samples)
+	var previousX, previousValue float64 = low, f(low)
+	var bestX, bestValue float64 = previousX, math.Abs(previousValue)
+	for i := 1; i <= samples; i += 1 {
+		var x float64 = low + float64(i)*step
+		var value float64 = f(x)
+		if (value > 0) != (previousValue > 0) {
+			var a, b, aValue float64 = previousX, x, previousValue
+			for j := 0; j < 60; j += 1 {
+				var middle float64 = (a + b) / 2
+				var middleValue float64 = f(middle)
+				if (middleValue > 0) == (aValue > 0) {
+					a, aValue = middle, middleValue
+				} else {
+					b = middle
+				}
+			}
+			return (a + b) / 2
+		}
+		if math.Abs(value) < bestValue {
+			bestX, bestValue = x, math.Abs(value)
+		}
+		previousX, previousValue = x, value
+	}
+	return bestX
+}
@@ -0,0 +1,194 @@
+package fluid
+
+import "math"
+
+// Backward equations of IAPWS-IF97 for regions 1 and 2: T(p,h), T(p,s) and, in region 1, p(h,s). They agree with the
+// forward equations only to within a few mK, so they are not used as results. if97StateByTemperature and
+// if97StateHS start their iteration from them instead, and the forward equations are then solved to full precision.
+
+// --- REGION 1 T(p,h) ---
+var backward1PHI = [...]float64{0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 2, 2, 3, 3, 4, 5, 6}
+var backward1PHJ = [...]float64{0, 1, 2, 6, 22, 32, 0, 1, 2, 3, 4, 10, 32, 10, 32, 10, 32, 32, 32, 32}
+var backward1PHN = [...]float64{
+	-0.23872489924521e3, 0.40421188637945e3, 0.11349746881718e3, -0.58457616048039e1,
+	-0.15285482413140e-3, -0.10866707695377e-5, -0.13391744872602e2, 0.43211039183559e2,
+	-0.54010067170506e2, 0.30535892203916e2, -0.65964749423638e1, 0.93965400878363e-2,
+	0.11573647505340e-6, -0.25858641282073e-4, -0.40644363084799e-8, 0.66456186191635e-7,
+	0.80670734103027e-10, -0.93477771213947e-12, 0.58265442020601e-14, -0.15020185953503e-16,
+}
+
+// --- REGION 1 T(p,s) ---
+var backward1PSI = [...]float64{0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 4}
+var backward1PSJ = [...]float64{0, 1, 2, 3, 11, 31, 0, 1, 2, 3, 12, 31, 0, 1, 2, 9, 31, 10, 32, 32}
+var backward1PSN = [...]float64{
+	0.17478268058307e3, 0.34806930892873e2, 0.65292584978455e1, 0.33039981775489,
+	-0.19281382923196e-6, -0.24909197244573e-22, -0.26107636489332, 0.22592965981586,
+	-0.64256463395226e-1, 0.78876289270526e-2, 0.35672110607366e-9, 0.17332496994895e-23,
+	0.56608900654837e-3, -0.32635483139717e-3, 0.44778286690632e-4, -0.51322156908507e-9,
+	-0.42522657042207e-25, 0.26400441360689e-12, 0.78124600459723e-28, -0.30732199903668e-30,
+}
+
+// --- REGION 1 p(h,s) ---
+var backward1HSI = [...]float64{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 3, 4, 4, 5}
+var backward1HSJ = [...]float64{0, 1, 2, 4, 5, 6, 8, 14, 0, 1, 4, 6, 0, 1, 10, 4, 1, 4, 0}
+var backward1HSN = [...]float64{
+	-0.691997014660582, -0.183612548787560e2, -0.928332409297335e1, 0.659639569909906e2,
+	-0.162060388912024e2, 0.450620017338667e3, 0.854680678224170e3, 0.607523214001162e4,
+	0.326487682621856e2, -0.269408844582931e2, -0.319947848334300e3, -0.928354307043320e3,
+	0.303634537455249e2, -0.650540422444146e2, -0.430991316516130e4, -0.747512324096068e3,
+	0.730000345529245e3, 0.114284032569021e4, -0.436407041874559e3,
+}
+
+// --- REGION 2 T(p,h), subregions 2a, 2b and 2c ---
+var backward2aPHI = [...]float64{0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2, 3, 3, 4, 4, 4, 5, 5, 5, 6, 6, 7}
+var backward2aPHJ = [...]float64{0, 1, 2, 3, 7, 20, 0, 1, 2, 3, 7, 9, 11, 18, 44, 0, 2, 7, 36, 38, 40, 42, 44, 24, 44, 12, 32, 44, 32, 36, 42, 34, 44, 28}
+var backward2aPHN = [...]float64{
+	0.10898952318288e4, 0.84951654495535e3, -0.10781748091826e3, 0.33153654801263e2,
+	-0.74232016790248e1, 0.11765048724356e2, 0.18445749355790e1, -0.41792700549624e1,
+	0.62478196935812e1, -0.17344563108114e2, -0.20058176862096e3, 0.27196065473796e3,
+	-0.45511318285818e3, 0.30919688604755e4, 0.25226640357872e6, -0.61707422868339e-2,
+	-0.31078046629583, 0.11670873077107e2, 0.12812798404046e9, -0.98554909623276e9,
+	0.28224546973002e10, -0.35948971410703e10, 0.17227349913197e10, -0.13551334240775e5,
+	0.12848734664650e8, 0.13865724283226e1, 0.23598832556514e6, -0.13105236545054e8,
+	0.73999835474766e4, -0.55196697030060e6, 0.37154085996233e7, 0.19127729239660e5,
+	-0.41535164835634e6, -0.62459855192507e2,
+}
+var backward2bPHI = [...]float64{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 4, 4, 5, 5, 5, 6, 7, 7, 9, 9}
+var backward2bPHJ = [...]float64{0, 1, 2, 12, 18, 24, 28, 40, 0, 2, 6, 12, 18, 24, 28, 40, 2, 8, 18, 40, 1, 2, 12, 24, 2, 12, 18, 24, 28, 40, 18, 24, 40, 28, 2, 28, 1, 40}
+var backward2bPHN = [...]float64{
+	0.14895041079516e4, 0.74307798314034e3, -0.97708318797837e2, 0.24742464705674e1,
+	-0.63281320016026, 0.11385952129658e1, -0.47811863648625, 0.85208123431544e-2,
+	0.93747147377932, 0.33593118604916e1, 0.33809355601454e1, 0.16844539671904,
+	0.73875745236695, -0.47128737436186, 0.15020273139707, -0.21764114219750e-2,
+	-0.21810755324761e-1, -0.10829784403677, -0.46333324635812e-1, 0.71280351959551e-4,
+	0.11032831789999e-3, 0.18955248387902e-3, 0.30891541160537e-2, 0.13555504554949e-2,
+	0.28640237477456e-6, -0.10779857357512e-4, -0.76462712454814e-4, 0.14052392818316e-4,
+	-0.31083814331434e-4, -0.10302738212103e-5, 0.28217281635040e-6, 0.12704902271945e-5,
+	0.73803353468292e-7, -0.11030139238909e-7, -0.81456365207833e-13, -0.25180545682962e-10,
+	-0.17565233969407e-17, 0.86934156344163e-14,
+}
+var backward2cPHI = [...]float64{-7, -7, -6, -6, -5, -5, -2, -2, -1, -1, 0, 0, 1, 1, 2, 6, 6, 6, 6, 6, 6, 6, 6}
+var backward2cPHJ = [...]float64{0, 4, 0, 2, 0, 2, 0, 1, 0, 2, 0, 1, 4, 8, 4, 0, 1, 4, 10, 12, 16, 20, 22}
+var backward2cPHN = [...]float64{
+	-0.32368398555242e13, 0.73263350902181e13, 0.35825089945447e12, -0.58340131851590e12,
+	-0.10783068217470e11, 0.20825544563171e11, 0.61074783564516e6, 0.85977722535580e6,
+	-0.25745723604170e5, 0.31081088422714e5, 0.12082315865936e4, 0.48219755109255e3,
+	0.37966001272486e1, -0.10842984880077e2, -0.45364172676660e-1, 0.14559115658698e-12,
+	0.11261597407230e-11, -0.17804982240686e-10, 0.12324579690832e-6, -0.11606921130984e-5,
+	0.27846367088554e-4, -0.59270038474176e-3, 0.12918582991878e-2,
+}
+
+// --- REGION 2 T(p,s), subregions 2a, 2b and 2c ---
+var backward2aPSI = [...]float64{
+	-1.5, -1.5, -1.5, -1.5, -1.5, -1.5, -1.25, -1.25, -1.25, -1, -1, -1, -1, -1, -1, -0.75, -0.75, -0.5, -0.5, -0.5, -0.5,
+	-0.25, -0.25, -0.25, -0.25, 0.25, 0.25, 0.25, 0.25, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.75, 0.75, 0.75, 0.75, 1, 1,
+	1.25, 1.25, 1.5, 1.5,
+}
+var backward2aPSJ = [...]float64{
+	-24, -23, -19, -13, -11, -10, -19, -15, -6, -26, -21, -17, -16, -9, -8, -15, -14, -26, -13, -9, -7, -27, -25, -11, -6,
+	1, 4, 8, 11, 0, 1, 5, 6, 10, 14, 16, 0, 4, 9, 17, 7, 18, 3, 15, 5, 18,
+}
+var backward2aPSN = [...]float64{
+	-0.39235983861984e6, 0.51526573827270e6, 0.40482443161048e5, -0.32193790923902e3,
+	0.96961424218694e2, -0.22867846371773e2, -0.44942914124357e6, -0.50118336020166e4,
+	0.35684463560015, 0.44235335848190e5, -0.13673388811708e5, 0.42163260207864e6,
+	0.22516925837475e5, 0.47442144865646e3, -0.14931130797647e3, -0.19781126320452e6,
+	-0.23554399470760e5, -0.19070616302076e5, 0.55375669883164e5, 0.38293691437363e4,
+	-0.60391860580567e3, 0.19363102620331e4, 0.42660643698610e4, -0.59780638872718e4,
+	-0.70401463926862e3, 0.33836784107553e3, 0.20862786635187e2, 0.33834172656196e-1,
+	-0.43124428414893e-4, 0.16653791356412e3, -0.13986292055898e3, -0.78849547999872,
+	0.72132411753872e-1, -0.59754839398283e-2, -0.12141358953904e-4, 0.23227096733871e-6,
+	-0.10538463566194e2, 0.20718925496502e1, -0.72193155260427e-1, 0.20749887081120e-6,
+	-0.18340657911379e-1, 0.29036272348696e-6, 0.21037527893619, 0.25681239729999e-3,
+	-0.12799002933781e-1, -0.82198102652018e-5,
+}
+var backward2bPSI = [...]float64{
+	-6, -6, -5, -5, -4, -4, -4, -3, -3, -3, -3, -2, -2, -2, -2, -1, -1, -1, -1, -1, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1,
+	2, 2, 2, 3, 3, 3, 4, 4, 5, 5, 5,
+}
+var backward2bPSJ = [...]float64{
+	0, 11, 0, 11, 0, 1, 11, 0, 1, 11, 12, 0, 1, 6, 10, 0, 1, 5, 8, 9, 0, 1, 2, 4, 5, 6, 9, 0, 1, 2, 3, 7, 8, 0, 1, 5, 0,
+	1, 3, 0, 1, 0, 1, 2,
+}
+var backward2bPSN = [...]float64{
+	0.31687665083497e6, 0.20864175881858e2, -0.39859399803599e6, -0.21816058518877e2,
+	0.22369785194242e6, -0.27841703445817e4, 0.99207436071480e1, -0.75197512299157e5,
+	0.29708605951158e4, -0.34406878548526e1, 0.38815564249115, 0.17511295085750e5,
+	-0.14237112854449e4, 0.10943803364167e1, 0.89971619308495, -0.33759740098958e4,
+	0.47162885818355e3, -0.19188241993679e1, 0.41078580492196, -0.33465378172097,
+	0.13870034777505e4, -0.40663326195838e3, 0.41727347159610e2, 0.21932549434532e1,
+	-0.10320050009077e1, 0.35882943516703, 0.52511453726066e-2, 0.12838916450705e2,
+	-0.28642437219381e1, 0.56912683664855, -0.99962954584931e-1, -0.32632037778459e-2,
+	0.23320922576723e-3, -0.15334809857450, 0.29072288239902e-1, 0.37534702741167e-3,
+	0.17296691702411e-2, -0.38556050844504e-3, -0.35017712292608e-4, -0.14566393631492e-4,
+	0.56420857267269e-5, 0.41286150074605e-7, -0.20684671118824e-7, 0.16409393674725e-8,
+}
+var backward2cPSI = [...]float64{-2, -2, -1, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 3, 3, 3, 4, 4, 4, 5, 5, 5, 6, 6, 7, 7, 7, 7, 7}
+var backward2cPSJ = [...]float64{0, 1, 0, 0, 1, 2, 3, 0, 1, 3, 4, 0, 1, 2, 0, 1, 5, 0, 1, 4, 0, 1, 2, 0, 1, 0, 1, 3, 4, 5}
+var backward2cPSN = [...]float64{
+	0.90968501005365e3, 0.24045667088420e4, -0.59162326387130e3, 0.54145404128074e3,
+	-0.27098308411192e3, 0.97976525097926e3, -0.46966772959435e3, 0.14399274604723e2,
+	-0.19104204230429e2, 0.53299167111971e1, -0.21252975375934e2, -0.31147334413760,
+	0.60334840894623, -0.42764839702509e-1, 0.58185597255259e-2, -0.14597008284753e-1,
+	0.56631175631027e-2, -0.76155864584577e-4, 0.22440342919332e-3, -0.12561095013413e-4,
+	0.63323132660934e-6, -0.20541989675375e-5, 0.36405370390082e-7, -0.29759897789215e-8,
+	0.10136618529763e-7, 0.59925719692351e-11, -0.20677870105164e-10, -0.20874278181886e-10,
+	0.10162166825089e-9, -0.16429828281347e-9,
+}
+
+// --- BOUNDARY BETWEEN SUBREGIONS 2B AND 2C ---
+var backward2bcN = [...]float64{0.90584278514723e3, -0.67955786399241, 0.12809002730136e-3}
+
+const backward2abP float64 = 4            // MPa, boundary between subregions 2a and 2b
+const backward2bcS float64 = 5.85         // kJ/(kg·K), boundary between subregions 2b and 2c in entropy
+const backward1MaxS float64 = 3.778281340 // kJ/(kg·K), entropy of saturated liquid at 623.15 K, the largest in region 1
+
+// --- BACKWARD EQUATIONS ---
+
+// backwardSum evaluates sum(n * x^I * y^J), the form all IF97 backward equations share.
+func backwardSum(i []float64, j []float64, n []float64, x float64, y float64) (sum float64) {
+	for k := range n {
+		var xI float64 = if97Pow(x, i[k])
+		if i[k] != math.Trunc(i[k]) {
+			xI = math.Pow(x, i[k])
+		}
+		sum += n[k] * xI * if97Pow(y, j[k])
+	}
+	return
+}
+
+func region1TemperaturePH(pressureMPa float64, enthalpyKJKG float64) float64 {
+	return backwardSum(backward1PHI[:], backward1PHJ[:], backward1PHN[:], pressureMPa, enthalpyKJKG/2500+1)
+}
+
+func region1TemperaturePS(pressureMPa float64, entropyKJKGK float64) float64 {
+	return backwardSum(backward1PSI[:], backward1PSJ[:], backward1PSN[:], pressureMPa, entropyKJKGK+2)
+}
+
+func region1PressureHS(enthalpyKJKG float64, entropyKJKGK float64) float64 {
+	return 100 * backwardSum(backward1HSI[:], backward1HSJ[:], backward1HSN[:], enthalpyKJKG/3400+0.05, entropyKJKGK/7.6+0.05)
+}
+
+func region2TemperaturePH(pressureMPa float64, enthalpyKJKG float64) float64 {
+	var n = backward2bcN
+	var eta float64 = enthalpyKJKG / 2000
+	switch {
+	case pressureMPa <= backward2abP:
+		return backwardSum(backward2aPHI[:], backward2aPHJ[:], backward2aPHN[:], pressureMPa, eta-2.1)
+	case pressureMPa <= n[0]+n[1]*enthalpyKJKG+n[2]*enthalpyKJKG*enthalpyKJKG:
+		return backwardSum(backward2bPHI[:], backward2bPHJ[:], backward2bPHN[:], pressureMPa-2, eta-2.6)
+	default:
+		return backwardSum(backward2cPHI[:], backward2cPHJ[:], backward2cPHN[:], pressureMPa+25, eta-1.8)
+	}
+}
+
+func region2TemperaturePS(pressureMPa float64, entropyKJKGK float64) float64 {
+	switch {
+	case pressureMPa <= backward2abP:
+		return backwardSum(backward2aPSI[:], backward2aPSJ[:], backward2aPSN[:], pressureMPa, entropyKJKGK/2-2)
+	case entropyKJKGK >= backward2bcS:
+		return backwardSum(backward2bPSI[:], backward2bPSJ[:], backward2bPSN[:], pressureMPa, 10-entropyKJKGK/0.7853)
+	default:
+		return backwardSum(backward2cPSI[:], backward2cPSJ[:], backward2cPSN[:], pressureMPa, 2-entropyKJKGK/2.9251)
+	}
+}
@@ -0,0 +1,263 @@
+package fluid
+
+import "math"
+
+// Native Go implementation of the IAPWS Industrial Formulation 1997 (IAPWS-IF97).
+// Everything in this file works in the units of the release itself: MPa, K, kJ/kg and kg/m^3.
+
+// --- CONSTANT DECLARATIONS ---
+const (
+	if97R            float64 = 0.461526     // specific gas constant of water in kJ/(kg·K)
+	if97CriticalT    float64 = 647.096      // K
+	if97CriticalP    float64 = 22.064       // MPa
+	if97CriticalRho  float64 = 322          // kg/m^3
+	if97TripleP      float64 = 0.000611657  // MPa
+	if97TripleT      float64 = 273.16       // K
+	if97MinT         float64 = 273.15       // K, lower temperature limit of regions 1 and 2
+	if97MaxT         float64 = 2273.15      // K, upper temperature limit of region 5
+	if97MaxP         float64 = 100          // MPa, upper pressure limit of regions 1, 2 and 3
+	if97Region5MaxP  float64 = 50           // MPa, upper pressure limit of region 5
+	if97Region13T    float64 = 623.15       // K, boundary between regions 1 and 3
+	if97Region25T    float64 = 1073.15      // K, boundary between regions 2 and 5
+	if97Region23MaxT float64 = 863.15       // K, highest temperature at which region 3 exists
+	if97Region13SatP float64 = 16.529164253 // MPa, saturation pressure at 623.15 K
+)
+
+// --- REGION 1 (compressed liquid) ---
+var region1I = [...]float64{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 3, 4, 4, 4, 5, 8, 8, 21, 23, 29, 30, 31, 32}
+var region1J = [...]float64{-2, -1, 0, 1, 2, 3, 4, 5, -9, -7, -1, 0, 1, 3, -3, 0, 1, 3, 17, -4, 0, 6, -5, -2, 10, -8, -11, -6, -29, -31, -38, -39, -40, -41}
+var region1N = [...]float64{
+	0.14632971213167, -0.84548187169114, -0.37563603672040e1, 0.33855169168385e1,
+	-0.95791963387872, 0.15772038513228, -0.16616417199501e-1, 0.81214629983568e-3,
+	0.28319080123804e-3, -0.60706301565874e-3, -0.18990068218419e-1, -0.32529748770505e-1,
+	-0.21841717175414e-1, -0.52838357969930e-4, -0.47184321073267e-3, -0.30001780793026e-3,
+	0.47661393906987e-4, -0.44141845330846e-5, -0.72694996297594e-15, -0.31679644845054e-4,
+	-0.28270797985312e-5, -0.85205128120103e-9, -0.22425281908000e-5, -0.65171222895601e-6,
+	-0.14341729937924e-12, -0.40516996860117e-6, -0.12734301741641e-8, -0.17424871230634e-9,
+	-0.68762131295531e-18, 0.14478307828521e-19, 0.26335781662795e-22, -0.11947622640071e-22,
+	0.18228094581404e-23, -0.93537087292458e-25,
+}
+
+// --- REGION 2 (superheated vapour) ---
+var region2J0 = [...]float64{0, 1, -5, -4, -3, -2, -1, 2, 3}
+var region2N0 = [...]float64{
+	-0.96927686500217e1, 0.10086655968018e2, -0.56087911283020e-2, 0.71452738081455e-1,
+	-0.40710498223928, 0.14240819171444e1, -0.43839511319450e1, -0.28408632460772,
+	0.21268463753307e-1,
+}
+var region2I = [...]float64{1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 3, 3, 3, 4, 4, 4, 5, 6, 6, 6, 7, 7, 7, 8, 8, 9, 10, 10, 10, 16, 16, 18, 20, 20, 20, 21, 22, 23, 24, 24, 24}
+var region2J = [...]float64{0, 1, 2, 3, 6, 1, 2, 4, 7, 36, 0, 1, 3, 6, 35, 1, 2, 3, 7, 3, 16, 35, 0, 11, 25, 8, 36, 13, 4, 10, 14, 29, 50, 57, 20, 35, 48, 21, 53, 39, 26, 40, 58}
+var region2N = [...]float64{
+	-0.17731742473213e-2, -0.17834862292358e-1, -0.45996013696365e-1, -0.57581259083432e-1,
+	-0.50325278727930e-1, -0.33032641670203e-4, -0.18948987516315e-3, -0.39392777243355e-2,
+	-0.43797295650573e-1, -0.26674547914087e-4, 0.20481737692309e-7, 0.43870667284435e-6,
+	-0.32277677238570e-4, -0.15033924542148e-2, -0.40668253562649e-1, -0.78847309559367e-9,
+	0.12790717852285e-7, 0.48225372718507e-6, 0.22922076337661e-5, -0.16714766451061e-10,
+	-0.21171472321355e-2, -0.23895741934104e2, -0.59059564324270e-17, -0.12621808899101e-5,
+	-0.38946842435739e-1, 0.11256211360459e-10, -0.82311340897998e1, 0.19809712802088e-7,
+	0.10406965210174e-18, -0.10234747095929e-12, -0.10018179379511e-8, -0.80882908646985e-10,
+	0.10693031879409, -0.33662250574171, 0.89185845355421e-24, 0.30629316876232e-12,
+	-0.42002467698208e-5, -0.59056029685639e-25, 0.37826947613457e-5, -0.12768608934681e-14,
+	0.73087610595061e-28, 0.55414715350778e-16, -0.94369707241210e-6,
+}
+
+// --- REGION 3 (near-critical, described by a Helmholtz free energy in density and temperature) ---
+const region3N1 float64 = 0.10658070028513e1 // coefficient of the ln(δ) term
+
+var region3I = [...]float64{0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 3, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 6, 6, 6, 7, 8, 9, 9, 10, 10, 11}
+var region3J = [...]float64{0, 1, 2, 7, 10, 12, 23, 2, 6, 15, 17, 0, 2, 6, 7, 22, 26, 0, 2, 4, 16, 26, 0, 2, 4, 26, 1, 3, 26, 0, 2, 26, 2, 26, 2, 26, 0, 1, 26}
+var region3N = [...]float64{
+	-0.15732845290239e2, 0.20944396974307e2, -0.76867707878716e1, 0.26185947787954e1,
+	-0.28080781148620e1, 0.12053369696517e1, -0.84566812812502e-2, -0.12654315477714e1,
+	-0.11524407806681e1, 0.88521043984318, -0.64207765181607, 0.38493460186671,
+	-0.85214708824206, 0.48972281541877e1, -0.30502617256965e1, 0.39420536879154e-1,
+	0.12558408424308, -0.27999329698710, 0.13899799569460e1, -0.20189915023570e1,
+	-0.82147637173963e-2, -0.47596035734923, 0.43984074473500e-1, -0.44476435428739,
+	0.90572070719733, 0.70522450087967, 0.10770512626332, -0.32913623258954,
+	-0.50871062041158, -0.22175400873096e-1, 0.94260751665092e-1, 0.16436278447961,
+	-0.13503372241348e-1, -0.14834345352472e-1, 0.57922953628084e-3, 0.32308904703711e-2,
+	0.80964802996215e-4, -0.16557679795037e-3, -0.44923899061815e-4,
+}
+
+// --- REGION 4 (saturation line) ---
+var region4N = [...]float64{
+	0.11670521452767e4, -0.72421316703206e6, -0.17073846940092e2, 0.12020824702470e5,
+	-0.32325550322333e7, 0.14915108613530e2, -0.48232657361591e4, 0.40511340542057e6,
+	-0.23855557567849, 0.65017534844798e3,
+}
+
+// --- REGION 5 (high-temperature vapour) ---
+var region5J0 = [...]float64{0, 1, -3, -2, -1, 2}
+var region5N0 = [...]float64{
+	-0.13179983674201e2, 0.68540841634434e1, -0.24805148933466e-1, 0.36901534980333,
+	-0.31161318213925e1, -0.32961626538917,
+}
+var region5I = [...]float64{1, 1, 1, 2, 2, 3}
+var region5J = [...]float64{1, 2, 3, 3, 9, 7}
+var region5N = [...]float64{
+	0.15736404855259e-2, 0.90153761673944e-3, -0.50270077677648e-2, 0.22440037409485e-5,
+	-0.41163275453471e-5, 0.37919454822955e-7,
+}
+
+// --- BOUNDARY BETWEEN REGIONS 2 AND 3 ---
+var regionB23N = [...]float64{0.34805185628969e3, -0.11671859879975e1, 0.10192970039326e-2, 0.57254459862746e3, 0.13918839778870e2}
+
+// --- STRUCT DECLARATIONS ---
+
+// if97Derivatives holds a dimensionless IF97 fundamental equation and its partial derivatives.
+// For the Gibbs regions (1, 2 and 5) the variables are π and τ, for region 3 they are δ and τ.
+type if97Derivatives struct {
+	f, fA, fAA, fT, fTT, fAT float64 // f, ∂f/∂a, ∂²f/∂a², ∂f/∂τ, ∂²f/∂τ², ∂²f/∂a∂τ where a is π or δ
+}
+
+// --- REGION EQUATIONS ---
+
+func region1Gibbs(pressureMPa float64, temperatureK float64) (gamma if97Derivatives) {
+	var pi float64 = pressureMPa / 16.53
+	var tau float64 = 1386 / temperatureK
+	var a float64 = 7.1 - pi
+	var b float64 = tau - 1.222
+	for i := range region1N {
+		var ii, jj, n float64 = region1I[i], region1J[i], region1N[i]
+		var aI float64 = if97Pow(a, ii)
+		var bJ float64 = if97Pow(b, jj)
+		gamma.f += n * aI * bJ
+		gamma.fA -= n * ii * if97Pow(a, ii-1) * bJ
+		gamma.fAA += n * ii * (ii - 1) * if97Pow(a, ii-2) * bJ
+		gamma.fT += n * aI * jj * if97Pow(b, jj-1)
+		gamma.fTT += n * aI * jj * (jj - 1) * if97Pow(b, jj-2)
+		gamma.fAT -= n * ii * if97Pow(a, ii-1) * jj * if97Pow(b, jj-1)
+	}
+	return
+}
+
+// vapourGibbs evaluates the ideal-gas plus residual Gibbs free energy shared by regions 2 and 5.
+func vapourGibbs(pi float64, tau float64, tauResidual float64, j0 []float64, n0 []float64, i []float64, j []float64, n []float64) (gamma if97Derivatives) {
+	gamma.f = math.Log(pi)
+	gamma.fA = 1 / pi
+	gamma.fAA = -1 / (pi * pi)
+	for k := range n0 {
+		gamma.f += n0[k] * if97Pow(tau, j0[k])
+		gamma.fT += n0[k] * j0[k] * if97Pow(tau, j0[k]-1)
+		gamma.fTT += n0[k] * j0[k] * (j0[k] - 1) * if97Pow(tau, j0[k]-2)
+	}
+	for k := range n {
+		var piI float64 = if97Pow(pi, i[k])
+		var tauJ float64 = if97Pow(tauResidual, j[k])
+		gamma.f += n[k] * piI * tauJ
+		gamma.fA += n[k] * i[k] * if97Pow(pi, i[k]-1) * tauJ
+		gamma.fAA += n[k] * i[k] * (i[k] - 1) * if97Pow(pi, i[k]-2) * tauJ
+		gamma.fT += n[k] * piI * j[k] * if97Pow(tauResidual, j[k]-1)
+		gamma.fTT += n[k] * piI * j[k] * (j[k] - 1) * if97Pow(tauResidual, j[k]-2)
+		gamma.fAT += n[k] * i[k] * if97Pow(pi, i[k]-1) * j[k] * if97Pow(tauResidual, j[k]-1)
+	}
+	return
+}
+
+func region2Gibbs(pressureMPa float64, temperatureK float64) if97Derivatives {
+	var tau float64 = 540 / temperatureK
+	return vapourGibbs(pressureMPa, tau, tau-0.5, region2J0[:], region2N0[:], region2I[:], region2J[:], region2N[:])
+}
+
+func region5Gibbs(pressureMPa float64, temperatureK float64) if97Derivatives {
+	var tau float64 = 1000 / temperatureK
+	return vapourGibbs(pressureMPa, tau, tau, region5J0[:], region5N0[:], region5I[:], region5J[:], region5N[:])
+}
+
+func region3Helmholtz(densityKGM3 float64, temperatureK float64) (phi if97Derivatives) {
+	var delta float64 = densityKGM3 / if97CriticalRho
+	var tau float64 = if97CriticalT / temperatureK
+	phi.f = region3N1 * math.Log(delta)
+	phi.fA = region3N1 / delta
+	phi.fAA = -region3N1 / (delta * delta)
+	for k := range region3N {
+		var ii, jj, n float64 = region3I[k], region3J[k], region3N[k]
+		var deltaI float64 = if97Pow(delta, ii)
+		var tauJ float64 = if97Pow(tau, jj)
+		phi.f += n * deltaI * tauJ
+		phi.fA += n * ii * if97Pow(delta, ii-1) * tauJ
+		phi.fAA += n * ii * (ii - 1) * if97Pow(delta, ii-2) * tauJ
+		phi.fT += n * deltaI * jj * if97Pow(tau, jj-1)
+		phi.fTT += n * deltaI * jj * (jj - 1) * if97Pow(tau, jj-2)
+		phi.fAT += n * ii * if97Pow(delta, ii-1) * jj * if97Pow(tau, jj-1)
+	}
+	return
+}
+
+// region3Pressure returns the pressure in MPa and its derivative with respect to density at constant temperature.
+func region3Pressure(densityKGM3 float64, temperatureK float64) (pressureMPa float64, dPdRho float64) {
+	var phi if97Derivatives = region3Helmholtz(densityKGM3, temperatureK)
+	var delta float64 = densityKGM3 / if97CriticalRho
+	pressureMPa = densityKGM3 * if97R * temperatureK * delta * phi.fA / 1000
+	dPdRho = if97R * temperatureK * (2*delta*phi.fA + delta*delta*phi.fAA) / 1000
+	return
+}
+
+// if97Pow raises x to an integer valued power. All IF97 exponents are integers, and repeated multiplication
+// is several times faster than math.Pow. A zero base with a negative exponent only occurs in terms that are
+// multiplied by a zero exponent afterwards, so 0 is returned for it instead of an infinity.
+func if97Pow(x float64, exponent float64) float64 {
+	var n int = int(exponent)
+	if n < 0 {
+		if x == 0 {
+			return 0
+		}
+		x, n = 1/x, -n
+	}
+	var result float64 = 1
+	for n > 0 {
+		if n&1 == 1 {
+			result *= x
+		}
+		x *= x
+		n >>= 1
+	}
+	return result
+}
+
+// --- SATURATION LINE AND BOUNDARIES ---
+
+func saturationPressure(temperatureK float64) float64 {
+	var n = region4N
+	var theta float64 = temperatureK + n[8]/(temperatureK-n[9])
+	var a float64 = theta*theta + n[0]*theta + n[1]
+	var b float64 = n[2]*theta*theta + n[3]*theta + n[4]
+	var c float64 = n[5]*theta*theta + n[6]*theta + n[7]
+	return math.Pow(2*c/(-b+math.Sqrt(b*b-4*a*c)), 4)
+}
+
+func saturationTemperature(pressureMPa float64) float64 {
+	var n = region4N
+	var beta float64 = math.Pow(pressureMPa, 0.25)
+	var e float64 = beta*beta + n[2]*beta + n[5]
+	var f float64 = n[0]*beta*beta + n[3]*beta + n[6]
+	var g float64 = n[1]*beta*beta + n[4]*beta + n[7]
+	var d float64 = 2 * g / (-f - math.Sqrt(f*f-4*e*g))
+	return (n[9] + d - math.Sqrt((n[9]+d)*(n[9]+d)-4*(n[8]+n[9]*d))) / 2
+}
+
+func regionB23Pressure(temperatureK float64) float64 {
+	var n = regionB23N
+	return n[0] + n[1]*temperatureK + n[2]*temperatureK*temperatureK
+}
+
+func regionB23Temperature(pressureMPa float64) float64 {
+	var n = regionB23N
+	return n[3] + math.Sqrt((pressureMPa-n[4])/n[2])
+}
+
+// saturatedDensityEstimates returns the auxiliary saturated liquid and vapour densities from the IAPWS
+// supplementary release on saturation properties. They are only used to seed the region 3 density iteration.
+func saturatedDensityEstimates(temperatureK float64) (liquidKGM3 float64, vapourKGM3 float64) {
+	var theta float64 = 1 - temperatureK/if97CriticalT
+	if theta <= 0 {
+		return if97CriticalRho, if97CriticalRho
+	}
+	var t3 float64 = math.Cbrt(theta)
+	liquidKGM3 = if97CriticalRho * (1 + 1.99274064*t3 + 1.09965342*t3*t3 - 0.510839303*math.Pow(theta, 5.0/3) -
+		1.75493479*math.Pow(theta, 16.0/3) - 45.5170352*math.Pow(theta, 43.0/3) - 6.74694450e5*math.Pow(theta, 110.0/3))
+	var t6 float64 = math.Pow(theta, 1.0/6)
+	vapourKGM3 = if97CriticalRho * math.Exp(-2.03150240*t6*t6-2.68302940*math.Pow(t6, 4)-5.38626492*math.Pow(t6, 8)-
+		17.2991605*math.Pow(t6, 18)-44.7586581*math.Pow(t6, 37)-63.9201063*math.Pow(t6, 71))
+	return
+}
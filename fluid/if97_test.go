@@ -0,0 +1,276 @@
+package fluid
+
+import (
+	"math"
+	"testing"
+)
+
+// The check values below are the computer-program verification tables of the IAPWS releases: IAPWS-IF97 (2007
+// revision) for the regions, the saturation line and the backward equations, the 2008 release on the viscosity of
+// ordinary water substance, and, for the thermal conductivity, reference values of the 2011 formulation the industrial
+// one approximates.
+
+// closeTo reports whether got is within the relative tolerance of want.
+func closeTo(got float64, want float64, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance*math.Abs(want)
+}
+
+func checkState(t *testing.T, state if97State, want [6]float64) {
+	t.Helper()
+	var got [6]float64 = [6]float64{state.specificVolume, state.enthalpy, state.internalEnergy, state.entropy, state.cp, state.speedOfSound}
+	var names [6]string = [6]string{"v", "h", "u", "s", "cp", "w"}
+	for i := range got {
+		if !closeTo(got[i], want[i], 1e-8) {
+			t.Errorf("%s = %.9e, want %.9e", names[i], got[i], want[i])
+		}
+	}
+}
+
+func TestRegion1(t *testing.T) {
+	var tests = []struct {
+		pressure, temperature float64
+		want                  [6]float64 // v, h, u, s, cp, w
+	}{
+		{3, 300, [6]float64{0.100215168e-2, 0.115331273e3, 0.112324818e3, 0.392294792, 0.417301218e1, 0.150773921e4}},
+		{80, 300, [6]float64{0.971180894e-3, 0.184142828e3, 0.106448356e3, 0.368563852, 0.401008987e1, 0.163469054e4}},
+		{3, 500, [6]float64{0.120241800e-2, 0.975542239e3, 0.971934985e3, 0.258041912e1, 0.465580682e1, 0.124071337e4}},
+	}
+	for _, test := range tests {
+		checkState(t, region1State(test.pressure, test.temperature), test.want)
+	}
+}
+
+func TestRegion2(t *testing.T) {
+	var tests = []struct {
+		pressure, temperature float64
+		want                  [6]float64
+	}{
+		{0.0035, 300, [6]float64{0.394913866e2, 0.254991145e4, 0.241169160e4, 0.852238967e1, 0.191300162e1, 0.427920172e3}},
+		{0.0035, 700, [6]float64{0.923015898e2, 0.333568375e4, 0.301262819e4, 0.101749996e2, 0.208141274e1, 0.644289068e3}},
+		{30, 700, [6]float64{0.542946619e-2, 0.263149474e4, 0.246861076e4, 0.517540298e1, 0.103505092e2, 0.480386523e3}},
+	}
+	for _, test := range tests {
+		checkState(t, region2State(test.pressure, test.temperature), test.want)
+	}
+}
+
+func TestRegion3(t *testing.T) {
+	var tests = []struct {
+		density, temperature float64
+		pressure             float64
+		want                 [6]float64
+	}{
+		{500, 650, 0.255837018e2, [6]float64{1.0 / 500, 0.186343019e4, 0.181226279e4, 0.405427273e1, 0.138935717e2, 0.502005554e3}},
+		{200, 650, 0.222930643e2, [6]float64{1.0 / 200, 0.237512401e4, 0.226365868e4, 0.485438792e1, 0.446579342e2, 0.383444594e3}},
+		{500, 750, 0.783095639e2, [6]float64{1.0 / 500, 0.225868845e4, 0.210206932e4, 0.446971906e1, 0.634165359e1, 0.760696041e3}},
+	}
+	for _, test := range tests {
+		var state if97State = region3State(test.density, test.temperature)
+		if !closeTo(state.pressure, test.pressure, 1e-8) {
+			t.Errorf("p(%v kg/m^3, %v K) = %.9e, want %.9e", test.density, test.temperature, state.pressure, test.pressure)
+		}
+		checkState(t, state, test.want)
+	}
+}
+
+func TestRegion5(t *testing.T) {
+	var tests = []struct {
+		pressure, temperature float64
+		want                  [6]float64
+	}{
+		{0.5, 1500, [6]float64{0.138455090e1, 0.521976855e4, 0.452749310e4, 0.965408875e1, 0.261609445e1, 0.917068690e3}},
+		{30, 1500, [6]float64{0.230761299e-1, 0.516723514e4, 0.447495124e4, 0.772970133e1, 0.272724317e1, 0.928548002e3}},
+		{30, 2000, [6]float64{0.311385219e-1, 0.657122604e4, 0.563707038e4, 0.853640523e1, 0.288569882e1, 0.106736948e4}},
+	}
+	for _, test := range tests {
+		checkState(t, region5State(test.pressure, test.temperature), test.want)
+	}
+}
+
+func TestSaturationLine(t *testing.T) {
+	var pressures = []struct{ temperature, pressure float64 }{
+		{300, 0.353658941e-2},
+		{500, 0.263889776e1},
+		{600, 0.123443146e2},
+	}
+	for _, test := range pressures {
+		if got := saturationPressure(test.temperature); !closeTo(got, test.pressure, 1e-8) {
+			t.Errorf("psat(%v K) = %.9e MPa, want %.9e", test.temperature, got, test.pressure)
+		}
+	}
+	var temperatures = []struct{ pressure, temperature float64 }{
+		{0.1, 0.372755919e3},
+		{1, 0.453035632e3},
+		{10, 0.584149488e3},
+	}
+	for _, test := range temperatures {
+		if got := saturationTemperature(test.pressure); !closeTo(got, test.temperature, 1e-8) {
+			t.Errorf("Tsat(%v MPa) = %.9e K, want %.9e", test.pressure, got, test.temperature)
+		}
+	}
+	if got := regionB23Pressure(0.62315e3); !closeTo(got, 0.165291643e2, 1e-8) {
+		t.Errorf("B23 p(623.15 K) = %.9e MPa, want 1.65291643e1", got)
+	}
+	if got := regionB23Temperature(0.165291643e2); !closeTo(got, 0.62315e3, 1e-8) {
+		t.Errorf("B23 T(16.5291643 MPa) = %.9e K, want 6.2315e2", got)
+	}
+}
+
+// The backward functions invert the forward equations numerically, so they agree with the IF97 backward equations to
+// within the tolerance IF97 allows those: 25 mK for T(p,h) and T(p,s), and 15 kPa for p(h,s) in region 1.
+func TestBackwardFunctions(t *testing.T) {
+	var temperatures = []struct {
+		name            string
+		state           if97State
+		temperature     float64 // K
+		toleranceKelvin float64
+	}{
+		{"T1(3 MPa, 500 kJ/kg)", if97StatePH(3, 500), 0.391798509e3, 0.025},
+		{"T1(80 MPa, 500 kJ/kg)", if97StatePH(80, 500), 0.378108626e3, 0.025},
+		{"T1(80 MPa, 1500 kJ/kg)", if97StatePH(80, 1500), 0.611041229e3, 0.025},
+		{"T2a(0.001 MPa, 3000 kJ/kg)", if97StatePH(0.001, 3000), 0.534433241e3, 0.025},
+		{"T2a(3 MPa, 3000 kJ/kg)", if97StatePH(3, 3000), 0.575373370e3, 0.025},
+		{"T2a(3 MPa, 4000 kJ/kg)", if97StatePH(3, 4000), 0.101077577e4, 0.025},
+		{"T2b(5 MPa, 3500 kJ/kg)", if97StatePH(5, 3500), 0.801299102e3, 0.025},
+		{"T2b(5 MPa, 4000 kJ/kg)", if97StatePH(5, 4000), 0.101531583e4, 0.025},
+		{"T2c(25 MPa, 3500 kJ/kg)", if97StatePH(25, 3500), 0.875279054e3, 0.025},
+		{"T1(3 MPa, 0.5 kJ/(kg·K))", if97StatePS(3, 0.5), 0.307842258e3, 0.025},
+		{"T1(80 MPa, 0.5 kJ/(kg·K))", if97StatePS(80, 0.5), 0.309979785e3, 0.025},
+		{"T1(80 MPa, 3 kJ/(kg·K))", if97StatePS(80, 3), 0.565899909e3, 0.025},
+		{"T2a(0.1 MPa, 7.5 kJ/(kg·K))", if97StatePS(0.1, 7.5), 0.399517097e3, 0.025},
+		{"T2a(0.1 MPa, 8 kJ/(kg·K))", if97StatePS(0.1, 8), 0.514127081e3, 0.025},
+		{"T2a(2.5 MPa, 8 kJ/(kg·K))", if97StatePS(2.5, 8), 0.103984917e4, 0.025},
+	}
+	for _, test := range temperatures {
+		if math.Abs(test.state.temperature-test.temperature) > test.toleranceKelvin {
+			t.Errorf("%s = %.6f K, want %.6f", test.name, test.state.temperature, test.temperature)
+		}
+	}
+
+	var pressures = []struct {
+		name     string
+		state    if97State
+		pressure float64 // MPa
+	}{
+		{"p1(90 kJ/kg, 0 kJ/(kg·K))", if97StateHS(90, 0), 0.9192954727e2},
+		{"p1(1500 kJ/kg, 3.4 kJ/(kg·K))", if97StateHS(1500, 3.4), 0.5868294423e2},
+	}
+	for _, test := range pressures {
+		if math.Abs(test.state.pressure-test.pressure) > 0.015 {
+			t.Errorf("%s = %.6f MPa, want %.6f", test.name, test.state.pressure, test.pressure)
+		}
+	}
+
+	// every backward function returns the state it is asked about
+	var state if97State = if97StatePT(7, 560)
+	for name, backward := range map[string]if97State{
+		"ph": if97StatePH(state.pressure, state.enthalpy),
+		"ps": if97StatePS(state.pressure, state.entropy),
+		"hs": if97StateHS(state.enthalpy, state.entropy),
+		"pv": if97StatePV(state.pressure, state.specificVolume),
+		"th": if97StateTH(state.temperature, state.enthalpy),
+		"ts": if97StateTS(state.temperature, state.entropy),
+		"tv": if97StateTV(state.temperature, state.specificVolume),
+	} {
+		if !closeTo(backward.temperature, state.temperature, 1e-7) || !closeTo(backward.pressure, state.pressure, 1e-6) {
+			t.Errorf("%s: %v MPa and %v K, want %v MPa and %v K", name, backward.pressure, backward.temperature, state.pressure, state.temperature)
+		}
+	}
+}
+
+// The IF97 backward equations that seed the backward functions, against the check values of the release.
+func TestBackwardEquations(t *testing.T) {
+	var tests = []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"T1(3 MPa, 500 kJ/kg)", region1TemperaturePH(3, 500), 0.391798509e3},
+		{"T1(80 MPa, 500 kJ/kg)", region1TemperaturePH(80, 500), 0.378108626e3},
+		{"T1(80 MPa, 1500 kJ/kg)", region1TemperaturePH(80, 1500), 0.611041229e3},
+		{"T1(3 MPa, 0.5 kJ/(kg·K))", region1TemperaturePS(3, 0.5), 0.307842258e3},
+		{"T1(80 MPa, 0.5 kJ/(kg·K))", region1TemperaturePS(80, 0.5), 0.309979785e3},
+		{"T1(80 MPa, 3 kJ/(kg·K))", region1TemperaturePS(80, 3), 0.565899909e3},
+		{"p1(0.001 kJ/kg, 0 kJ/(kg·K))", region1PressureHS(0.001, 0), 0.9800980612e-3},
+		{"p1(90 kJ/kg, 0 kJ/(kg·K))", region1PressureHS(90, 0), 0.9192954727e2},
+		{"p1(1500 kJ/kg, 3.4 kJ/(kg·K))", region1PressureHS(1500, 3.4), 0.5868294423e2},
+		{"T2a(0.001 MPa, 3000 kJ/kg)", region2TemperaturePH(0.001, 3000), 0.534433241e3},
+		{"T2a(3 MPa, 3000 kJ/kg)", region2TemperaturePH(3, 3000), 0.575373370e3},
+		{"T2a(3 MPa, 4000 kJ/kg)", region2TemperaturePH(3, 4000), 0.101077577e4},
+		{"T2b(5 MPa, 3500 kJ/kg)", region2TemperaturePH(5, 3500), 0.801299102e3},
+		{"T2b(5 MPa, 4000 kJ/kg)", region2TemperaturePH(5, 4000), 0.101531583e4},
+		{"T2b(25 MPa, 3500 kJ/kg)", region2TemperaturePH(25, 3500), 0.875279054e3},
+		{"T2c(40 MPa, 2700 kJ/kg)", region2TemperaturePH(40, 2700), 0.743056411e3},
+		{"T2c(60 MPa, 2700 kJ/kg)", region2TemperaturePH(60, 2700), 0.791137067e3},
+		{"T2c(60 MPa, 3200 kJ/kg)", region2TemperaturePH(60, 3200), 0.882756860e3},
+		{"T2a(0.1 MPa, 7.5 kJ/(kg·K))", region2TemperaturePS(0.1, 7.5), 0.399517097e3},
+		{"T2a(0.1 MPa, 8 kJ/(kg·K))", region2TemperaturePS(0.1, 8), 0.514127081e3},
+		{"T2a(2.5 MPa, 8 kJ/(kg·K))", region2TemperaturePS(2.5, 8), 0.103984917e4},
+		{"T2b(8 MPa, 6 kJ/(kg·K))", region2TemperaturePS(8, 6), 0.600484040e3},
+		{"T2b(8 MPa, 7.5 kJ/(kg·K))", region2TemperaturePS(8, 7.5), 0.106495556e4},
+		{"T2b(90 MPa, 6 kJ/(kg·K))", region2TemperaturePS(90, 6), 0.103801126e4},
+		{"T2c(20 MPa, 5.75 kJ/(kg·K))", region2TemperaturePS(20, 5.75), 0.697992849e3},
+		{"T2c(80 MPa, 5.25 kJ/(kg·K))", region2TemperaturePS(80, 5.25), 0.854011484e3},
+		{"T2c(80 MPa, 5.75 kJ/(kg·K))", region2TemperaturePS(80, 5.75), 0.949017998e3},
+	}
+	for _, test := range tests {
+		if !closeTo(test.got, test.want, 1e-8) {
+			t.Errorf("%s = %.9e, want %.9e", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestViscosity(t *testing.T) {
+	var tests = []struct {
+		temperature, density float64
+		viscosity            float64 // μPa·s
+	}{
+		{298.15, 998, 889.735100},
+		{298.15, 1200, 1437.649467},
+		{373.15, 1000, 307.883622},
+		{433.15, 1, 14.538324},
+		{433.15, 1000, 217.685358},
+		{873.15, 1, 32.619287},
+		{873.15, 100, 35.802262},
+		{873.15, 600, 77.430195},
+		{1173.15, 1, 44.217245},
+		{1173.15, 100, 47.640433},
+		{1173.15, 400, 64.154608},
+	}
+	for _, test := range tests {
+		if got := singlePhaseViscosity(test.density, test.temperature) * 1e6; !closeTo(got, test.viscosity, 1e-7) {
+			t.Errorf("viscosity(%v K, %v kg/m^3) = %.6f μPa·s, want %.6f", test.temperature, test.density, got, test.viscosity)
+		}
+	}
+}
+
+func TestThermalConductivity(t *testing.T) {
+	var tests = []struct {
+		name         string
+		state        if97State
+		conductivity float64 // W/(m·K)
+	}{
+		{"liquid at 0.1 MPa and 25 °C", if97StatePT(0.1, 298.15), 0.6072},
+		{"liquid at 7 MPa and 280 °C", if97StatePT(7, 553.15), 0.5831},
+		{"steam at 0.1 MPa and 200 °C", if97StatePT(0.1, 473.15), 0.03337},
+	}
+	for _, test := range tests {
+		if got := test.state.thermalConductivity(); !closeTo(got, test.conductivity, 0.02) {
+			t.Errorf("%s: conductivity = %.5f W/(m·K), want %.5f within 2%%", test.name, got, test.conductivity)
+		}
+	}
+}
+
+func TestSurfaceTension(t *testing.T) {
+	var tests = []struct{ temperature, tension float64 }{
+		{273.16, 75.65}, // IAPWS 1994 table, mN/m
+		{373.15, 58.91},
+		{573.15, 14.36},
+		{647.096, 0},
+	}
+	for _, test := range tests {
+		if got := surfaceTension(test.temperature); math.Abs(got-test.tension) > 0.01 {
+			t.Errorf("surface tension(%v K) = %.3f mN/m, want %.2f", test.temperature, got, test.tension)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package fluid
+
+import "math"
+
+// Transport properties used alongside the native IF97 backend.
+
+// --- VISCOSITY (IAPWS 2008, industrial form without the critical enhancement) ---
+var viscosityH0 = [...]float64{1.67752, 2.20462, 0.6366564, -0.241605}
+var viscosityHI = [...]float64{0, 1, 2, 3, 0, 1, 2, 3, 5, 0, 1, 2, 3, 4, 0, 1, 0, 3, 4, 3, 5}
+var viscosityHJ = [...]float64{0, 0, 0, 0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 4, 4, 5, 6, 6}
+var viscosityH = [...]float64{
+	5.20094e-1, 8.50895e-2, -1.08374, -2.89555e-1, 2.22531e-1, 9.99115e-1, 1.88797, 1.26613,
+	1.20573e-1, -2.81378e-1, -9.06851e-1, -7.72479e-1, -4.89837e-1, -2.57040e-1, 1.61913e-1, 2.57399e-1,
+	-3.25372e-2, 6.98452e-2, 8.72102e-3, -4.35673e-3, -5.93264e-4,
+}
+
+// singlePhaseViscosity returns the dynamic viscosity in Pa·s of water at the given density and temperature.
+func singlePhaseViscosity(densityKGM3 float64, temperatureK float64) float64 {
+	var tBar float64 = temperatureK / if97CriticalT
+	var rhoBar float64 = densityKGM3 / if97CriticalRho
+	var denominator float64 = 0
+	for i, h := range viscosityH0 {
+		denominator += h / math.Pow(tBar, float64(i))
+	}
+	var mu0 float64 = 100 * math.Sqrt(tBar) / denominator
+	var sum float64 = 0
+	for k, h := range viscosityH {
+		sum += h * math.Pow(1/tBar-1, viscosityHI[k]) * math.Pow(rhoBar-1, viscosityHJ[k])
+	}
+	var mu1 float64 = math.Exp(rhoBar * sum)
+	return mu0 * mu1 * 1e-6 // μPa·s to Pa·s
+}
+
+// dynamicViscosity returns the dynamic viscosity of the state in Pa·s.
+// Saturated mixtures use the McAdams quality weighting of the two phase viscosities.
+func (state if97State) dynamicViscosity() float64 {
+	if state.region != 4 {
+		return singlePhaseViscosity(1/state.specificVolume, state.temperature)
+	}
+	var liquidViscosity float64 = singlePhaseViscosity(state.liquidDensity, state.temperature)
+	var vapourViscosity float64 = singlePhaseViscosity(state.vapourDensity, state.temperature)
+	return 1 / (state.quality/vapourViscosity + (1-state.quality)/liquidViscosity)
+}
+
+// --- THERMAL CONDUCTIVITY (IAPWS 1998 industrial formulation, as recommended for use with IF97) ---
+
+// singlePhaseThermalConductivity returns the thermal conductivity in W/(m·K) of water at the given density and temperature.
+func singlePhaseThermalConductivity(densityKGM3 float64, temperatureK float64) float64 {
+	var tBar float64 = temperatureK / 647.26
+	var rhoBar float64 = densityKGM3 / 317.7
+	var lambda0 float64 = math.Sqrt(tBar) * (0.0102811 + 0.0299621*tBar + 0.0156146*tBar*tBar - 0.00422464*tBar*tBar*tBar)
+	var lambda1 float64 = -0.397070 + 0.400302*rhoBar + 1.060000*math.Exp(-0.171587*(rhoBar+2.392190)*(rhoBar+2.392190))
+	var deltaT float64 = math.Abs(tBar-1) + 0.00308976
+	var q float64 = 2 + 0.0822994/math.Pow(deltaT, 3.0/5)
+	var s float64 = 1 / deltaT
+	if tBar < 1 {
+		s = 10.0932 / math.Pow(deltaT, 3.0/5)
+	}
+	var lambda2 float64 = (0.0701309/math.Pow(tBar, 10)+0.0118520)*math.Pow(rhoBar, 9.0/5)*math.Exp(0.642857*(1-math.Pow(rhoBar, 14.0/5))) +
+		0.00169937*s*math.Pow(rhoBar, q)*math.Exp((q/(1+q))*(1-math.Pow(rhoBar, 1+q))) -
+		1.0200*math.Exp(-4.11717*math.Pow(tBar, 3.0/2)-6.17937/math.Pow(rhoBar, 5))
+	return lambda0 + lambda1 + lambda2
+}
+
+// thermalConductivity returns the thermal conductivity of the state in W/(m·K).
+// Saturated mixtures weight the two phase conductivities by quality.
+func (state if97State) thermalConductivity() float64 {
+	if state.region != 4 {
+		return singlePhaseThermalConductivity(1/state.specificVolume, state.temperature)
+	}
+	var liquidConductivity float64 = singlePhaseThermalConductivity(state.liquidDensity, state.temperature)
+	var vapourConductivity float64 = singlePhaseThermalConductivity(state.vapourDensity, state.temperature)
+	return state.quality*vapourConductivity + (1-state.quality)*liquidConductivity
+}
+
+// --- SURFACE TENSION (IAPWS 1994) ---
+
+// surfaceTension returns the surface tension in mN/m of water against its vapour at the given temperature.
+func surfaceTension(temperatureK float64) float64 {
+	var tau float64 = 1 - temperatureK/if97CriticalT
+	if tau <= 0 {
+		return 0 // no interface above the critical point
+	}
+	return 235.8 * math.Pow(tau, 1.256) * (1 - 0.625*tau)
+}
@@ -0,0 +1,104 @@
+package fluid
+
+import "math"
+
+// --- STRUCT DECLARATIONS ---
+
+// FluidJetPump is the mixing section and diffuser of a jet pump, chained into a flow path from the throat header to
+// the plenum it discharges into. The drive flow reaches the throat header through the nozzle, a junction ending at the
+// header, and the suction flow through the suction inlet, another junction ending there. The throat header is the
+// static pressure at the entry of the mixing section; the nozzle and the suction inlet must lose at least the velocity
+// head of their flow (a K-Factor of 1 or more) to reach it. The momentum both streams carry into the mixing section
+// raises the pressure along it, so the fast drive jet entrains the suction flow and pumps it into the plenum. The
+// momentum of the streams is taken at the flows of the last step, as the speed of a pump is, and at their own
+// densities rather than that of the throat header, whose static pressure may fall below saturation while the jet is fast.
+type FluidJetPump struct {
+	JunctionBase     FluidJunctionBase // the base junction info
+	ThroatDiameter   float64           // diameter of the mixing section in milimeters
+	DiffuserDiameter float64           // outlet diameter of the diffuser in milimeters
+	Length           float64           // length of the mixing section and diffuser in meters
+	ThroatKFactor    float64           // friction loss of the mixing section in velocity heads of the throat flow
+	InletElevation   float64           // elevation in meters of the mixing section
+	OutletElevation  float64           // elevation in meters where the diffuser discharges
+
+	DriveJunction   string // the nozzle, the pipe carrying the drive flow into the throat header
+	SuctionJunction string // the suction inlet, the pipe carrying the entrained flow into the throat header
+
+	DriveFlow      float64 // kg/s through the nozzle during the last step
+	SuctionFlow    float64 // kg/s through the suction inlet during the last step
+	DriveDensity   float64 // kg/m^3 of the drive stream during the last step
+	SuctionDensity float64 // kg/m^3 of the suction stream during the last step
+	NozzleArea     float64 // m^2, of the drive junction
+	SuctionArea    float64 // m^2, of the suction junction
+	MassFlowRate   float64 // kg/s, positive from source to destination
+}
+
+// --- CONSTANT DECLARATIONS ---
+const jetPumpDiffuserEfficiency float64 = 0.85 // share of the ideal pressure recovery the diffuser achieves
+
+func (jetPump FluidJetPump) base() FluidJunctionBase  { return jetPump.JunctionBase }
+func (jetPump FluidJetPump) kind() string             { return "JetPump" }
+func (jetPump FluidJetPump) inletElevation() float64  { return jetPump.InletElevation }
+func (jetPump FluidJetPump) outletElevation() float64 { return jetPump.OutletElevation }
+func (jetPump FluidJetPump) massFlowRate() float64    { return jetPump.MassFlowRate }
+func (jetPump FluidJetPump) inertia() float64 {
+	return jetPump.Length / pipeArea(jetPump.ThroatDiameter)
+}
+
+// pressureDrop is the loss of the mixing section and diffuser less the pressure the momentum of the drive and suction
+// streams adds. Forward through the throat, the momentum balance of the mixing section gives
+//
+//	p_out - p_in = (W_d²/(ρ_d·A_n) + W_s²/(ρ_s·A_s) - W²/(ρ·A_t)) / A_t - K·W²/(2·ρ·A_t²)
+//
+// and the diffuser recovers part of the velocity head of the throat, where ρ is the density of the mixed streams.
+// Against the throat, the jet pump is a plain loss and the streams only push back. Only the flow through the jet pump
+// itself is taken at the end of the step, so the drop rises with it like that of any other junction.
+func (jetPump FluidJetPump) pressureDrop(massFlowRate float64, stream WaterProperties) float64 {
+	var throatArea, diffuserArea float64 = pipeArea(jetPump.ThroatDiameter), pipeArea(jetPump.DiffuserDiameter)
+	var momentum float64 // N carried into the mixing section by the drive and suction streams
+	var inflow, inflowVolume float64
+	if jetPump.NozzleArea > 0 && jetPump.DriveFlow > 0 && jetPump.DriveDensity > 0 {
+		momentum += jetPump.DriveFlow * jetPump.DriveFlow / (jetPump.DriveDensity * jetPump.NozzleArea)
+		inflow, inflowVolume = inflow+jetPump.DriveFlow, inflowVolume+jetPump.DriveFlow/jetPump.DriveDensity
+	}
+	if jetPump.SuctionArea > 0 && jetPump.SuctionFlow > 0 && jetPump.SuctionDensity > 0 {
+		momentum += jetPump.SuctionFlow * jetPump.SuctionFlow / (jetPump.SuctionDensity * jetPump.SuctionArea)
+		inflow, inflowVolume = inflow+jetPump.SuctionFlow, inflowVolume+jetPump.SuctionFlow/jetPump.SuctionDensity
+	}
+	var density float64 = stream.Density
+	if massFlowRate >= 0 && inflowVolume > 0 {
+		density = inflow / inflowVolume
+	}
+	var velocityHead float64 = massFlowRate * math.Abs(massFlowRate) / (2 * density * throatArea * throatArea)
+	if massFlowRate < 0 {
+		return -momentum/throatArea + (1+jetPump.ThroatKFactor)*velocityHead
+	}
+	var recovery float64 = jetPumpDiffuserEfficiency * (1 - math.Pow(throatArea/diffuserArea, 2))
+	return -momentum/throatArea + (2+jetPump.ThroatKFactor-recovery)*velocityHead
+}
+
+// updateJetPumps hands every jet pump whose nozzle or suction inlet is the given junction the flow and the stream that
+// junction carried during the step just solved.
+func (network *FluidNetwork) updateJetPumps(junctionId string, stream WaterProperties) {
+	for id, jetPump := range network.JetPumps {
+		if jetPump.DriveJunction != junctionId && jetPump.SuctionJunction != junctionId {
+			continue
+		}
+		var pipe FluidPipe = network.Pipes[junctionId]
+		if jetPump.DriveJunction == junctionId {
+			jetPump.DriveFlow, jetPump.DriveDensity, jetPump.NozzleArea = pipe.MassFlowRate, stream.Density, pipeArea(pipe.PipeDiameter)
+		} else {
+			jetPump.SuctionFlow, jetPump.SuctionDensity, jetPump.SuctionArea = pipe.MassFlowRate, stream.Density, pipeArea(pipe.PipeDiameter)
+		}
+		network.JetPumps[id] = jetPump
+	}
+}
+
+// JetPumpFlow returns the total flow of all jet pumps in kg/s. In a BWR every drop of core flow passes a jet pump, so
+// this is the core flow, and it is measured the same way at the plant.
+func (network *FluidNetwork) JetPumpFlow() (flow float64) {
+	for _, id := range sortedKeys(network.JetPumps) {
+		flow += network.JetPumps[id].MassFlowRate
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package fluid
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestJetPumpInletsMustBePipesIntoTheThroat(t *testing.T) {
+	var tests = []struct {
+		name    string
+		modify  func(network *FluidNetwork)
+		problem bool
+	}{
+		{"built-in plant", func(network *FluidNetwork) {}, false},
+		{"pump as drive junction", func(network *FluidNetwork) {
+			var jetPump FluidJetPump = network.JetPumps["JetPumpA"]
+			jetPump.DriveJunction = "RecircPumpA"
+			network.JetPumps["JetPumpA"] = jetPump
+		}, true},
+		{"missing suction junction", func(network *FluidNetwork) {
+			var jetPump FluidJetPump = network.JetPumps["JetPumpA"]
+			jetPump.SuctionJunction = ""
+			network.JetPumps["JetPumpA"] = jetPump
+		}, true},
+		{"pipe into the other throat", func(network *FluidNetwork) {
+			var jetPump FluidJetPump = network.JetPumps["JetPumpA"]
+			jetPump.SuctionJunction = "JetPumpSuctionB"
+			network.JetPumps["JetPumpA"] = jetPump
+		}, true},
+		{"same pipe twice", func(network *FluidNetwork) {
+			var jetPump FluidJetPump = network.JetPumps["JetPumpA"]
+			jetPump.SuctionJunction = jetPump.DriveJunction
+			network.JetPumps["JetPumpA"] = jetPump
+		}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var network *FluidNetwork = DefaultPlantModel().Network()
+			test.modify(network)
+			var err error = network.Initialize()
+			var topologyError *TopologyError
+			var found bool
+			if errors.As(err, &topologyError) {
+				for _, problem := range topologyError.Problems {
+					found = found || problem.Kind == InvalidJetPumpInlet && problem.ID == "JetPumpA"
+				}
+			}
+			if found != test.problem {
+				t.Errorf("Initialize() = %v, want an invalid jet pump inlet problem %v", err, test.problem)
+			}
+		})
+	}
+}
+
+func TestJetPumpsEntrainAndConserveMass(t *testing.T) {
+	var network *FluidNetwork = DefaultPlantModel().Network()
+	if err := network.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	var initial float64 = totalMass(network)
+	for i := 0; i < 60; i += 1 {
+		network.SimulateFlow(time.Second)
+	}
+	if drift := math.Abs(totalMass(network)-initial) / initial; drift > 1e-9 {
+		t.Errorf("total mass changed by %.3g of %.0f kg", drift, initial)
+	}
+	var drive, suction float64
+	for _, jetPump := range network.JetPumps {
+		drive += jetPump.DriveFlow
+		suction += jetPump.SuctionFlow
+	}
+	if drive <= 0 || suction <= 0 {
+		t.Fatalf("drive flow %.0f kg/s and suction flow %.0f kg/s, want both positive", drive, suction)
+	}
+	if flow := network.JetPumpFlow(); math.Abs(flow-drive-suction) > 0.01*flow {
+		t.Errorf("jet pump flow %.0f kg/s, want the drive and suction flows %.0f kg/s", flow, drive+suction)
+	}
+}
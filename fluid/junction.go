@@ -0,0 +1,118 @@
+package fluid
+
+import "math"
+
+// junction is an element that can be chained into a flow path between nodes and headers: a pipe, a pump, a valve or a
+// jet pump.
+type junction interface {
+	base() FluidJunctionBase
+	kind() string                                                      // Pipe/Pump/Valve/JetPump
+	inertia() float64                                                  // L/A of the fluid in the junction in 1/m
+	inletElevation() float64                                           // meters
+	outletElevation() float64                                          // meters
+	pressureDrop(massFlowRate float64, stream WaterProperties) float64 // Pa, with the sign of the flow. Negative where the junction adds head.
+	massFlowRate() float64                                             // kg/s
+}
+
+func (pipe FluidPipe) base() FluidJunctionBase  { return pipe.JunctionBase }
+func (pipe FluidPipe) kind() string             { return "Pipe" }
+func (pipe FluidPipe) inletElevation() float64  { return pipe.InletElevation }
+func (pipe FluidPipe) outletElevation() float64 { return pipe.OutletElevation }
+func (pipe FluidPipe) massFlowRate() float64    { return pipe.MassFlowRate }
+func (pipe FluidPipe) inertia() float64 {
+	return pipe.PipeLength / pipeArea(pipe.PipeDiameter)
+}
+func (pipe FluidPipe) pressureDrop(massFlowRate float64, stream WaterProperties) float64 {
+	return pipe.PressureDrop(massFlowRate, stream)
+}
+
+// junction returns the pipe, pump, valve or jet pump with the given ID.
+func (network *FluidNetwork) junction(junctionId string) (junction, bool) {
+	if pipe, ok := network.Pipes[junctionId]; ok {
+		return pipe, true
+	}
+	if pump, ok := network.Pumps[junctionId]; ok {
+		return pump, true
+	}
+	if valve, ok := network.Valves[junctionId]; ok {
+		return valve, true
+	}
+	if jetPump, ok := network.JetPumps[junctionId]; ok {
+		return jetPump, true
+	}
+	return nil, false
+}
+
+// junctionIDs returns the IDs of all pipes, pumps, valves and jet pumps in order.
+func (network *FluidNetwork) junctionIDs() []string {
+	var bases map[string]FluidJunctionBase = make(map[string]FluidJunctionBase)
+	for id, pipe := range network.Pipes {
+		bases[id] = pipe.JunctionBase
+	}
+	for id, pump := range network.Pumps {
+		bases[id] = pump.JunctionBase
+	}
+	for id, valve := range network.Valves {
+		bases[id] = valve.JunctionBase
+	}
+	for id, jetPump := range network.JetPumps {
+		bases[id] = jetPump.JunctionBase
+	}
+	return sortedKeys(bases)
+}
+
+func (network *FluidNetwork) setJunctionMassFlowRate(junctionId string, massFlowRate float64) {
+	if pipe, ok := network.Pipes[junctionId]; ok {
+		pipe.MassFlowRate = massFlowRate
+		network.Pipes[junctionId] = pipe
+	}
+	if pump, ok := network.Pumps[junctionId]; ok {
+		pump.MassFlowRate = massFlowRate
+		network.Pumps[junctionId] = pump
+	}
+	if valve, ok := network.Valves[junctionId]; ok {
+		valve.MassFlowRate = massFlowRate
+		network.Valves[junctionId] = valve
+	}
+	if jetPump, ok := network.JetPumps[junctionId]; ok {
+		jetPump.MassFlowRate = massFlowRate
+		network.JetPumps[junctionId] = jetPump
+	}
+}
+
+// topologyJunctions collects the junction info of all pipes, pumps, valves and jet pumps for the topology validation. An ID used
+// by more than one junction is reported separately, as the map can only hold one of them.
+func (network *FluidNetwork) topologyJunctions() (junctions map[string]topologyJunction, duplicates []string) {
+	junctions = make(map[string]topologyJunction)
+	for id, pipe := range network.Pipes {
+		junctions[id] = topologyJunction{pipe.JunctionBase, "Pipe"}
+	}
+	for _, id := range sortedKeys(network.Pumps) {
+		if _, ok := junctions[id]; ok {
+			duplicates = append(duplicates, id)
+			continue
+		}
+		junctions[id] = topologyJunction{network.Pumps[id].JunctionBase, "Pump"}
+	}
+	for _, id := range sortedKeys(network.Valves) {
+		if _, ok := junctions[id]; ok {
+			duplicates = append(duplicates, id)
+			continue
+		}
+		junctions[id] = topologyJunction{network.Valves[id].JunctionBase, "Valve"}
+	}
+	for _, id := range sortedKeys(network.JetPumps) {
+		if _, ok := junctions[id]; ok {
+			duplicates = append(duplicates, id)
+			continue
+		}
+		junctions[id] = topologyJunction{network.JetPumps[id].JunctionBase, "JetPump"}
+	}
+	return
+}
+
+// pipeArea returns the flow area in m^2 of a pipe with the given diameter in milimeters.
+func pipeArea(diameterMM float64) float64 {
+	var diameter float64 = diameterMM / 1000
+	return math.Pi * diameter * diameter / 4
+}
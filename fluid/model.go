@@ -0,0 +1,790 @@
+package fluid
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// --- CONSTANT DECLARATIONS ---
+const PlantModelVersion int = 2       // The model file version this build reads. Bump it whenever the schema changes incompatibly.
+const saturationTolerance float64 = 1 // K a closed node with a steam space may start off the saturation temperature of its pressure
+
+//go:embed models/default.json
+var defaultPlantModel []byte // The built-in test plant.
+
+// plantSections are the top-level sections of a model file that configure the reactor and its protection system, see
+// simulation.PlantModel. The fluid network skips them, so it can be read from the file of a whole plant.
+var plantSections []string = []string{"reactor", "protection"}
+
+// --- STRUCT DECLARATIONS ---
+
+// PlantModel is the declarative description of a plant layout, usually read from a JSON model file.
+type PlantModel struct {
+	Version int                    `json:"version"`
+	Nodes   map[string]NodeModel   `json:"nodes"`
+	Headers map[string]HeaderModel `json:"headers"`
+	Pipes   map[string]PipeModel   `json:"pipes"`
+	Pumps   map[string]PumpModel   `json:"pumps"`
+	Valves  map[string]ValveModel  `json:"valves"`
+
+	JetPumps map[string]JetPumpModel `json:"jetPumps"`
+
+	HeatStructures map[string]HeatStructureModel `json:"heatStructures"`
+}
+
+// NodeModel holds the configuration and initial conditions of a fluid node. Required fields are pointers so a missing
+// value can be told apart from zero.
+type NodeModel struct {
+	Temperature *float64 `json:"temperature"` // initial temperature in degrees Celsius
+	Pressure    *float64 `json:"pressure"`    // initial pressure in Pa
+	Volume      *float64 `json:"volume"`      // initial liquid volume in cubic meters
+	MaxVolume   *float64 `json:"maxVolume"`   // cubic meters
+	Closed      bool     `json:"closed"`      // a sealed vessel, see FluidNode.Closed
+
+	BottomElevation *float64 `json:"bottomElevation"` // meters
+	TopElevation    *float64 `json:"topElevation"`    // meters
+}
+
+// HeaderModel describes a header. Its state is solved from the flows through it, so it has no initial conditions.
+type HeaderModel struct{}
+
+// PipeModel holds the configuration of a pipe.
+type PipeModel struct {
+	SourceType      string   `json:"sourceType"`      // Node/Header/Junction
+	SourceID        string   `json:"sourceId"`        // e.g. FeedwaterHeader
+	DestinationType string   `json:"destinationType"` // Node/Header/Junction
+	DestinationID   string   `json:"destinationId"`   // e.g. ReactorVessel
+	Diameter        *float64 `json:"diameter"`        // milimeters
+	Length          *float64 `json:"length"`          // meters
+	MinorK          float64  `json:"minorK"`          // K-Factor of fittings, elbows... in the pipe
+	Material        string   `json:"material"`        // a key of PipeRoughness, CommercialSteel if empty
+	Roughness       *float64 `json:"roughness"`       // meters, overrides the roughness of the material
+	FrictionModel   string   `json:"frictionModel"`   // Churchill/ColebrookWhite/SwameeJain/Laminar, Churchill if empty
+	InletElevation  float64  `json:"inletElevation"`  // meters, where the pipe leaves its source
+	OutletElevation float64  `json:"outletElevation"` // meters, where the pipe enters its destination
+}
+
+// PumpModel holds the configuration and initial conditions of a pump. Rated values describe the best efficiency point.
+type PumpModel struct {
+	SourceType      string    `json:"sourceType"`      // Node/Header/Junction
+	SourceID        string    `json:"sourceId"`        // e.g. Hotwell
+	DestinationType string    `json:"destinationType"` // Node/Header/Junction
+	DestinationID   string    `json:"destinationId"`   // e.g. FeedwaterHeader
+	Diameter        *float64  `json:"diameter"`        // milimeters, of the suction and discharge nozzles
+	Length          *float64  `json:"length"`          // meters of flow passage through the pump, 1 if omitted
+	InletElevation  float64   `json:"inletElevation"`  // meters
+	OutletElevation float64   `json:"outletElevation"` // meters
+	RatedFlow       *float64  `json:"ratedFlow"`       // m^3/s
+	RatedHead       *float64  `json:"ratedHead"`       // m
+	RatedSpeed      *float64  `json:"ratedSpeed"`      // rpm
+	RatedDensity    *float64  `json:"ratedDensity"`    // kg/m^3, 1000 if omitted
+	RatedTorque     *float64  `json:"ratedTorque"`     // N·m, derived from the rated point at 80 % efficiency if omitted
+	HeadCurve       []float64 `json:"headCurve"`       // homologous head curve, see FluidPump.HeadCurve. DefaultPumpHeadCurve if omitted
+	TorqueCurve     []float64 `json:"torqueCurve"`     // homologous torque curve. DefaultPumpTorqueCurve if omitted
+	Inertia         *float64  `json:"inertia"`         // kg·m²
+	FrictionTorque  *float64  `json:"frictionTorque"`  // N·m at rated speed, 2 % of the rated torque if omitted
+	MaxMotorTorque  *float64  `json:"maxMotorTorque"`  // N·m, 1.5 times the rated torque if omitted
+	SpeedDemand     *float64  `json:"speedDemand"`     // rpm, the rated speed if omitted
+	Speed           float64   `json:"speed"`           // initial speed in rpm
+	Running         bool      `json:"running"`         // the motor breaker starts closed
+}
+
+// ValveModel holds the configuration and initial conditions of a valve.
+type ValveModel struct {
+	SourceType      string   `json:"sourceType"`      // Node/Header/Junction
+	SourceID        string   `json:"sourceId"`        // e.g. MainSteamLineA
+	DestinationType string   `json:"destinationType"` // Node/Header/Junction
+	DestinationID   string   `json:"destinationId"`   // e.g. SteamHeader
+	Type            string   `json:"type"`            // Gate/Globe/Butterfly/Check
+	Diameter        *float64 `json:"diameter"`        // milimeters
+	Length          *float64 `json:"length"`          // meters of flow passage through the valve, 1 if omitted
+	OpenK           *float64 `json:"openK"`           // loss coefficient when fully open, a typical value for the type if omitted
+	InletElevation  float64  `json:"inletElevation"`  // meters
+	OutletElevation float64  `json:"outletElevation"` // meters
+	StrokeTime      *float64 `json:"strokeTime"`      // seconds for a full stroke, required unless the valve is a check valve
+	FailPosition    string   `json:"failPosition"`    // AsIs/Open/Closed, AsIs if empty
+	Position        *float64 `json:"position"`        // initial and commanded position, 0 closed to 1 fully open. 1 if omitted
+	Failed          bool     `json:"failed"`          // the actuator starts without power
+}
+
+// JetPumpModel holds the configuration of a jet pump, see FluidJetPump. Its source is the throat header, which the
+// nozzle and suction inlet pipes lead into.
+type JetPumpModel struct {
+	SourceType       string   `json:"sourceType"`       // Header
+	SourceID         string   `json:"sourceId"`         // e.g. JetPumpThroatA
+	DestinationType  string   `json:"destinationType"`  // Node/Header/Junction
+	DestinationID    string   `json:"destinationId"`    // e.g. LowerPlenum
+	ThroatDiameter   *float64 `json:"throatDiameter"`   // milimeters
+	DiffuserDiameter *float64 `json:"diffuserDiameter"` // milimeters, at the outlet of the diffuser
+	Length           *float64 `json:"length"`           // meters of mixing section and diffuser
+	ThroatK          *float64 `json:"throatK"`          // friction loss of the mixing section in velocity heads, 0.1 if omitted
+	InletElevation   float64  `json:"inletElevation"`   // meters
+	OutletElevation  float64  `json:"outletElevation"`  // meters
+	DriveJunction    string   `json:"driveJunction"`    // the nozzle pipe
+	SuctionJunction  string   `json:"suctionJunction"`  // the suction inlet pipe
+}
+
+// HeatStructureModel holds the configuration and initial temperature of a heat structure.
+type HeatStructureModel struct {
+	Geometry     string                    `json:"geometry"`     // Slab/Cylinder
+	Material     string                    `json:"material"`     // a key of SolidMaterials
+	Density      *float64                  `json:"density"`      // kg/m^3, overrides the density of the material
+	SpecificHeat *float64                  `json:"specificHeat"` // J/(kg·K), overrides the specific heat of the material
+	Conductivity *float64                  `json:"conductivity"` // W/(m·K), overrides the conductivity of the material
+	InnerRadius  float64                   `json:"innerRadius"`  // meters, of a cylinder
+	Thickness    *float64                  `json:"thickness"`    // meters
+	Area         *float64                  `json:"area"`         // m^2 of a slab face
+	Length       *float64                  `json:"length"`       // meters of a cylinder
+	Cells        int                       `json:"cells"`        // mesh cells across the thickness, 5 if omitted
+	Temperature  *float64                  `json:"temperature"`  // initial temperature in degrees Celsius
+	Inner        HeatStructureSurfaceModel `json:"inner"`
+	Outer        HeatStructureSurfaceModel `json:"outer"`
+}
+
+// HeatStructureSurfaceModel describes what a surface of a heat structure exchanges heat with. A surface with neither
+// a node nor an ambient is adiabatic.
+type HeatStructureSurfaceModel struct {
+	Node                    string   `json:"node"`                    // the node wetting the surface
+	Junction                string   `json:"junction"`                // the pipe, pump, valve or jet pump whose flow sweeps the surface
+	HydraulicDiameter       *float64 `json:"hydraulicDiameter"`       // meters, required with a node
+	FlowArea                *float64 `json:"flowArea"`                // m^2, required with a junction
+	BottomElevation         *float64 `json:"bottomElevation"`         // meters, the bottom of the node if omitted
+	TopElevation            *float64 `json:"topElevation"`            // meters, the top of the node if omitted
+	AmbientTemperature      *float64 `json:"ambientTemperature"`      // degrees Celsius
+	HeatTransferCoefficient *float64 `json:"heatTransferCoefficient"` // W/(m²·K) to the ambient
+}
+
+// DefaultPlantModel returns the built-in test plant.
+func DefaultPlantModel() PlantModel {
+	var model, err = ParsePlantModel(defaultPlantModel)
+	if err != nil {
+		panic("built-in plant model is invalid: " + err.Error())
+	}
+	return model
+}
+
+// LoadPlantModel reads and validates the fluid network of a plant model file.
+func LoadPlantModel(path string) (PlantModel, error) {
+	return LoadModel(path, ParsePlantModel)
+}
+
+// ParsePlantModel decodes and validates the fluid network of a JSON plant model, see DecodeModel. The reactor and
+// protection sections of a whole plant are skipped.
+func ParsePlantModel(data []byte) (PlantModel, error) {
+	return DecodeModel[PlantModel](data, plantSections...)
+}
+
+// LoadModel reads a model file and parses it with the given function, such as ParsePlantModel. Errors are prefixed
+// with the path of the file.
+func LoadModel[M any](path string, parse func(data []byte) (M, error)) (M, error) {
+	var zero M
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return zero, err
+	}
+	model, err := parse(data)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", path, err)
+	}
+	return model, nil
+}
+
+// DecodeModel decodes and validates a JSON model. Unknown fields are rejected, so a misspelt key is reported instead
+// of silently falling back to zero, except for the top-level sections named in skip, which belong to another part of
+// the plant.
+func DecodeModel[M interface{ Validate() error }](data []byte, skip ...string) (M, error) {
+	var model, zero M
+	if len(skip) > 0 {
+		var sections map[string]json.RawMessage
+		if err := json.Unmarshal(data, &sections); err != nil {
+			return zero, err
+		}
+		for _, section := range skip {
+			delete(sections, section)
+		}
+		data, _ = json.Marshal(sections) // raw sections always marshal
+	}
+	var decoder *json.Decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&model); err != nil {
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &typeError) {
+			return zero, fmt.Errorf("%s: expected %s, got %s", typeError.Field, typeError.Type, typeError.Value)
+		}
+		return zero, err
+	}
+	if err := model.Validate(); err != nil {
+		return zero, err
+	}
+	return model, nil
+}
+
+// Validate checks every node and pipe of the model against the schema and the layout of the network against the
+// topology rules, and reports all problems found at once.
+func (model PlantModel) Validate() error {
+	var problems []error
+	var problem = func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if model.Version != PlantModelVersion {
+		problem("unsupported model version %d, expected %d", model.Version, PlantModelVersion)
+	}
+	if len(model.Nodes) == 0 {
+		problem("model has no nodes")
+	}
+
+	for _, id := range sortedKeys(model.Nodes) {
+		var node NodeModel = model.Nodes[id]
+		if node.Temperature == nil {
+			problem("node %q: temperature is missing", id)
+		} else if *node.Temperature < 0 || *node.Temperature > 800 {
+			problem("node %q: temperature %g °C is outside the IF97 range of 0 to 800 °C", id, *node.Temperature)
+		}
+		if node.Pressure == nil {
+			problem("node %q: pressure is missing", id)
+		} else if *node.Pressure <= 0 || *node.Pressure > 100000000 {
+			problem("node %q: pressure %g Pa is outside the IF97 range of 0 to 100 MPa", id, *node.Pressure)
+		}
+		if node.MaxVolume == nil {
+			problem("node %q: maxVolume is missing", id)
+		} else if *node.MaxVolume <= 0 {
+			problem("node %q: maxVolume must be positive, got %g", id, *node.MaxVolume)
+		}
+		if node.Volume == nil {
+			problem("node %q: volume is missing", id)
+		} else if *node.Volume < 0 {
+			problem("node %q: volume must not be negative, got %g", id, *node.Volume)
+		} else if node.MaxVolume != nil && *node.Volume > *node.MaxVolume {
+			problem("node %q: volume %g is larger than maxVolume %g", id, *node.Volume, *node.MaxVolume)
+		} else if node.Closed && node.MaxVolume != nil && *node.Volume < *node.MaxVolume && node.Temperature != nil && node.Pressure != nil {
+			if *node.Temperature >= CriticalTemperature {
+				problem("node %q: a closed node above the critical temperature has no vapour to fill it, volume must equal maxVolume", id)
+			} else if boiling := saturationTemperature(*node.Pressure/1000000) - 273.15; !(math.Abs(boiling-*node.Temperature) <= saturationTolerance) {
+				problem("node %q: a closed node with a steam space starts saturated, but %g °C is not the saturation temperature of %g Pa", id, *node.Temperature, *node.Pressure)
+			}
+		}
+		if node.BottomElevation == nil {
+			problem("node %q: bottomElevation is missing", id)
+		}
+		if node.TopElevation == nil {
+			problem("node %q: topElevation is missing", id)
+		} else if node.BottomElevation != nil && *node.TopElevation <= *node.BottomElevation {
+			problem("node %q: topElevation %g must be above bottomElevation %g", id, *node.TopElevation, *node.BottomElevation)
+		}
+	}
+
+	for _, id := range sortedKeys(model.Pipes) {
+		var pipe PipeModel = model.Pipes[id]
+		if pipe.SourceID == "" {
+			problem("pipe %q: sourceId is missing", id)
+		}
+		if pipe.DestinationID == "" {
+			problem("pipe %q: destinationId is missing", id)
+		}
+		if pipe.Diameter == nil {
+			problem("pipe %q: diameter is missing", id)
+		} else if *pipe.Diameter <= 0 {
+			problem("pipe %q: diameter must be positive, got %g", id, *pipe.Diameter)
+		}
+		if pipe.Length == nil {
+			problem("pipe %q: length is missing", id)
+		} else if *pipe.Length <= 0 {
+			problem("pipe %q: length must be positive, got %g", id, *pipe.Length)
+		}
+		if pipe.MinorK < 0 {
+			problem("pipe %q: minorK must not be negative, got %g", id, pipe.MinorK)
+		}
+		if _, ok := PipeRoughness[pipe.Material]; pipe.Material != "" && !ok {
+			problem("pipe %q: unknown material %q", id, pipe.Material)
+		}
+		if pipe.Roughness != nil && *pipe.Roughness < 0 {
+			problem("pipe %q: roughness must not be negative, got %g", id, *pipe.Roughness)
+		}
+		switch FrictionModel(pipe.FrictionModel) {
+		case "", Churchill, ColebrookWhite, SwameeJain, Laminar:
+		default:
+			problem("pipe %q: unknown friction model %q, expected Churchill, ColebrookWhite, SwameeJain or Laminar", id, pipe.FrictionModel)
+		}
+	}
+
+	for _, id := range sortedKeys(model.Pumps) {
+		var pump PumpModel = model.Pumps[id]
+		if pump.SourceID == "" {
+			problem("pump %q: sourceId is missing", id)
+		}
+		if pump.DestinationID == "" {
+			problem("pump %q: destinationId is missing", id)
+		}
+		var required = func(name string, value *float64) {
+			if value == nil {
+				problem("pump %q: %s is missing", id, name)
+			} else if *value <= 0 {
+				problem("pump %q: %s must be positive, got %g", id, name, *value)
+			}
+		}
+		var optional = func(name string, value *float64) {
+			if value != nil && *value <= 0 {
+				problem("pump %q: %s must be positive, got %g", id, name, *value)
+			}
+		}
+		required("diameter", pump.Diameter)
+		required("ratedFlow", pump.RatedFlow)
+		required("ratedHead", pump.RatedHead)
+		required("ratedSpeed", pump.RatedSpeed)
+		required("inertia", pump.Inertia)
+		optional("length", pump.Length)
+		optional("ratedDensity", pump.RatedDensity)
+		optional("ratedTorque", pump.RatedTorque)
+		optional("maxMotorTorque", pump.MaxMotorTorque)
+		if pump.FrictionTorque != nil && *pump.FrictionTorque < 0 {
+			problem("pump %q: frictionTorque must not be negative, got %g", id, *pump.FrictionTorque)
+		}
+		if pump.SpeedDemand != nil && *pump.SpeedDemand < 0 {
+			problem("pump %q: speedDemand must not be negative, got %g", id, *pump.SpeedDemand)
+		} else if pump.SpeedDemand != nil && pump.RatedSpeed != nil && *pump.SpeedDemand > pumpMaxSpeedRatio**pump.RatedSpeed {
+			problem("pump %q: speedDemand %g is above %.0f%% of ratedSpeed", id, *pump.SpeedDemand, 100*pumpMaxSpeedRatio)
+		}
+		if pump.HeadCurve != nil && len(pump.HeadCurve) < 3 {
+			problem("pump %q: headCurve needs at least 3 points, got %d", id, len(pump.HeadCurve))
+		}
+		if pump.TorqueCurve != nil && len(pump.TorqueCurve) < 3 {
+			problem("pump %q: torqueCurve needs at least 3 points, got %d", id, len(pump.TorqueCurve))
+		}
+	}
+
+	for _, id := range sortedKeys(model.Valves) {
+		var valve ValveModel = model.Valves[id]
+		if valve.SourceID == "" {
+			problem("valve %q: sourceId is missing", id)
+		}
+		if valve.DestinationID == "" {
+			problem("valve %q: destinationId is missing", id)
+		}
+		switch ValveType(valve.Type) {
+		case GateValve, GlobeValve, ButterflyValve, CheckValve:
+		case "":
+			problem("valve %q: type is missing", id)
+		default:
+			problem("valve %q: unknown type %q, expected Gate, Globe, Butterfly or Check", id, valve.Type)
+		}
+		if valve.Diameter == nil {
+			problem("valve %q: diameter is missing", id)
+		} else if *valve.Diameter <= 0 {
+			problem("valve %q: diameter must be positive, got %g", id, *valve.Diameter)
+		}
+		if valve.Length != nil && *valve.Length <= 0 {
+			problem("valve %q: length must be positive, got %g", id, *valve.Length)
+		}
+		if valve.OpenK != nil && *valve.OpenK <= 0 {
+			problem("valve %q: openK must be positive, got %g", id, *valve.OpenK)
+		}
+		if ValveType(valve.Type) == CheckValve {
+			if valve.StrokeTime != nil || valve.FailPosition != "" || valve.Position != nil || valve.Failed {
+				problem("valve %q: a check valve has no actuator, strokeTime, failPosition, position and failed do not apply", id)
+			}
+			continue
+		}
+		if valve.StrokeTime == nil {
+			problem("valve %q: strokeTime is missing", id)
+		} else if *valve.StrokeTime < 0 {
+			problem("valve %q: strokeTime must not be negative, got %g", id, *valve.StrokeTime)
+		}
+		switch ValveFailPosition(valve.FailPosition) {
+		case "", FailAsIs, FailOpen, FailClosed:
+		default:
+			problem("valve %q: unknown fail position %q, expected AsIs, Open or Closed", id, valve.FailPosition)
+		}
+		if valve.Position != nil && (*valve.Position < 0 || *valve.Position > 1) {
+			problem("valve %q: position must be between 0 and 1, got %g", id, *valve.Position)
+		}
+	}
+
+	for _, id := range sortedKeys(model.JetPumps) {
+		var jetPump JetPumpModel = model.JetPumps[id]
+		if jetPump.SourceType != "Header" {
+			problem("jet pump %q: sourceType must be Header, the throat header its nozzle and suction inlet lead into, got %q", id, jetPump.SourceType)
+		}
+		if jetPump.SourceID == "" {
+			problem("jet pump %q: sourceId is missing", id)
+		}
+		if jetPump.DestinationID == "" {
+			problem("jet pump %q: destinationId is missing", id)
+		}
+		var required = func(name string, value *float64) {
+			if value == nil {
+				problem("jet pump %q: %s is missing", id, name)
+			} else if *value <= 0 {
+				problem("jet pump %q: %s must be positive, got %g", id, name, *value)
+			}
+		}
+		required("throatDiameter", jetPump.ThroatDiameter)
+		required("diffuserDiameter", jetPump.DiffuserDiameter)
+		required("length", jetPump.Length)
+		if jetPump.ThroatDiameter != nil && jetPump.DiffuserDiameter != nil && *jetPump.DiffuserDiameter < *jetPump.ThroatDiameter {
+			problem("jet pump %q: diffuserDiameter %g must not be smaller than throatDiameter %g", id, *jetPump.DiffuserDiameter, *jetPump.ThroatDiameter)
+		}
+		if jetPump.ThroatK != nil && *jetPump.ThroatK < 0 {
+			problem("jet pump %q: throatK must not be negative, got %g", id, *jetPump.ThroatK)
+		}
+	}
+
+	for _, id := range sortedKeys(model.HeatStructures) {
+		var structure HeatStructureModel = model.HeatStructures[id]
+		var positive = func(name string, value *float64, required bool) {
+			if value == nil && required {
+				problem("heat structure %q: %s is missing", id, name)
+			} else if value != nil && *value <= 0 {
+				problem("heat structure %q: %s must be positive, got %g", id, name, *value)
+			}
+		}
+		switch HeatStructureGeometry(structure.Geometry) {
+		case Slab:
+			positive("area", structure.Area, true)
+		case Cylinder:
+			positive("length", structure.Length, true)
+			if structure.InnerRadius < 0 {
+				problem("heat structure %q: innerRadius must not be negative, got %g", id, structure.InnerRadius)
+			}
+		case "":
+			problem("heat structure %q: geometry is missing", id)
+		default:
+			problem("heat structure %q: unknown geometry %q, expected Slab or Cylinder", id, structure.Geometry)
+		}
+		if _, ok := SolidMaterials[structure.Material]; !ok && structure.Material != "" {
+			problem("heat structure %q: unknown material %q", id, structure.Material)
+		} else if structure.Material == "" && (structure.Density == nil || structure.SpecificHeat == nil || structure.Conductivity == nil) {
+			problem("heat structure %q: material is missing, give a material or its density, specificHeat and conductivity", id)
+		}
+		positive("density", structure.Density, false)
+		positive("specificHeat", structure.SpecificHeat, false)
+		positive("conductivity", structure.Conductivity, false)
+		positive("thickness", structure.Thickness, true)
+		if structure.Cells < 0 {
+			problem("heat structure %q: cells must be at least 1 to hold any heat, got %d", id, structure.Cells)
+		}
+		if structure.Temperature == nil {
+			problem("heat structure %q: temperature is missing", id)
+		}
+		for _, side := range []struct {
+			name    string
+			surface HeatStructureSurfaceModel
+		}{{"inner", structure.Inner}, {"outer", structure.Outer}} {
+			var surface HeatStructureSurfaceModel = side.surface
+			var ambient bool = surface.AmbientTemperature != nil || surface.HeatTransferCoefficient != nil
+			if surface.Node == "" {
+				if surface.Junction != "" || surface.HydraulicDiameter != nil || surface.FlowArea != nil || surface.BottomElevation != nil || surface.TopElevation != nil {
+					problem("heat structure %q: %s surface has flow settings but no node", id, side.name)
+				}
+				if ambient && (surface.AmbientTemperature == nil || surface.HeatTransferCoefficient == nil) {
+					problem("heat structure %q: %s surface needs both ambientTemperature and heatTransferCoefficient", id, side.name)
+				}
+				if surface.HeatTransferCoefficient != nil && *surface.HeatTransferCoefficient < 0 {
+					problem("heat structure %q: %s heatTransferCoefficient must not be negative, got %g", id, side.name, *surface.HeatTransferCoefficient)
+				}
+				continue
+			}
+			if ambient {
+				problem("heat structure %q: %s surface cannot face both node %q and an ambient", id, side.name, surface.Node)
+			}
+			if _, ok := model.Nodes[surface.Node]; !ok {
+				problem("heat structure %q: %s surface faces node %q, which does not exist", id, side.name, surface.Node)
+			}
+			if side.name == "inner" && HeatStructureGeometry(structure.Geometry) == Cylinder && structure.InnerRadius == 0 {
+				problem("heat structure %q: a solid cylinder has no inner surface to face node %q", id, surface.Node)
+			}
+			if surface.HydraulicDiameter == nil {
+				problem("heat structure %q: %s hydraulicDiameter is missing", id, side.name)
+			} else if *surface.HydraulicDiameter <= 0 {
+				problem("heat structure %q: %s hydraulicDiameter must be positive, got %g", id, side.name, *surface.HydraulicDiameter)
+			}
+			if surface.Junction != "" {
+				_, isPipe := model.Pipes[surface.Junction]
+				_, isPump := model.Pumps[surface.Junction]
+				_, isValve := model.Valves[surface.Junction]
+				_, isJetPump := model.JetPumps[surface.Junction]
+				if !isPipe && !isPump && !isValve && !isJetPump {
+					problem("heat structure %q: %s surface is swept by junction %q, which does not exist", id, side.name, surface.Junction)
+				}
+				if surface.FlowArea == nil {
+					problem("heat structure %q: %s flowArea is missing", id, side.name)
+				} else if *surface.FlowArea <= 0 {
+					problem("heat structure %q: %s flowArea must be positive, got %g", id, side.name, *surface.FlowArea)
+				}
+			}
+			if (surface.BottomElevation == nil) != (surface.TopElevation == nil) {
+				problem("heat structure %q: %s surface needs both bottomElevation and topElevation or neither", id, side.name)
+			} else if surface.BottomElevation != nil && *surface.TopElevation < *surface.BottomElevation {
+				problem("heat structure %q: %s topElevation %g is below bottomElevation %g", id, side.name, *surface.TopElevation, *surface.BottomElevation)
+			}
+		}
+	}
+
+	var nodes map[string]FluidNode = make(map[string]FluidNode) // the layout can be checked even if some fields are invalid
+	for id := range model.Nodes {
+		nodes[id] = FluidNode{}
+	}
+	var headers map[string]FluidHeader = make(map[string]FluidHeader)
+	for id := range model.Headers {
+		headers[id] = FluidHeader{}
+	}
+	var network *FluidNetwork = NewFluidNetwork(nodes, headers, make(map[string]FluidPipe), nil, nil)
+	for id, pipe := range model.Pipes {
+		network.Pipes[id] = FluidPipe{JunctionBase: FluidJunctionBase{pipe.SourceType, pipe.SourceID, pipe.DestinationType, pipe.DestinationID}}
+	}
+	for id, pump := range model.Pumps {
+		network.Pumps[id] = FluidPump{JunctionBase: FluidJunctionBase{pump.SourceType, pump.SourceID, pump.DestinationType, pump.DestinationID}}
+	}
+	for id, valve := range model.Valves {
+		network.Valves[id] = FluidValve{JunctionBase: FluidJunctionBase{valve.SourceType, valve.SourceID, valve.DestinationType, valve.DestinationID}}
+	}
+	for id, jetPump := range model.JetPumps {
+		network.JetPumps[id] = FluidJetPump{
+			JunctionBase:    FluidJunctionBase{jetPump.SourceType, jetPump.SourceID, jetPump.DestinationType, jetPump.DestinationID},
+			DriveJunction:   jetPump.DriveJunction,
+			SuctionJunction: jetPump.SuctionJunction,
+		}
+	}
+	for _, topologyProblem := range network.Validate() {
+		problems = append(problems, topologyProblem)
+	}
+	return errors.Join(problems...)
+}
+
+// Network builds an uninitialized fluid network from the model.
+func (model PlantModel) Network() *FluidNetwork {
+	var nodes map[string]FluidNode = make(map[string]FluidNode)
+	for id, node := range model.Nodes {
+		nodes[id] = FluidNode{
+			Temperature: *node.Temperature,
+			Pressure:    *node.Pressure,
+			Volume:      *node.Volume,
+			MaxVolume:   *node.MaxVolume,
+			Closed:      node.Closed,
+
+			BottomElevation: *node.BottomElevation,
+			TopElevation:    *node.TopElevation,
+		}
+	}
+	var headers map[string]FluidHeader = make(map[string]FluidHeader)
+	for id := range model.Headers {
+		headers[id] = FluidHeader{}
+	}
+	var pipes map[string]FluidPipe = make(map[string]FluidPipe)
+	for id, pipe := range model.Pipes {
+		var roughness float64 = PipeRoughness["CommercialSteel"]
+		if pipe.Material != "" {
+			roughness = PipeRoughness[pipe.Material]
+		}
+		if pipe.Roughness != nil {
+			roughness = *pipe.Roughness
+		}
+		var frictionModel FrictionModel = Churchill
+		if pipe.FrictionModel != "" {
+			frictionModel = FrictionModel(pipe.FrictionModel)
+		}
+		pipes[id] = FluidPipe{
+			JunctionBase: FluidJunctionBase{
+				SourceType:      pipe.SourceType,
+				SourceID:        pipe.SourceID,
+				DestinationType: pipe.DestinationType,
+				DestinationID:   pipe.DestinationID,
+			},
+			PipeDiameter:  *pipe.Diameter,
+			PipeLength:    *pipe.Length,
+			MinorKFactor:  pipe.MinorK,
+			Roughness:     roughness,
+			FrictionModel: frictionModel,
+
+			InletElevation:  pipe.InletElevation,
+			OutletElevation: pipe.OutletElevation,
+		}
+	}
+	var pumps map[string]FluidPump = make(map[string]FluidPump)
+	for id, pump := range model.Pumps {
+		var valueOr = func(value *float64, fallback float64) float64 {
+			if value != nil {
+				return *value
+			}
+			return fallback
+		}
+		var ratedDensity float64 = valueOr(pump.RatedDensity, 1000)
+		var ratedSpeed float64 = *pump.RatedSpeed
+		var ratedTorque float64 = valueOr(pump.RatedTorque, ratedDensity*gravity**pump.RatedHead**pump.RatedFlow/(pumpRatedEfficiency*ratedSpeed*2*math.Pi/60))
+		var headCurve, torqueCurve []float64 = DefaultPumpHeadCurve(), DefaultPumpTorqueCurve()
+		if pump.HeadCurve != nil {
+			headCurve = pump.HeadCurve
+		}
+		if pump.TorqueCurve != nil {
+			torqueCurve = pump.TorqueCurve
+		}
+		pumps[id] = FluidPump{
+			JunctionBase: FluidJunctionBase{
+				SourceType:      pump.SourceType,
+				SourceID:        pump.SourceID,
+				DestinationType: pump.DestinationType,
+				DestinationID:   pump.DestinationID,
+			},
+			Diameter:        *pump.Diameter,
+			Length:          valueOr(pump.Length, 1),
+			InletElevation:  pump.InletElevation,
+			OutletElevation: pump.OutletElevation,
+
+			RatedFlow:    *pump.RatedFlow,
+			RatedHead:    *pump.RatedHead,
+			RatedSpeed:   ratedSpeed,
+			RatedTorque:  ratedTorque,
+			RatedDensity: ratedDensity,
+			HeadCurve:    headCurve,
+			TorqueCurve:  torqueCurve,
+
+			Inertia:        *pump.Inertia,
+			FrictionTorque: valueOr(pump.FrictionTorque, 0.02*ratedTorque),
+			MaxMotorTorque: valueOr(pump.MaxMotorTorque, 1.5*ratedTorque),
+
+			SpeedDemand: valueOr(pump.SpeedDemand, ratedSpeed),
+			Tripped:     !pump.Running,
+			Speed:       pump.Speed,
+		}
+	}
+	var valves map[string]FluidValve = make(map[string]FluidValve)
+	for id, valve := range model.Valves {
+		var valveType ValveType = ValveType(valve.Type)
+		var openK float64 = defaultValveOpenK[valveType]
+		if valve.OpenK != nil {
+			openK = *valve.OpenK
+		}
+		var length, strokeTime, position float64 = 1, 0, 1
+		if valve.Length != nil {
+			length = *valve.Length
+		}
+		if valve.StrokeTime != nil {
+			strokeTime = *valve.StrokeTime
+		}
+		if valve.Position != nil {
+			position = *valve.Position
+		}
+		var failPosition ValveFailPosition = FailAsIs
+		if valve.FailPosition != "" {
+			failPosition = ValveFailPosition(valve.FailPosition)
+		}
+		valves[id] = FluidValve{
+			JunctionBase: FluidJunctionBase{
+				SourceType:      valve.SourceType,
+				SourceID:        valve.SourceID,
+				DestinationType: valve.DestinationType,
+				DestinationID:   valve.DestinationID,
+			},
+			Type:            valveType,
+			Diameter:        *valve.Diameter,
+			Length:          length,
+			OpenKFactor:     openK,
+			InletElevation:  valve.InletElevation,
+			OutletElevation: valve.OutletElevation,
+
+			StrokeTime:   strokeTime,
+			FailPosition: failPosition,
+
+			Position:          position,
+			CommandedPosition: position,
+			Failed:            valve.Failed,
+		}
+	}
+	var network *FluidNetwork = NewFluidNetwork(nodes, headers, pipes, pumps, valves)
+	for id, jetPump := range model.JetPumps {
+		var throatK float64 = 0.1
+		if jetPump.ThroatK != nil {
+			throatK = *jetPump.ThroatK
+		}
+		network.JetPumps[id] = FluidJetPump{
+			JunctionBase: FluidJunctionBase{
+				SourceType:      jetPump.SourceType,
+				SourceID:        jetPump.SourceID,
+				DestinationType: jetPump.DestinationType,
+				DestinationID:   jetPump.DestinationID,
+			},
+			ThroatDiameter:   *jetPump.ThroatDiameter,
+			DiffuserDiameter: *jetPump.DiffuserDiameter,
+			Length:           *jetPump.Length,
+			ThroatKFactor:    throatK,
+			InletElevation:   jetPump.InletElevation,
+			OutletElevation:  jetPump.OutletElevation,
+
+			DriveJunction:   jetPump.DriveJunction,
+			SuctionJunction: jetPump.SuctionJunction,
+		}
+	}
+	for id, structure := range model.HeatStructures {
+		var material SolidMaterial = SolidMaterials[structure.Material]
+		if structure.Density != nil {
+			material.Density = *structure.Density
+		}
+		if structure.SpecificHeat != nil {
+			material.SpecificHeat = *structure.SpecificHeat
+		}
+		if structure.Conductivity != nil {
+			material.Conductivity = *structure.Conductivity
+		}
+		var cells int = structure.Cells
+		if cells == 0 {
+			cells = 5
+		}
+		var temperatures []float64 = make([]float64, cells)
+		for i := range temperatures {
+			temperatures[i] = *structure.Temperature
+		}
+		var heatStructure HeatStructure = HeatStructure{
+			Geometry:     HeatStructureGeometry(structure.Geometry),
+			Material:     material,
+			InnerRadius:  structure.InnerRadius,
+			Thickness:    *structure.Thickness,
+			Inner:        heatStructureSurface(structure.Inner, nodes),
+			Outer:        heatStructureSurface(structure.Outer, nodes),
+			Temperatures: temperatures,
+		}
+		if structure.Area != nil {
+			heatStructure.Area = *structure.Area
+		}
+		if structure.Length != nil {
+			heatStructure.Length = *structure.Length
+		}
+		network.HeatStructures[id] = heatStructure
+	}
+	return network
+}
+
+func heatStructureSurface(surface HeatStructureSurfaceModel, nodes map[string]FluidNode) HeatStructureSurface {
+	var result HeatStructureSurface = HeatStructureSurface{NodeID: surface.Node, JunctionID: surface.Junction}
+	if surface.Node == "" {
+		if surface.AmbientTemperature != nil {
+			result.AmbientTemperature = *surface.AmbientTemperature
+			result.AmbientHeatTransferCoefficient = *surface.HeatTransferCoefficient
+		}
+		return result
+	}
+	result.HydraulicDiameter = *surface.HydraulicDiameter
+	if surface.FlowArea != nil {
+		result.FlowArea = *surface.FlowArea
+	}
+	result.BottomElevation, result.TopElevation = nodes[surface.Node].BottomElevation, nodes[surface.Node].TopElevation
+	if surface.BottomElevation != nil {
+		result.BottomElevation, result.TopElevation = *surface.BottomElevation, *surface.TopElevation
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a map in order, so problems are always reported in the same order.
+func sortedKeys[V any](values map[string]V) []string {
+	var keys []string = make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
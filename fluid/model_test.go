@@ -0,0 +1,99 @@
+package fluid
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePlantModelProblems(t *testing.T) {
+	var tests = []struct {
+		name     string
+		old, new string // replaced once in closedLoopModel
+		want     string // part of the error, empty for a valid model
+	}{
+		{"valid", "", "", ""},
+		{"empty open node", `"volume": 60`, `"volume": 0`, ""},
+		{"empty closed node", `"pressure": 300000, "volume": 20`, `"pressure": 4247, "volume": 0`, ""},
+		{"closed node off the saturation line", `"pressure": 300000, "volume": 20`, `"pressure": 300000, "volume": 10`,
+			`node "Vessel": a closed node with a steam space starts saturated, but 30 °C is not the saturation temperature of 300000 Pa`},
+		{"unknown version", `"version": 2`, `"version": 1`, "unsupported model version 1"},
+		{"reactor and protection of a whole plant", `"version": 2,`, `"version": 2, "reactor": {"coreNode": "Vessel"}, "protection": {},`, ""},
+		{"unknown section", `"version": 2,`, `"version": 2, "reactors": {},`, `unknown field "reactors"`},
+		{"unknown field", `"minorK": 1,`, `"minorK": 1, "minorKFactor": 1,`, `unknown field "minorKFactor"`},
+		{"wrong type", `"diameter": 300, "length": 15`, `"diameter": "300", "length": 15`, "expected float64, got string"},
+		{"missing temperature", `"Tank": {"temperature": 30, `, `"Tank": {`, `node "Tank": temperature is missing`},
+		{"missing volume", `"volume": 60, `, ``, `node "Tank": volume is missing`},
+		{"pressure out of range", `"pressure": 300000`, `"pressure": 200000000`, `node "Vessel": pressure 2e+08 Pa is outside the IF97 range`},
+		{"negative volume", `"volume": 60`, `"volume": -1`, `node "Tank": volume must not be negative, got -1`},
+		{"volume above maxVolume", `"volume": 60`, `"volume": 300`, `node "Tank": volume 300 is larger than maxVolume 200`},
+		{"empty supercritical vessel", `"temperature": 30, "pressure": 300000, "volume": 20`, `"temperature": 400, "pressure": 30000000, "volume": 0`,
+			`node "Vessel": a closed node above the critical temperature has no vapour to fill it`},
+		{"elevations upside down", `"bottomElevation": 10, "topElevation": 15`, `"bottomElevation": 10, "topElevation": 5`, `node "Tank": topElevation 5 must be above bottomElevation 10`},
+		{"negative length", `"length": 25`, `"length": -25`, `pipe "Return": length must be positive, got -25`},
+		{"unknown friction model", `"minorK": 3,`, `"minorK": 3, "frictionModel": "Moody",`, `pipe "Return": unknown friction model "Moody"`},
+		{"missing rated head", `"ratedHead": 40,`, ``, `pump "Pump": ratedHead is missing`},
+		{"speed demand above the controller limit", `"speedDemand": 1500`, `"speedDemand": 1800`, `pump "Pump": speedDemand 1800 is above 110% of ratedSpeed`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var model string = closedLoopModel
+			if test.old != "" {
+				if !strings.Contains(model, test.old) {
+					t.Fatalf("%q is not in the model", test.old)
+				}
+				model = strings.Replace(model, test.old, test.new, 1)
+			}
+			var _, err = ParsePlantModel([]byte(model))
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.want != "" && err == nil:
+				t.Errorf("no error, want %q", test.want)
+			case test.want != "" && !strings.Contains(err.Error(), test.want):
+				t.Errorf("error %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestHeatStructureWithoutCellsIsRejected(t *testing.T) {
+	var model PlantModel = DefaultPlantModel()
+	var structure HeatStructureModel = model.HeatStructures["VesselWall"]
+	structure.Cells = -1
+	model.HeatStructures["VesselWall"] = structure
+	var want string = `heat structure "VesselWall": cells must be at least 1 to hold any heat, got -1`
+	if err := model.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error %v, want %q", err, want)
+	}
+}
+
+func TestDefaultPlantStartsAtItsConfiguredState(t *testing.T) {
+	var model PlantModel = DefaultPlantModel()
+	var network *FluidNetwork = model.Network()
+	if err := network.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range sortedKeys(model.Nodes) {
+		var configured NodeModel = model.Nodes[id]
+		var node FluidNode = network.Nodes[id]
+		if !closeTo(node.Pressure, *configured.Pressure, 1e-6) || math.Abs(node.Temperature-*configured.Temperature) > saturationTolerance {
+			t.Errorf("%s starts at %v Pa and %v °C, want the configured %v Pa and %v °C", id, node.Pressure, node.Temperature, *configured.Pressure, *configured.Temperature)
+		}
+	}
+}
+
+func TestEmptyNodesSimulate(t *testing.T) {
+	var model string = strings.Replace(closedLoopModel, `"volume": 60`, `"volume": 0`, 1)
+	var network *FluidNetwork = newTestNetwork(t, model)
+	for i := 0; i < 10; i += 1 {
+		network.SimulateFlow(time.Second)
+	}
+	for _, id := range sortedKeys(network.Nodes) {
+		var node FluidNode = network.Nodes[id]
+		if math.IsNaN(node.Mass) || math.IsNaN(node.Pressure) || math.IsNaN(node.Temperature) {
+			t.Errorf("node %s: mass %v kg, pressure %v Pa, temperature %v °C", id, node.Mass, node.Pressure, node.Temperature)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-//go:build darwin && arm64
+//go:build darwin && arm64 && cgo && !purego
 
 package fluid
 
@@ -28,3 +28,53 @@ func cgoPs(pressure float64, entropy float64, propertyID int) float64 {
 func cgoHs(enthalpy float64, entropy float64, propertyID int) float64 {
 	return float64(C.hs(C.double(enthalpy), C.double(entropy), C.int(propertyID)))
 }
+
+// cgoPv is the macOS-specific wrapper that calls the C.pv function.
+func cgoPv(pressure float64, specificVolume float64, propertyID int) float64 {
+	return float64(C.pv(C.double(pressure), C.double(specificVolume), C.int(propertyID)))
+}
+
+// cgoTh is the macOS-specific wrapper that calls the C.th function.
+func cgoTh(temperature float64, enthalpy float64, propertyID int) float64 {
+	return float64(C.th(C.double(temperature), C.double(enthalpy), C.int(propertyID)))
+}
+
+// cgoTs is the macOS-specific wrapper that calls the C.ts function.
+func cgoTs(temperature float64, entropy float64, propertyID int) float64 {
+	return float64(C.ts(C.double(temperature), C.double(entropy), C.int(propertyID)))
+}
+
+// cgoTv is the macOS-specific wrapper that calls the C.tv function.
+func cgoTv(temperature float64, specificVolume float64, propertyID int) float64 {
+	return float64(C.tv(C.double(temperature), C.double(specificVolume), C.int(propertyID)))
+}
+
+// cgoPx is the macOS-specific wrapper that calls the C.px function.
+func cgoPx(pressure float64, quality float64, propertyID int) float64 {
+	return float64(C.px(C.double(pressure), C.double(quality), C.int(propertyID)))
+}
+
+// cgoTx is the macOS-specific wrapper that calls the C.tx function.
+func cgoTx(temperature float64, quality float64, propertyID int) float64 {
+	return float64(C.tx(C.double(temperature), C.double(quality), C.int(propertyID)))
+}
+
+// cgoHx is the macOS-specific wrapper that calls the C.hx function.
+func cgoHx(enthalpy float64, quality float64, propertyID int) float64 {
+	return float64(C.hx(C.double(enthalpy), C.double(quality), C.int(propertyID)))
+}
+
+// cgoSx is the macOS-specific wrapper that calls the C.sx function.
+func cgoSx(entropy float64, quality float64, propertyID int) float64 {
+	return float64(C.sx(C.double(entropy), C.double(quality), C.int(propertyID)))
+}
+
+// cgoIshd is the macOS-specific wrapper that calls the C.ishd function.
+func cgoIshd(inletPressure float64, inletTemperature float64, outletPressure float64) float64 {
+	return float64(C.ishd(C.double(inletPressure), C.double(inletTemperature), C.double(outletPressure)))
+}
+
+// cgoIef is the macOS-specific wrapper that calls the C.ief function.
+func cgoIef(inletPressure float64, inletTemperature float64, outletPressure float64, outletTemperature float64) float64 {
+	return float64(C.ief(C.double(inletPressure), C.double(inletTemperature), C.double(outletPressure), C.double(outletTemperature)))
+}
@@ -1,58 +1,85 @@
 package fluid
 
+import "math"
+
+// The Calculate* functions look up single properties with the default provider of the build, see
+// DefaultPropertyProvider. A FluidNetwork uses the provider it was given instead.
+
 // Property codes from SEUIF97
 const (
-	PRESSURE          = 0
-	TEMPERATURE       = 1
-	SPECIFIC_VOLUME   = 3
-	ENTHALPY          = 4
-	ENTROPY           = 5
-	DYNAMIC_VISCOSITY = 24
+	PRESSURE                             = 0  // MPa
+	TEMPERATURE                          = 1  // °C
+	DENSITY                              = 2  // kg/m^3
+	SPECIFIC_VOLUME                      = 3  // m^3/kg
+	ENTHALPY                             = 4  // kJ/kg
+	ENTROPY                              = 5  // kJ/(kg·K)
+	EXERGY                               = 6  // kJ/kg
+	INTERNAL_ENERGY                      = 7  // kJ/kg
+	ISOBARIC_HEAT_CAPACITY               = 8  // kJ/(kg·K)
+	ISOCHORIC_HEAT_CAPACITY              = 9  // kJ/(kg·K)
+	SPEED_OF_SOUND                       = 10 // m/s
+	ISENTROPIC_EXPONENT                  = 11
+	HELMHOLTZ_FREE_ENERGY                = 12 // kJ/kg
+	GIBBS_FREE_ENERGY                    = 13 // kJ/kg
+	COMPRESSIBILITY_FACTOR               = 14
+	STEAM_QUALITY                        = 15
+	REGION                               = 16
+	ISOBARIC_EXPANSION_COEFFICIENT       = 17 // 1/K
+	ISOTHERMAL_COMPRESSIBILITY           = 18 // 1/MPa
+	DV_DT_AT_CONSTANT_P                  = 19 // m^3/(kg·K)
+	DV_DP_AT_CONSTANT_T                  = 20 // m^3/(kg·MPa)
+	DP_DT_AT_CONSTANT_V                  = 21 // MPa/K
+	ISOTHERMAL_JOULE_THOMSON_COEFFICIENT = 22 // kJ/(kg·MPa)
+	JOULE_THOMSON_COEFFICIENT            = 23 // K/MPa
+	DYNAMIC_VISCOSITY                    = 24 // kg/(m·s)
+	KINEMATIC_VISCOSITY                  = 25 // m^2/s
+	THERMAL_CONDUCTIVITY                 = 26 // W/(m·K)
+	THERMAL_DIFFUSIVITY                  = 27 // m^2/s
+	PRANDTL_NUMBER                       = 28
+	SURFACE_TENSION                      = 29 // mN/m
 )
 
 func CalculatePressureHs(EnthalpyKJKG float64, EntropyKJ float64) (Pressure float64) {
-	var pressure float64 = cgoHs(EnthalpyKJKG, EntropyKJ, PRESSURE) // MPa
+	var pressure float64 = DefaultPropertyProvider().Property(HS, EnthalpyKJKG, EntropyKJ, PRESSURE) // MPa
 	return pressure
 }
 
 func CalculateTemperatureHs(EnthalpyKJKG float64, EntropyKJ float64) (Temperature float64) {
-	var temperature float64 = cgoHs(EnthalpyKJKG, EntropyKJ, TEMPERATURE) // Celsius
+	var temperature float64 = DefaultPropertyProvider().Property(HS, EnthalpyKJKG, EntropyKJ, TEMPERATURE) // Celsius
 	return temperature
 }
 
 func CalculateTemperaturePh(PressureMPa float64, EnthalpyKJKG float64) float64 {
-	var temperature float64 = cgoPh(PressureMPa, EnthalpyKJKG, TEMPERATURE) // Celsius
+	var temperature float64 = DefaultPropertyProvider().Property(PH, PressureMPa, EnthalpyKJKG, TEMPERATURE) // Celsius
 	return temperature
 }
 
 func CalculateTemperaturePs(PressureMPa float64, EntropyKJ float64) float64 {
-	var temperature float64 = cgoPs(PressureMPa, EntropyKJ, TEMPERATURE) // Celsius
+	var temperature float64 = DefaultPropertyProvider().Property(PS, PressureMPa, EntropyKJ, TEMPERATURE) // Celsius
 	return temperature
 }
 
 func CalculateSpecificVolumePh(PressureMPa float64, EnthalpyKJKG float64) float64 {
-	var volume float64 = cgoPh(PressureMPa, EnthalpyKJKG, SPECIFIC_VOLUME) // m^3/kg
+	var volume float64 = DefaultPropertyProvider().Property(PH, PressureMPa, EnthalpyKJKG, SPECIFIC_VOLUME) // m^3/kg
 	return volume
 }
 
 func CalculateSpecificVolumePs(PressureMPa float64, EntropyKJ float64) float64 {
-	var volume float64 = cgoPs(PressureMPa, EntropyKJ, SPECIFIC_VOLUME) // m^3/kg
+	var volume float64 = DefaultPropertyProvider().Property(PS, PressureMPa, EntropyKJ, SPECIFIC_VOLUME) // m^3/kg
 	return volume
 }
 
 func CalculateSpecificVolumePt(PressureMPa float64, TemperatureC float64) float64 {
-	var volume float64 = cgoPt(PressureMPa, TemperatureC, SPECIFIC_VOLUME) // m^3/kg
+	var volume float64 = DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, SPECIFIC_VOLUME) // m^3/kg
 	return volume
 }
 
 func CalculateDensityPt(PressureMPa float64, TemperatureC float64) float64 {
-	var specificVolumeM3kg = cgoPt(PressureMPa, TemperatureC, SPECIFIC_VOLUME)
-	return 1.0 / float64(specificVolumeM3kg) // density = 1/specific_volume. kg/m^3
+	return DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, DENSITY) // kg/m^3
 }
 
 func CalculateDensityPh(PressureMPa float64, EnthalpyKJKG float64) float64 {
-	var specificVolumeM3kg = cgoPh(PressureMPa, EnthalpyKJKG, SPECIFIC_VOLUME)
-	return 1.0 / float64(specificVolumeM3kg)
+	return DefaultPropertyProvider().Property(PH, PressureMPa, EnthalpyKJKG, DENSITY)
 }
 
 func CalculateMass(DensityKGM3 float64, VolumeM3 float64) (Mass float64) { // custom function based on density and specific volume
@@ -60,21 +87,118 @@ func CalculateMass(DensityKGM3 float64, VolumeM3 float64) (Mass float64) { // cu
 }
 
 func CalculateEnthalpyPt(PressureMPa float64, TemperatureC float64) (Enthalpy float64) {
-	var EnthalpyKJKG = cgoPt(PressureMPa, TemperatureC, ENTHALPY)
+	var EnthalpyKJKG = DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, ENTHALPY)
 	return EnthalpyKJKG // kJ/kg
 }
 
 func CalculateEnthalpyPs(PressureMPa float64, EntropyKJ float64) (Enthalpy float64) {
-	var EnthalpyKJKG = cgoPs(PressureMPa, EntropyKJ, ENTHALPY)
+	var EnthalpyKJKG = DefaultPropertyProvider().Property(PS, PressureMPa, EntropyKJ, ENTHALPY)
 	return EnthalpyKJKG // kJ/kg
 }
 
 func CalculateEntropyPt(PressureMPa float64, TemperatureC float64) float64 {
-	var entropy float64 = cgoPt(PressureMPa, TemperatureC, ENTROPY)
+	var entropy float64 = DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, ENTROPY)
 	return entropy
 }
 
 func CalculateDynamicViscosityPt(PressureMPa float64, TemperatureC float64) float64 {
-	var viscosity float64 = cgoPt(PressureMPa, TemperatureC, DYNAMIC_VISCOSITY) // kg/(m·s)
+	var viscosity float64 = DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, DYNAMIC_VISCOSITY) // kg/(m·s)
 	return viscosity
 }
+
+func CalculateSteamQualityPh(PressureMPa float64, EnthalpyKJKG float64) float64 {
+	var quality float64 = DefaultPropertyProvider().Property(PH, PressureMPa, EnthalpyKJKG, STEAM_QUALITY) // 0 = saturated liquid, 1 = saturated vapor
+	return quality
+}
+
+func CalculateSteamQualityHs(EnthalpyKJKG float64, EntropyKJ float64) float64 {
+	var quality float64 = DefaultPropertyProvider().Property(HS, EnthalpyKJKG, EntropyKJ, STEAM_QUALITY)
+	return quality
+}
+
+func CalculateSaturationTemperature(PressureMPa float64) float64 {
+	var temperature float64 = DefaultPropertyProvider().Property(PX, PressureMPa, 0, TEMPERATURE) // Celsius
+	return temperature
+}
+
+func CalculateSaturationPressure(TemperatureC float64) float64 {
+	var pressure float64 = DefaultPropertyProvider().Property(TX, TemperatureC, 0, PRESSURE) // MPa
+	return pressure
+}
+
+func CalculateSaturatedLiquidEnthalpy(PressureMPa float64) float64 {
+	var enthalpy float64 = DefaultPropertyProvider().Property(PX, PressureMPa, 0, ENTHALPY) // kJ/kg
+	return enthalpy
+}
+
+func CalculateSaturatedVaporEnthalpy(PressureMPa float64) float64 {
+	var enthalpy float64 = DefaultPropertyProvider().Property(PX, PressureMPa, 1, ENTHALPY) // kJ/kg
+	return enthalpy
+}
+
+func CalculateSaturatedLiquidDensity(PressureMPa float64) float64 {
+	var density float64 = DefaultPropertyProvider().Property(PX, PressureMPa, 0, DENSITY) // kg/m^3
+	return density
+}
+
+func CalculateSaturatedVaporDensity(PressureMPa float64) float64 {
+	var density float64 = DefaultPropertyProvider().Property(PX, PressureMPa, 1, DENSITY) // kg/m^3
+	return density
+}
+
+func CalculateIsobaricHeatCapacityPt(PressureMPa float64, TemperatureC float64) float64 {
+	var cp float64 = DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, ISOBARIC_HEAT_CAPACITY) // kJ/(kg·K)
+	return cp
+}
+
+func CalculateThermalConductivityPt(PressureMPa float64, TemperatureC float64) float64 {
+	var conductivity float64 = DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, THERMAL_CONDUCTIVITY) // W/(m·K)
+	return conductivity
+}
+
+func CalculateSpeedOfSoundPt(PressureMPa float64, TemperatureC float64) float64 {
+	var speed float64 = DefaultPropertyProvider().Property(PT, PressureMPa, TemperatureC, SPEED_OF_SOUND) // m/s
+	return speed
+}
+
+func CalculateSurfaceTension(TemperatureC float64) float64 {
+	var tension float64 = DefaultPropertyProvider().Property(TX, TemperatureC, 0, SURFACE_TENSION) // mN/m
+	return tension
+}
+
+const isentropicDropResolution float64 = 0.001 // kJ/kg
+
+// expansionProvider is a PropertyProvider that evaluates expansions itself, as SEUIF97 does with ishd and ief.
+type expansionProvider interface {
+	IsentropicEnthalpyDrop(inletPressureMPa float64, inletTemperatureC float64, outletPressureMPa float64) float64
+	IsentropicEfficiency(inletPressureMPa float64, inletTemperatureC float64, outletPressureMPa float64, outletTemperatureC float64) float64
+}
+
+// CalculateIsentropicEnthalpyDrop is the equivalent of SEUIF97 ishd: the enthalpy drop of an ideal expansion from the inlet state to the outlet pressure.
+// A provider with its own ishd is asked directly, any other through the states at both ends.
+func CalculateIsentropicEnthalpyDrop(InletPressureMPa float64, InletTemperatureC float64, OutletPressureMPa float64) float64 {
+	var properties PropertyProvider = DefaultPropertyProvider()
+	if provider, ok := properties.(expansionProvider); ok {
+		return provider.IsentropicEnthalpyDrop(InletPressureMPa, InletTemperatureC, OutletPressureMPa) // kJ/kg
+	}
+	var inlet WaterProperties = properties.Pt(InletPressureMPa, InletTemperatureC)
+	var idealOutlet WaterProperties = properties.Ps(OutletPressureMPa, inlet.Entropy)
+	return inlet.Enthalpy - idealOutlet.Enthalpy // kJ/kg
+}
+
+// CalculateIsentropicEfficiency is the equivalent of SEUIF97 ief: the actual enthalpy drop of an expansion as a percentage of the isentropic one.
+// Without an isentropic drop, when the outlet pressure is the inlet pressure, the efficiency is undefined and NaN is returned;
+// a drop smaller than the round-trip error of the property backends counts as none.
+func CalculateIsentropicEfficiency(InletPressureMPa float64, InletTemperatureC float64, OutletPressureMPa float64, OutletTemperatureC float64) float64 {
+	var isentropicDrop float64 = CalculateIsentropicEnthalpyDrop(InletPressureMPa, InletTemperatureC, OutletPressureMPa)
+	if math.Abs(isentropicDrop) < isentropicDropResolution || math.IsNaN(isentropicDrop) {
+		return math.NaN()
+	}
+	var properties PropertyProvider = DefaultPropertyProvider()
+	if provider, ok := properties.(expansionProvider); ok {
+		return provider.IsentropicEfficiency(InletPressureMPa, InletTemperatureC, OutletPressureMPa, OutletTemperatureC) // %
+	}
+	var inlet WaterProperties = properties.Pt(InletPressureMPa, InletTemperatureC)
+	var outlet WaterProperties = properties.Pt(OutletPressureMPa, OutletTemperatureC)
+	return 100 * (inlet.Enthalpy - outlet.Enthalpy) / isentropicDrop // %
+}
@@ -0,0 +1,40 @@
+package fluid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIsentropicExpansion(t *testing.T) {
+	// 10 MPa and 600 °C expanded to 1 MPa: the ideal outlet is superheated at about 245 °C, 694 kJ/kg lower
+	var drop float64 = CalculateIsentropicEnthalpyDrop(10, 600, 1)
+	if math.Abs(drop-694) > 2 {
+		t.Errorf("isentropic enthalpy drop = %v kJ/kg, want about 694", drop)
+	}
+	var idealOutlet float64 = CalculateTemperaturePs(1, CalculateEntropyPt(10, 600))
+
+	var tests = []struct {
+		name                              string
+		outletPressure, outletTemperature float64
+		want                              float64 // %, NaN when undefined
+		tolerance                         float64
+	}{
+		{"ideal expansion", 1, idealOutlet, 100, 1e-6},
+		{"real expansion", 1, idealOutlet + 40, 100 * (CalculateEnthalpyPt(10, 600) - CalculateEnthalpyPt(1, idealOutlet+40)) / drop, 1e-6},
+		{"no pressure drop", 10, 550, math.NaN(), 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var efficiency float64 = CalculateIsentropicEfficiency(10, 600, test.outletPressure, test.outletTemperature)
+			if math.IsNaN(test.want) {
+				if !math.IsNaN(efficiency) {
+					t.Errorf("isentropic efficiency = %v %%, want NaN", efficiency)
+				}
+				return
+			}
+			if math.Abs(efficiency-test.want) > test.tolerance || efficiency >= 100+test.tolerance {
+				t.Errorf("isentropic efficiency = %v %%, want %v", efficiency, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package fluid
+
+// NativeIF97Provider is the PropertyProvider backed by the pure-Go IAPWS-IF97 implementation. It is available on every platform.
+type NativeIF97Provider struct{}
+
+func (NativeIF97Provider) Pt(pressureMPa float64, temperatureC float64) WaterProperties {
+	return if97StatePT(pressureMPa, temperatureC+273.15).waterProperties()
+}
+
+func (NativeIF97Provider) Ph(pressureMPa float64, enthalpyKJKG float64) WaterProperties {
+	return if97StatePH(pressureMPa, enthalpyKJKG).waterProperties()
+}
+
+func (NativeIF97Provider) Ps(pressureMPa float64, entropyKJKGK float64) WaterProperties {
+	return if97StatePS(pressureMPa, entropyKJKGK).waterProperties()
+}
+
+func (NativeIF97Provider) Pv(pressureMPa float64, specificVolumeM3KG float64) WaterProperties {
+	return if97StatePV(pressureMPa, specificVolumeM3KG).waterProperties()
+}
+
+func (NativeIF97Provider) Th(temperatureC float64, enthalpyKJKG float64) WaterProperties {
+	return if97StateTH(temperatureC+273.15, enthalpyKJKG).waterProperties()
+}
+
+func (NativeIF97Provider) Ts(temperatureC float64, entropyKJKGK float64) WaterProperties {
+	return if97StateTS(temperatureC+273.15, entropyKJKGK).waterProperties()
+}
+
+func (NativeIF97Provider) Tv(temperatureC float64, specificVolumeM3KG float64) WaterProperties {
+	return if97StateTV(temperatureC+273.15, specificVolumeM3KG).waterProperties()
+}
+
+func (NativeIF97Provider) Hs(enthalpyKJKG float64, entropyKJKGK float64) WaterProperties {
+	return if97StateHS(enthalpyKJKG, entropyKJKGK).waterProperties()
+}
+
+func (NativeIF97Provider) Px(pressureMPa float64, quality float64) WaterProperties {
+	return if97StatePX(pressureMPa, quality).waterProperties()
+}
+
+func (NativeIF97Provider) Tx(temperatureC float64, quality float64) WaterProperties {
+	return if97StateTX(temperatureC+273.15, quality).waterProperties()
+}
+
+func (NativeIF97Provider) Hx(enthalpyKJKG float64, quality float64) WaterProperties {
+	return if97StateHX(enthalpyKJKG, quality).waterProperties()
+}
+
+func (NativeIF97Provider) Sx(entropyKJKGK float64, quality float64) WaterProperties {
+	return if97StateSX(entropyKJKGK, quality).waterProperties()
+}
+
+// Property computes the whole state, the native implementation gets every property of it at little extra cost.
+func (provider NativeIF97Provider) Property(pair InputPair, first float64, second float64, propertyID int) float64 {
+	return StateOf(provider, pair, first, second).Property(propertyID)
+}
+
+// Exergy is measured against liquid water at 25 °C and atmospheric pressure.
+var exergyDeadState if97State = region1State(0.101325, 298.15)
+
+// waterProperties converts a native IF97 state into the provider representation.
+func (state if97State) waterProperties() WaterProperties {
+	var temperatureK float64 = state.temperature
+	var volume float64 = state.specificVolume
+	var viscosity float64 = state.dynamicViscosity()
+	var conductivity float64 = state.thermalConductivity()
+	var isothermalThrottling float64 = (volume - temperatureK*state.expansion*volume) * 1000 // kJ/(kg·MPa)
+	return WaterProperties{
+		Pressure:                          state.pressure,
+		Temperature:                       temperatureK - 273.15,
+		Density:                           1 / volume,
+		SpecificVolume:                    volume,
+		Enthalpy:                          state.enthalpy,
+		Entropy:                           state.entropy,
+		Exergy:                            state.enthalpy - exergyDeadState.enthalpy - exergyDeadState.temperature*(state.entropy-exergyDeadState.entropy),
+		InternalEnergy:                    state.internalEnergy,
+		IsobaricHeatCapacity:              state.cp,
+		IsochoricHeatCapacity:             state.cv,
+		SpeedOfSound:                      state.speedOfSound,
+		IsentropicExponent:                state.speedOfSound * state.speedOfSound / (state.pressure * 1000000 * volume),
+		HelmholtzFreeEnergy:               state.internalEnergy - temperatureK*state.entropy,
+		GibbsFreeEnergy:                   state.enthalpy - temperatureK*state.entropy,
+		CompressibilityFactor:             state.pressure * 1000 * volume / (if97R * temperatureK),
+		Quality:                           state.quality,
+		Region:                            float64(state.region),
+		IsobaricExpansionCoefficient:      state.expansion,
+		IsothermalCompressibility:         state.compressibility,
+		VolumeTemperatureDerivative:       state.expansion * volume,
+		VolumePressureDerivative:          -state.compressibility * volume,
+		PressureTemperatureDerivative:     state.expansion / state.compressibility,
+		IsothermalJouleThomsonCoefficient: isothermalThrottling,
+		JouleThomsonCoefficient:           -isothermalThrottling / state.cp,
+		DynamicViscosity:                  viscosity,
+		KinematicViscosity:                viscosity * volume,
+		ThermalConductivity:               conductivity,
+		ThermalDiffusivity:                conductivity * volume / (state.cp * 1000),
+		PrandtlNumber:                     viscosity * state.cp * 1000 / conductivity,
+		SurfaceTension:                    surfaceTension(temperatureK),
+	}
+}
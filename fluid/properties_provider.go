@@ -0,0 +1,177 @@
+package fluid
+
+import "math"
+
+// --- STRUCT DECLARATIONS ---
+
+// WaterProperties is the set of water/steam properties a PropertyProvider resolves for one state point.
+// Every field corresponds to one SEUIF97 property code and uses the SEUIF97 unit for it.
+type WaterProperties struct {
+	Pressure                          float64 // MPa
+	Temperature                       float64 // °C
+	Density                           float64 // kg/m^3
+	SpecificVolume                    float64 // m^3/kg
+	Enthalpy                          float64 // kJ/kg
+	Entropy                           float64 // kJ/(kg·K)
+	Exergy                            float64 // kJ/kg
+	InternalEnergy                    float64 // kJ/kg
+	IsobaricHeatCapacity              float64 // kJ/(kg·K), cp
+	IsochoricHeatCapacity             float64 // kJ/(kg·K), cv
+	SpeedOfSound                      float64 // m/s
+	IsentropicExponent                float64 // dimensionless
+	HelmholtzFreeEnergy               float64 // kJ/kg
+	GibbsFreeEnergy                   float64 // kJ/kg
+	CompressibilityFactor             float64 // dimensionless
+	Quality                           float64 // vapour mass fraction, 0 to 1
+	Region                            float64 // IF97 region, 1 to 5
+	IsobaricExpansionCoefficient      float64 // 1/K
+	IsothermalCompressibility         float64 // 1/MPa
+	VolumeTemperatureDerivative       float64 // (∂v/∂T)p in m^3/(kg·K)
+	VolumePressureDerivative          float64 // (∂v/∂p)T in m^3/(kg·MPa)
+	PressureTemperatureDerivative     float64 // (∂p/∂T)v in MPa/K
+	IsothermalJouleThomsonCoefficient float64 // (∂h/∂p)T in kJ/(kg·MPa)
+	JouleThomsonCoefficient           float64 // (∂T/∂p)h in K/MPa
+	DynamicViscosity                  float64 // kg/(m·s)
+	KinematicViscosity                float64 // m^2/s
+	ThermalConductivity               float64 // W/(m·K)
+	ThermalDiffusivity                float64 // m^2/s
+	PrandtlNumber                     float64 // dimensionless
+	SurfaceTension                    float64 // mN/m
+}
+
+// InputPair names the two properties that fix a state, after the SEUIF97 function that takes them.
+type InputPair string
+
+const (
+	PT InputPair = "pt" // pressure and temperature
+	PH InputPair = "ph" // pressure and enthalpy
+	PS InputPair = "ps" // pressure and entropy
+	PV InputPair = "pv" // pressure and specific volume
+	TH InputPair = "th" // temperature and enthalpy
+	TS InputPair = "ts" // temperature and entropy
+	TV InputPair = "tv" // temperature and specific volume
+	HS InputPair = "hs" // enthalpy and entropy
+	PX InputPair = "px" // pressure and quality
+	TX InputPair = "tx" // temperature and quality
+	HX InputPair = "hx" // enthalpy and quality
+	SX InputPair = "sx" // entropy and quality
+)
+
+// PropertyProvider is an equation of state for water and steam. Each method named after an input pair supported by
+// SEUIF97 returns every property of the resulting state, so the flow solver never depends on a specific backend.
+// Property returns a single one, which is all a backend that works property by property has to compute; callers that
+// read one field should use it. Inputs use SEUIF97 units: MPa, °C, kJ/kg, kJ/(kg·K), m^3/kg and a steam quality
+// between 0 and 1.
+type PropertyProvider interface {
+	Pt(pressureMPa float64, temperatureC float64) WaterProperties
+	Ph(pressureMPa float64, enthalpyKJKG float64) WaterProperties
+	Ps(pressureMPa float64, entropyKJKGK float64) WaterProperties
+	Pv(pressureMPa float64, specificVolumeM3KG float64) WaterProperties
+	Th(temperatureC float64, enthalpyKJKG float64) WaterProperties
+	Ts(temperatureC float64, entropyKJKGK float64) WaterProperties
+	Tv(temperatureC float64, specificVolumeM3KG float64) WaterProperties
+	Hs(enthalpyKJKG float64, entropyKJKGK float64) WaterProperties
+	Px(pressureMPa float64, quality float64) WaterProperties
+	Tx(temperatureC float64, quality float64) WaterProperties
+	Hx(enthalpyKJKG float64, quality float64) WaterProperties
+	Sx(entropyKJKGK float64, quality float64) WaterProperties
+	Property(pair InputPair, first float64, second float64, propertyID int) float64 // by SEUIF97 property code
+}
+
+// StateOf returns every property of the state the input pair fixes, through the method of the provider for that pair.
+func StateOf(provider PropertyProvider, pair InputPair, first float64, second float64) WaterProperties {
+	switch pair {
+	case PT:
+		return provider.Pt(first, second)
+	case PH:
+		return provider.Ph(first, second)
+	case PS:
+		return provider.Ps(first, second)
+	case PV:
+		return provider.Pv(first, second)
+	case TH:
+		return provider.Th(first, second)
+	case TS:
+		return provider.Ts(first, second)
+	case TV:
+		return provider.Tv(first, second)
+	case HS:
+		return provider.Hs(first, second)
+	case PX:
+		return provider.Px(first, second)
+	case TX:
+		return provider.Tx(first, second)
+	case HX:
+		return provider.Hx(first, second)
+	case SX:
+		return provider.Sx(first, second)
+	}
+	return WaterProperties{}
+}
+
+// Property returns the field of the properties with the given SEUIF97 property code, or NaN for an unknown code.
+func (properties WaterProperties) Property(propertyID int) float64 {
+	switch propertyID {
+	case PRESSURE:
+		return properties.Pressure
+	case TEMPERATURE:
+		return properties.Temperature
+	case DENSITY:
+		return properties.Density
+	case SPECIFIC_VOLUME:
+		return properties.SpecificVolume
+	case ENTHALPY:
+		return properties.Enthalpy
+	case ENTROPY:
+		return properties.Entropy
+	case EXERGY:
+		return properties.Exergy
+	case INTERNAL_ENERGY:
+		return properties.InternalEnergy
+	case ISOBARIC_HEAT_CAPACITY:
+		return properties.IsobaricHeatCapacity
+	case ISOCHORIC_HEAT_CAPACITY:
+		return properties.IsochoricHeatCapacity
+	case SPEED_OF_SOUND:
+		return properties.SpeedOfSound
+	case ISENTROPIC_EXPONENT:
+		return properties.IsentropicExponent
+	case HELMHOLTZ_FREE_ENERGY:
+		return properties.HelmholtzFreeEnergy
+	case GIBBS_FREE_ENERGY:
+		return properties.GibbsFreeEnergy
+	case COMPRESSIBILITY_FACTOR:
+		return properties.CompressibilityFactor
+	case STEAM_QUALITY:
+		return properties.Quality
+	case REGION:
+		return properties.Region
+	case ISOBARIC_EXPANSION_COEFFICIENT:
+		return properties.IsobaricExpansionCoefficient
+	case ISOTHERMAL_COMPRESSIBILITY:
+		return properties.IsothermalCompressibility
+	case DV_DT_AT_CONSTANT_P:
+		return properties.VolumeTemperatureDerivative
+	case DV_DP_AT_CONSTANT_T:
+		return properties.VolumePressureDerivative
+	case DP_DT_AT_CONSTANT_V:
+		return properties.PressureTemperatureDerivative
+	case ISOTHERMAL_JOULE_THOMSON_COEFFICIENT:
+		return properties.IsothermalJouleThomsonCoefficient
+	case JOULE_THOMSON_COEFFICIENT:
+		return properties.JouleThomsonCoefficient
+	case DYNAMIC_VISCOSITY:
+		return properties.DynamicViscosity
+	case KINEMATIC_VISCOSITY:
+		return properties.KinematicViscosity
+	case THERMAL_CONDUCTIVITY:
+		return properties.ThermalConductivity
+	case THERMAL_DIFFUSIVITY:
+		return properties.ThermalDiffusivity
+	case PRANDTL_NUMBER:
+		return properties.PrandtlNumber
+	case SURFACE_TENSION:
+		return properties.SurfaceTension
+	}
+	return math.NaN()
+}
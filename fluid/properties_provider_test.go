@@ -0,0 +1,69 @@
+package fluid
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPropertyMatchesState(t *testing.T) {
+	var tests = []struct {
+		pair          InputPair
+		first, second float64
+	}{
+		{PT, 7, 280},
+		{PH, 7, 1200},
+		{PS, 7, 3},
+		{PV, 7, 0.0013},
+		{TH, 280, 1200},
+		{TS, 280, 3},
+		{TV, 280, 0.0013},
+		{HS, 2800, 6},
+		{PX, 7, 0.3},
+		{TX, 280, 0.3},
+		{HX, 2000, 0.5},
+		{SX, 5, 0.6},
+	}
+	for _, test := range tests {
+		t.Run(string(test.pair), func(t *testing.T) {
+			var state WaterProperties = StateOf(DefaultPropertyProvider(), test.pair, test.first, test.second)
+			for _, propertyID := range []int{PRESSURE, TEMPERATURE, DENSITY, ENTHALPY, ENTROPY, INTERNAL_ENERGY, STEAM_QUALITY} {
+				var got, want float64 = DefaultPropertyProvider().Property(test.pair, test.first, test.second, propertyID), state.Property(propertyID)
+				if math.IsNaN(want) || math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+					t.Errorf("property %d = %v, want %v from the full state", propertyID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownPropertyCode(t *testing.T) {
+	if got := DefaultPropertyProvider().Property(PT, 7, 280, 99); !math.IsNaN(got) {
+		t.Errorf("property 99 = %v, want NaN", got)
+	}
+}
+
+// countingProvider is the native IF97 provider that counts the single property lookups made through it.
+type countingProvider struct {
+	NativeIF97Provider
+	lookups *int
+}
+
+func (provider countingProvider) Property(pair InputPair, first float64, second float64, propertyID int) float64 {
+	*provider.lookups += 1
+	return provider.NativeIF97Provider.Property(pair, first, second, propertyID)
+}
+
+func TestNetworksUseTheirOwnProvider(t *testing.T) {
+	var lookups int
+	var counted, other *FluidNetwork = newTestNetwork(t, closedLoopModel), newTestNetwork(t, closedLoopModel)
+	counted.Properties = countingProvider{lookups: &lookups}
+	other.SimulateFlow(time.Second)
+	if lookups != 0 {
+		t.Fatalf("%d lookups through the provider of another network", lookups)
+	}
+	counted.SimulateFlow(time.Second)
+	if lookups == 0 {
+		t.Error("no lookups through the provider of the network")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build purego || !cgo || !(windows || (darwin && arm64))
+
+package fluid
+
+// DefaultPropertyProvider returns the provider of this build. Pure-Go builds are used on platforms without a prebuilt
+// libseuif97.a, when cgo is disabled, or when the purego build tag is given. They default to the native IF97
+// implementation.
+func DefaultPropertyProvider() PropertyProvider {
+	return NativeIF97Provider{}
+}
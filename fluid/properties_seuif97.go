@@ -0,0 +1,140 @@
+//go:build cgo && !purego && (windows || (darwin && arm64))
+
+package fluid
+
+import "math"
+
+// SEUIF97Provider is the PropertyProvider backed by the bundled SEUIF97 C library.
+type SEUIF97Provider struct{}
+
+func (SEUIF97Provider) Pt(pressureMPa float64, temperatureC float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoPt(pressureMPa, temperatureC, propertyID) })
+}
+
+func (SEUIF97Provider) Ph(pressureMPa float64, enthalpyKJKG float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoPh(pressureMPa, enthalpyKJKG, propertyID) })
+}
+
+func (SEUIF97Provider) Ps(pressureMPa float64, entropyKJKGK float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoPs(pressureMPa, entropyKJKGK, propertyID) })
+}
+
+func (SEUIF97Provider) Pv(pressureMPa float64, specificVolumeM3KG float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoPv(pressureMPa, specificVolumeM3KG, propertyID) })
+}
+
+func (SEUIF97Provider) Th(temperatureC float64, enthalpyKJKG float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoTh(temperatureC, enthalpyKJKG, propertyID) })
+}
+
+func (SEUIF97Provider) Ts(temperatureC float64, entropyKJKGK float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoTs(temperatureC, entropyKJKGK, propertyID) })
+}
+
+func (SEUIF97Provider) Tv(temperatureC float64, specificVolumeM3KG float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoTv(temperatureC, specificVolumeM3KG, propertyID) })
+}
+
+func (SEUIF97Provider) Hs(enthalpyKJKG float64, entropyKJKGK float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoHs(enthalpyKJKG, entropyKJKGK, propertyID) })
+}
+
+func (SEUIF97Provider) Px(pressureMPa float64, quality float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoPx(pressureMPa, quality, propertyID) })
+}
+
+func (SEUIF97Provider) Tx(temperatureC float64, quality float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoTx(temperatureC, quality, propertyID) })
+}
+
+func (SEUIF97Provider) Hx(enthalpyKJKG float64, quality float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoHx(enthalpyKJKG, quality, propertyID) })
+}
+
+func (SEUIF97Provider) Sx(entropyKJKGK float64, quality float64) WaterProperties {
+	return seuif97Properties(func(propertyID int) float64 { return cgoSx(entropyKJKGK, quality, propertyID) })
+}
+
+// Property makes a single SEUIF97 call for the one property code asked for.
+func (SEUIF97Provider) Property(pair InputPair, first float64, second float64, propertyID int) float64 {
+	switch pair {
+	case PT:
+		return cgoPt(first, second, propertyID)
+	case PH:
+		return cgoPh(first, second, propertyID)
+	case PS:
+		return cgoPs(first, second, propertyID)
+	case PV:
+		return cgoPv(first, second, propertyID)
+	case TH:
+		return cgoTh(first, second, propertyID)
+	case TS:
+		return cgoTs(first, second, propertyID)
+	case TV:
+		return cgoTv(first, second, propertyID)
+	case HS:
+		return cgoHs(first, second, propertyID)
+	case PX:
+		return cgoPx(first, second, propertyID)
+	case TX:
+		return cgoTx(first, second, propertyID)
+	case HX:
+		return cgoHx(first, second, propertyID)
+	case SX:
+		return cgoSx(first, second, propertyID)
+	}
+	return math.NaN()
+}
+
+// IsentropicEnthalpyDrop calls SEUIF97 ishd.
+func (SEUIF97Provider) IsentropicEnthalpyDrop(inletPressureMPa float64, inletTemperatureC float64, outletPressureMPa float64) float64 {
+	return cgoIshd(inletPressureMPa, inletTemperatureC, outletPressureMPa)
+}
+
+// IsentropicEfficiency calls SEUIF97 ief.
+func (SEUIF97Provider) IsentropicEfficiency(inletPressureMPa float64, inletTemperatureC float64, outletPressureMPa float64, outletTemperatureC float64) float64 {
+	return cgoIef(inletPressureMPa, inletTemperatureC, outletPressureMPa, outletTemperatureC)
+}
+
+// seuif97Properties fills a WaterProperties by querying SEUIF97 once per property code, 30 calls into the library.
+// Callers that need a single property should ask for it with Property instead.
+func seuif97Properties(lookup func(propertyID int) float64) WaterProperties {
+	return WaterProperties{
+		Pressure:                          lookup(PRESSURE),
+		Temperature:                       lookup(TEMPERATURE),
+		Density:                           lookup(DENSITY),
+		SpecificVolume:                    lookup(SPECIFIC_VOLUME),
+		Enthalpy:                          lookup(ENTHALPY),
+		Entropy:                           lookup(ENTROPY),
+		Exergy:                            lookup(EXERGY),
+		InternalEnergy:                    lookup(INTERNAL_ENERGY),
+		IsobaricHeatCapacity:              lookup(ISOBARIC_HEAT_CAPACITY),
+		IsochoricHeatCapacity:             lookup(ISOCHORIC_HEAT_CAPACITY),
+		SpeedOfSound:                      lookup(SPEED_OF_SOUND),
+		IsentropicExponent:                lookup(ISENTROPIC_EXPONENT),
+		HelmholtzFreeEnergy:               lookup(HELMHOLTZ_FREE_ENERGY),
+		GibbsFreeEnergy:                   lookup(GIBBS_FREE_ENERGY),
+		CompressibilityFactor:             lookup(COMPRESSIBILITY_FACTOR),
+		Quality:                           lookup(STEAM_QUALITY),
+		Region:                            lookup(REGION),
+		IsobaricExpansionCoefficient:      lookup(ISOBARIC_EXPANSION_COEFFICIENT),
+		IsothermalCompressibility:         lookup(ISOTHERMAL_COMPRESSIBILITY),
+		VolumeTemperatureDerivative:       lookup(DV_DT_AT_CONSTANT_P),
+		VolumePressureDerivative:          lookup(DV_DP_AT_CONSTANT_T),
+		PressureTemperatureDerivative:     lookup(DP_DT_AT_CONSTANT_V),
+		IsothermalJouleThomsonCoefficient: lookup(ISOTHERMAL_JOULE_THOMSON_COEFFICIENT),
+		JouleThomsonCoefficient:           lookup(JOULE_THOMSON_COEFFICIENT),
+		DynamicViscosity:                  lookup(DYNAMIC_VISCOSITY),
+		KinematicViscosity:                lookup(KINEMATIC_VISCOSITY),
+		ThermalConductivity:               lookup(THERMAL_CONDUCTIVITY),
+		ThermalDiffusivity:                lookup(THERMAL_DIFFUSIVITY),
+		PrandtlNumber:                     lookup(PRANDTL_NUMBER),
+		SurfaceTension:                    lookup(SURFACE_TENSION),
+	}
+}
+
+// DefaultPropertyProvider returns the provider of this build. Builds with cgo on Windows and Apple Silicon macOS
+// default to the bundled SEUIF97 library.
+func DefaultPropertyProvider() PropertyProvider {
+	return SEUIF97Provider{}
+}
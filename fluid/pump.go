@@ -0,0 +1,201 @@
+package fluid
+
+import (
+	"fmt"
+	"math"
+)
+
+// --- STRUCT DECLARATIONS ---
+
+// FluidPump is a centrifugal pump that can be chained into a flow path like a pipe. Its head and hydraulic torque
+// follow homologous four-quadrant curves, so it also behaves when it runs backwards, is driven by reverse flow or
+// coasts down after a trip. The rotor speed follows from its inertia and the torques of the motor, the fluid and friction.
+type FluidPump struct {
+	JunctionBase    FluidJunctionBase // the base junction info
+	Diameter        float64           // diameter of the suction and discharge nozzles in milimeters
+	Length          float64           // length of the flow passage through the pump in meters
+	InletElevation  float64           // elevation in meters where the pump takes suction
+	OutletElevation float64           // elevation in meters where the pump discharges
+
+	RatedFlow    float64   // m^3/s
+	RatedHead    float64   // m
+	RatedSpeed   float64   // rpm
+	RatedTorque  float64   // N·m, hydraulic torque at the rated point
+	RatedDensity float64   // kg/m^3, density the rated torque refers to
+	HeadCurve    []float64 // homologous head h/(α²+v²), at equally spaced θ = atan2(α, v) from -π to π
+	TorqueCurve  []float64 // homologous torque β/(α²+v²), same spacing as HeadCurve
+
+	Inertia        float64 // kg·m² of the impeller, shaft, flywheel and motor rotor
+	FrictionTorque float64 // N·m of bearing and seal friction at rated speed, proportional to the speed
+	MaxMotorTorque float64 // N·m the motor can deliver
+
+	SpeedDemand     float64 // rpm the speed controller drives the pump to
+	Tripped         bool    // the motor breaker is open and the pump coasts
+	Speed           float64 // rpm, negative when the pump is spun backwards
+	MassFlowRate    float64 // kg/s, positive from source to destination
+	Head            float64 // m, developed during the last step
+	HydraulicTorque float64 // N·m the fluid exerted on the impeller during the last step
+}
+
+// --- CONSTANT DECLARATIONS ---
+const pumpMotorSlip float64 = 0.02      // fraction of rated speed below the demand at which the motor delivers full torque
+const pumpRatedEfficiency float64 = 0.8 // used to derive the rated torque when none is given
+const pumpCurvePoints int = 37          // points of the built-in homologous curves, every 10 degrees
+const pumpMaxSpeedRatio float64 = 1.1   // highest speed demand the speed controller accepts, as a fraction of rated
+
+// DefaultPumpHeadCurve returns the homologous head curve of a typical radial pump, tabulated from
+//
+//	h = a·α² - 0.05·|α|·v - 0.2·v·|v|, a = 1.25 for forward and 0.45 for reverse rotation
+//
+// with α the speed and v the volumetric flow as fractions of rated. It passes through 1 at the rated point.
+func DefaultPumpHeadCurve() []float64 {
+	var headCurve, _ []float64 = defaultPumpCurves()
+	return headCurve
+}
+
+// DefaultPumpTorqueCurve returns the homologous torque curve of the same pump, tabulated from
+//
+//	β = 0.45·α·|α| + 0.75·α·|v| - 0.2·v·|v|
+//
+// It passes through 1 at the rated point.
+func DefaultPumpTorqueCurve() []float64 {
+	var _, torqueCurve []float64 = defaultPumpCurves()
+	return torqueCurve
+}
+
+func defaultPumpCurves() (headCurve []float64, torqueCurve []float64) {
+	for i := 0; i < pumpCurvePoints; i += 1 {
+		var theta float64 = -math.Pi + 2*math.Pi*float64(i)/float64(pumpCurvePoints-1)
+		var speed, flow float64 = math.Sin(theta), math.Cos(theta) // any point on the ray, the curves only depend on θ
+		var a float64 = 1.25
+		if speed < 0 {
+			a = 0.45
+		}
+		var head float64 = a*speed*speed - 0.05*math.Abs(speed)*flow - 0.2*flow*math.Abs(flow)
+		var torque float64 = 0.45*speed*math.Abs(speed) + 0.75*speed*math.Abs(flow) - 0.2*flow*math.Abs(flow)
+		headCurve = append(headCurve, head)
+		torqueCurve = append(torqueCurve, torque)
+	}
+	return
+}
+
+// homologous interpolates a homologous curve at the given speed and flow ratios and scales it back by α²+v².
+func homologous(curve []float64, speedRatio float64, flowRatio float64) float64 {
+	var magnitude float64 = speedRatio*speedRatio + flowRatio*flowRatio
+	if magnitude == 0 {
+		return 0
+	}
+	var position float64 = (math.Atan2(speedRatio, flowRatio) + math.Pi) / (2 * math.Pi) * float64(len(curve)-1)
+	var index int = min(int(position), len(curve)-2)
+	var weight float64 = position - float64(index)
+	return magnitude * ((1-weight)*curve[index] + weight*curve[index+1])
+}
+
+func (pump FluidPump) ratios(massFlowRate float64, density float64) (speedRatio float64, flowRatio float64) {
+	return pump.Speed / pump.RatedSpeed, massFlowRate / density / pump.RatedFlow
+}
+
+func (pump FluidPump) base() FluidJunctionBase  { return pump.JunctionBase }
+func (pump FluidPump) kind() string             { return "Pump" }
+func (pump FluidPump) inletElevation() float64  { return pump.InletElevation }
+func (pump FluidPump) outletElevation() float64 { return pump.OutletElevation }
+func (pump FluidPump) massFlowRate() float64    { return pump.MassFlowRate }
+func (pump FluidPump) inertia() float64 {
+	return pump.Length / pipeArea(pump.Diameter)
+}
+
+// pressureDrop is the negative of the pressure the pump adds at its current speed. Running backwards or driven by
+// reverse flow, the head can turn negative and the pump becomes a resistance.
+func (pump FluidPump) pressureDrop(massFlowRate float64, stream WaterProperties) float64 {
+	var speedRatio, flowRatio = pump.ratios(massFlowRate, stream.Density)
+	return -stream.Density * gravity * pump.RatedHead * homologous(pump.HeadCurve, speedRatio, flowRatio)
+}
+
+// motorTorque returns the torque in N·m the motor applies at the given speed. Like an induction motor it delivers
+// torque in proportion to how far the speed lags the demand, so the pump settles just below the demanded speed.
+func (pump FluidPump) motorTorque(speed float64) float64 {
+	if pump.Tripped {
+		return 0
+	}
+	var torque float64 = pump.MaxMotorTorque * (pump.SpeedDemand - speed) / (pumpMotorSlip * pump.RatedSpeed)
+	return math.Min(math.Max(torque, 0), pump.MaxMotorTorque)
+}
+
+// simulateRotor advances the speed of the pump over one time step at the flow the step ended with:
+//
+//	I * dω/dt = T_motor - T_hydraulic - T_friction
+//
+// The net torque falls as the speed rises, so this has the same form as the momentum equation of a flow path and is
+// integrated implicitly the same way. A pump on a stiff motor characteristic then settles at any time step instead of
+// oscillating around its demand.
+func (pump FluidPump) simulateRotor(stream WaterProperties, deltaTimeSeconds float64) FluidPump {
+	var flowRatio float64 = pump.MassFlowRate / stream.Density / pump.RatedFlow
+	var hydraulicTorque = func(speed float64) float64 {
+		return pump.RatedTorque * homologous(pump.TorqueCurve, speed/pump.RatedSpeed, flowRatio) * stream.Density / pump.RatedDensity
+	}
+	var retardingTorque = func(speed float64) float64 {
+		return hydraulicTorque(speed) + pump.FrictionTorque*speed/pump.RatedSpeed - pump.motorTorque(speed)
+	}
+	var rotorInertia float64 = 2 * math.Pi * pump.Inertia / 60 // N·m·s per rpm
+	pump.Speed, _ = integrateMomentum(rotorInertia, retardingTorque, pump.Speed, 0, deltaTimeSeconds)
+
+	var speedRatio, _ = pump.ratios(pump.MassFlowRate, stream.Density)
+	pump.Head = pump.RatedHead * homologous(pump.HeadCurve, speedRatio, flowRatio)
+	pump.HydraulicTorque = hydraulicTorque(pump.Speed)
+	return pump
+}
+
+// simulateJunctions advances the rotors of all pumps in a flow path, lets its check valves follow the flow and hands
+// the jet pumps it feeds their drive and suction streams, given the fluid flowing through it.
+func (network *FluidNetwork) simulateJunctions(flowPath FlowPath, stream WaterProperties, deltaTimeSeconds float64) {
+	for _, junctionId := range flowPath.JunctionIDs {
+		if pump, ok := network.Pumps[junctionId]; ok {
+			network.Pumps[junctionId] = pump.simulateRotor(stream, deltaTimeSeconds)
+		}
+		if valve, ok := network.Valves[junctionId]; ok && valve.Type == CheckValve {
+			valve.Position = 0
+			if valve.MassFlowRate > 0 {
+				valve.Position = 1
+			}
+			network.Valves[junctionId] = valve
+		}
+		network.updateJetPumps(junctionId, stream)
+	}
+}
+
+// TripPump opens the motor breaker of a pump, which then coasts down on its inertia.
+func (network *FluidNetwork) TripPump(pumpId string) error {
+	var pump, ok = network.Pumps[pumpId]
+	if !ok {
+		return fmt.Errorf("pump %q does not exist", pumpId)
+	}
+	pump.Tripped = true
+	network.Pumps[pumpId] = pump
+	return nil
+}
+
+// StartPump closes the motor breaker of a pump, which then accelerates towards its speed demand.
+func (network *FluidNetwork) StartPump(pumpId string) error {
+	var pump, ok = network.Pumps[pumpId]
+	if !ok {
+		return fmt.Errorf("pump %q does not exist", pumpId)
+	}
+	pump.Tripped = false
+	network.Pumps[pumpId] = pump
+	return nil
+}
+
+// SetPumpSpeedDemand sets the speed in rpm the speed controller of a pump drives it to. Demands above the highest speed
+// of the controller, 110% of rated, are clamped to it.
+func (network *FluidNetwork) SetPumpSpeedDemand(pumpId string, speed float64) error {
+	var pump, ok = network.Pumps[pumpId]
+	if !ok {
+		return fmt.Errorf("pump %q does not exist", pumpId)
+	}
+	if speed < 0 {
+		return fmt.Errorf("pump %q: speed demand must not be negative, got %g", pumpId, speed)
+	}
+	pump.SpeedDemand = math.Min(speed, pumpMaxSpeedRatio*pump.RatedSpeed)
+	network.Pumps[pumpId] = pump
+	return nil
+}
@@ -0,0 +1,142 @@
+package fluid
+
+import (
+	"math"
+	"testing"
+)
+
+// testPump returns a coasting pump at rated speed with no flow through it.
+func testPump() FluidPump {
+	return FluidPump{
+		RatedFlow:      0.5,
+		RatedHead:      50,
+		RatedSpeed:     1500,
+		RatedTorque:    1000,
+		RatedDensity:   1000,
+		HeadCurve:      DefaultPumpHeadCurve(),
+		TorqueCurve:    DefaultPumpTorqueCurve(),
+		Inertia:        20,
+		FrictionTorque: 20,
+		MaxMotorTorque: 1500,
+		SpeedDemand:    1500,
+		Tripped:        true,
+		Speed:          1500,
+	}
+}
+
+func TestHomologousCurves(t *testing.T) {
+	var tests = []struct {
+		name              string
+		speed, flow       float64 // fractions of rated
+		head, torque      float64 // fractions of rated
+		toleranceFraction float64
+	}{
+		// the rated point lies halfway between two table points 10° apart, the others on one
+		{"rated point", 1, 1, 1, 1, 0.02},
+		{"shut off at rated speed", 1, 0, 1.25, 0.45, 1e-12},
+		{"locked rotor, forward flow", 0, 1, -0.2, -0.2, 1e-12},
+		{"locked rotor, reverse flow", 0, -1, 0.2, 0.2, 1e-12},
+		{"locked rotor, twice the reverse flow", 0, -2, 0.8, 0.8, 1e-12},
+		{"standing still", 0, 0, 0, 0, 0},
+	}
+	for _, test := range tests {
+		var head float64 = homologous(DefaultPumpHeadCurve(), test.speed, test.flow)
+		var torque float64 = homologous(DefaultPumpTorqueCurve(), test.speed, test.flow)
+		if math.Abs(head-test.head) > test.toleranceFraction*math.Abs(test.head) {
+			t.Errorf("%s: head = %v of rated, want %v", test.name, head, test.head)
+		}
+		if math.Abs(torque-test.torque) > test.toleranceFraction*math.Abs(test.torque) {
+			t.Errorf("%s: torque = %v of rated, want %v", test.name, torque, test.torque)
+		}
+	}
+
+	// the rotor reports the same head and torque at the rated point
+	var pump FluidPump = testPump()
+	pump.MassFlowRate = pump.RatedFlow * pump.RatedDensity
+	pump.Tripped = false
+	pump = pump.simulateRotor(WaterProperties{Density: pump.RatedDensity}, 0.001)
+	if math.Abs(pump.Head-pump.RatedHead) > 0.02*pump.RatedHead {
+		t.Errorf("head at the rated point = %v m, want %v m", pump.Head, pump.RatedHead)
+	}
+	if math.Abs(pump.HydraulicTorque-pump.RatedTorque) > 0.02*pump.RatedTorque {
+		t.Errorf("hydraulic torque at the rated point = %v N·m, want %v N·m", pump.HydraulicTorque, pump.RatedTorque)
+	}
+}
+
+func TestDefaultPumpCurvesAreCopies(t *testing.T) {
+	var head, torque []float64 = DefaultPumpHeadCurve(), DefaultPumpTorqueCurve()
+	head[0], torque[0] = 100, 100
+	if DefaultPumpHeadCurve()[0] == 100 || DefaultPumpTorqueCurve()[0] == 100 {
+		t.Error("editing a default pump curve changed the curves of the next pump")
+	}
+}
+
+func TestPumpCoastDown(t *testing.T) {
+	// without flow the tripped rotor is slowed by friction a·ω and the impeller b·ω², with I·dω/dt = -(a·ω + b·ω²), so
+	//
+	//	ω(t) = a·ω0·e^(-a·t/I) / (a + b·ω0·(1 - e^(-a·t/I)))
+	var network FluidNetwork = FluidNetwork{Pumps: map[string]FluidPump{"Pump": testPump()}}
+	if err := network.StartPump("Pump"); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.TripPump("Pump"); err != nil {
+		t.Fatal(err)
+	}
+	var pump FluidPump = network.Pumps["Pump"]
+	if !pump.Tripped || pump.motorTorque(0) != 0 {
+		t.Fatalf("tripped pump: Tripped = %v, motor torque = %v N·m", pump.Tripped, pump.motorTorque(0))
+	}
+
+	var stream WaterProperties = WaterProperties{Density: pump.RatedDensity}
+	var rotorInertia float64 = 2 * math.Pi * pump.Inertia / 60
+	var a float64 = pump.FrictionTorque / pump.RatedSpeed
+	var b float64 = 0.45 * pump.RatedTorque / (pump.RatedSpeed * pump.RatedSpeed)
+	var seconds float64 = 30
+	var decay float64 = math.Exp(-a * seconds / rotorInertia)
+	var want float64 = a * pump.Speed * decay / (a + b*pump.Speed*(1-decay))
+
+	for _, step := range []float64{0.01, 0.1} {
+		var coasting FluidPump = pump
+		for i := 0; i < int(math.Round(seconds/step)); i += 1 {
+			coasting = coasting.simulateRotor(stream, step)
+		}
+		if math.Abs(coasting.Speed-want) > 0.01*want {
+			t.Errorf("speed %v s after the trip in steps of %v s = %v rpm, want %v rpm", seconds, step, coasting.Speed, want)
+		}
+	}
+
+	if err := network.TripPump("Missing"); err == nil {
+		t.Error("tripping a missing pump succeeded")
+	}
+	if err := network.StartPump("Missing"); err == nil {
+		t.Error("starting a missing pump succeeded")
+	}
+}
+
+func TestSetPumpSpeedDemand(t *testing.T) {
+	var tests = []struct {
+		name   string
+		demand float64
+		want   float64
+		fails  bool
+	}{
+		{"below rated", 1200, 1200, false},
+		{"at the controller limit", 1650, 1650, false},
+		{"above the controller limit", 2000, 1650, false},
+		{"negative", -100, 1500, true},
+	}
+	for _, test := range tests {
+		var network FluidNetwork = FluidNetwork{Pumps: map[string]FluidPump{"Pump": testPump()}}
+		var err error = network.SetPumpSpeedDemand("Pump", test.demand)
+		if (err != nil) != test.fails {
+			t.Errorf("%s: error = %v, want an error %v", test.name, err, test.fails)
+		}
+		if got := network.Pumps["Pump"].SpeedDemand; math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: speed demand = %v rpm, want %v rpm", test.name, got, test.want)
+		}
+	}
+	var network FluidNetwork = FluidNetwork{Pumps: map[string]FluidPump{}}
+	if err := network.SetPumpSpeedDemand("Missing", 1000); err == nil {
+		t.Error("setting the speed of a missing pump succeeded")
+	}
+}
@@ -0,0 +1,290 @@
+package fluid
+
+import (
+	"math"
+	"sort"
+)
+
+// --- CONSTANT DECLARATIONS ---
+const flowSolverTolerance float64 = 0.000001   // kg/s, largest mass imbalance a solved header or closed node may keep
+const flowSolverIterations int = 30            // Newton iterations per flow step
+const flowSolverPressureStep float64 = 1000000 // Pa, a Newton step moves no pressure by more than this or its own value
+const flowStepSplits int = 8                   // times a flow step may be halved, down to 1/256 of it
+const flowStepLinearity float64 = 0.1          // share of its pressure change a closed node may end the step away from its solved pressure
+const flowStepPressureTolerance float64 = 100  // Pa a closed node may always end the step away from its solved pressure
+
+// --- STRUCT DECLARATIONS ---
+
+// pressureUnknown is a pressure the flow solver solves for: every header, and every closed node whose pressure rises
+// as it fills. Open nodes are vented and keep their pressure during a step.
+type pressureUnknown struct {
+	terminalType string // Node/Header
+	terminalId   string
+	oldPressure  float64 // Pa, at the start of the step
+	capacitance  float64 // kg/(s·Pa), mass flow that raises the pressure of a closed node by 1 Pa over the step, 0 for headers
+}
+
+// solveFlows finds the flow along every flow path at the end of the time step. The flows follow from the pressures at
+// both ends of each path through the momentum equation, see integrateMomentum, and the unknown pressures are solved
+// so that every header passes on exactly what it receives and every closed node ends the step at the pressure its new
+// inventory gives, linearized around its current state:
+//
+//	header:      sum(m_in) - sum(m_out) = 0
+//	closed node: sum(m_in) - sum(m_out) - capacitance*(p - p_old) = 0
+//
+// The flows are eliminated with m = m(dP), which leaves one equation per unknown pressure. Its Jacobian is sparse,
+// with one row and column per pressure and an off-diagonal entry per flow path, and symmetric positive definite, so
+// each Newton step is solved with the conjugate gradient method. Solving for the end-of-step pressures makes the
+// step implicit, so it stays stable at large time steps where a stiff vessel would otherwise overshoot. The solved
+// pressures of the closed nodes are returned as well, so the caller can check them against the pressures their new
+// inventories actually give.
+func (network *FluidNetwork) solveFlows(connections [][2]pathConnection, deltaTimeSeconds float64) (flows []float64, nodePressures map[string]float64) {
+	var unknowns []pressureUnknown
+	var index map[string]int = make(map[string]int) // terminal type and ID to unknown
+	for _, headerId := range sortedKeys(network.Headers) {
+		index["Header:"+headerId] = len(unknowns)
+		unknowns = append(unknowns, pressureUnknown{"Header", headerId, network.Headers[headerId].Pressure, 0})
+	}
+	for _, nodeId := range sortedKeys(network.Nodes) {
+		var node FluidNode = network.Nodes[nodeId]
+		if !node.Closed {
+			continue
+		}
+		var stiffness float64 = nodePressureStiffness(network.properties(), node)
+		if stiffness <= 0 || math.IsNaN(stiffness) {
+			continue // the node behaves like a vent, its pressure does not respond to its inventory
+		}
+		index["Node:"+nodeId] = len(unknowns)
+		unknowns = append(unknowns, pressureUnknown{"Node", nodeId, node.Pressure, 1 / (stiffness * deltaTimeSeconds)})
+	}
+	var unknownIndex = func(terminalType string, terminalId string) int {
+		if i, ok := index[terminalType+":"+terminalId]; ok {
+			return i
+		}
+		return -1
+	}
+
+	var inertias []float64 = make([]float64, len(network.FlowPaths))
+	var elevationGains []float64 = make([]float64, len(network.FlowPaths))
+	for k, flowPath := range network.FlowPaths {
+		inertias[k] = network.CalculatePathInertia(flowPath)
+		elevationGains[k] = network.CalculatePathElevationGain(flowPath)
+	}
+
+	var pressures []float64 = make([]float64, len(unknowns))
+	for i, unknown := range unknowns {
+		pressures[i] = unknown.oldPressure
+	}
+	var pressureOf = func(pressures []float64, terminalType string, terminalId string) float64 {
+		if i := unknownIndex(terminalType, terminalId); i >= 0 {
+			return pressures[i]
+		}
+		return network.Nodes[terminalId].Pressure
+	}
+
+	// evaluate returns the flows, their derivatives with respect to the pressure difference and the mass imbalance of
+	// every unknown for a set of pressures.
+	var evaluate = func(pressures []float64) (flows []float64, derivatives []float64, imbalance []float64, norm float64) {
+		for i, unknown := range unknowns {
+			if unknown.terminalType == "Header" {
+				var header FluidHeader = network.Headers[unknown.terminalId]
+				header.Pressure = pressures[i]
+				network.Headers[unknown.terminalId] = header
+			}
+		}
+		flows = make([]float64, len(network.FlowPaths))
+		derivatives = make([]float64, len(network.FlowPaths))
+		imbalance = make([]float64, len(unknowns))
+		for i, unknown := range unknowns {
+			imbalance[i] = -unknown.capacitance * (pressures[i] - unknown.oldPressure)
+		}
+		for k, flowPath := range network.FlowPaths {
+			var deltaP float64 = pressureOf(pressures, flowPath.SourceType, flowPath.SourceID) + connections[k][0].head -
+				pressureOf(pressures, flowPath.DestinationType, flowPath.DestinationID) - connections[k][1].head
+			var oldMassFlowRate float64 = network.GetPathMassFlowRate(flowPath)
+			var fromSource bool = oldMassFlowRate > 0 || (oldMassFlowRate == 0 && deltaP >= 0) // the fluid already in the path decides which stream enters it
+			var stream WaterProperties = network.pathStream(k, fromSource, connections)
+			deltaP -= stream.Density * gravity * elevationGains[k] // the column of fluid in the pipes
+			var pressureDrop = func(massFlowRate float64) float64 {
+				return network.CalculatePathPressureDrop(flowPath, massFlowRate, stream)
+			}
+			flows[k], derivatives[k] = integrateMomentum(inertias[k], pressureDrop, oldMassFlowRate, deltaP, deltaTimeSeconds)
+			if (flows[k] > 0 && connections[k][0].uncovered) || (flows[k] < 0 && connections[k][1].uncovered) {
+				flows[k], derivatives[k] = 0, 1e-12 // nothing can be drawn from above the water line
+			}
+			if i := unknownIndex(flowPath.SourceType, flowPath.SourceID); i >= 0 {
+				imbalance[i] -= flows[k]
+			}
+			if i := unknownIndex(flowPath.DestinationType, flowPath.DestinationID); i >= 0 {
+				imbalance[i] += flows[k]
+			}
+		}
+		for _, value := range imbalance {
+			norm = math.Max(norm, math.Abs(value))
+		}
+		return
+	}
+
+	flows, derivatives, imbalance, norm := evaluate(pressures)
+	for iteration := 0; iteration < flowSolverIterations && norm > flowSolverTolerance; iteration += 1 {
+		var jacobian sparseMatrix = newSparseMatrix(len(unknowns)) // minus the derivative of the imbalance with respect to the pressures
+		for i, unknown := range unknowns {
+			jacobian.add(i, i, unknown.capacitance)
+		}
+		for k, flowPath := range network.FlowPaths {
+			var source int = unknownIndex(flowPath.SourceType, flowPath.SourceID)
+			var destination int = unknownIndex(flowPath.DestinationType, flowPath.DestinationID)
+			if source >= 0 {
+				jacobian.add(source, source, derivatives[k])
+			}
+			if destination >= 0 {
+				jacobian.add(destination, destination, derivatives[k])
+			}
+			if source >= 0 && destination >= 0 {
+				jacobian.add(source, destination, -derivatives[k])
+				jacobian.add(destination, source, -derivatives[k])
+			}
+		}
+		var correction []float64 = jacobian.solve(imbalance)
+		var bound float64 = 1 // scales the whole step, so a poor linearization cannot throw one pressure across the range
+		for i := range correction {
+			var limit float64 = math.Max(pressures[i], flowSolverPressureStep)
+			if math.Abs(correction[i]) > limit {
+				bound = math.Min(bound, limit/math.Abs(correction[i]))
+			}
+		}
+
+		var accepted bool = false
+		for damping := bound; damping > 0.001*bound; damping /= 2 { // halve the step until the imbalance shrinks
+			var trial []float64 = make([]float64, len(pressures))
+			for i := range pressures {
+				trial[i] = math.Min(math.Max(pressures[i]+damping*correction[i], 611.657), 100000000)
+			}
+			var trialFlows, trialDerivatives, trialImbalance, trialNorm = evaluate(trial)
+			if trialNorm < norm {
+				pressures, flows, derivatives, imbalance, norm = trial, trialFlows, trialDerivatives, trialImbalance, trialNorm
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			evaluate(pressures) // restore the header pressures of the best iterate
+			break
+		}
+	}
+	nodePressures = make(map[string]float64)
+	for i, unknown := range unknowns {
+		if unknown.terminalType == "Node" {
+			nodePressures[unknown.terminalId] = pressures[i]
+		}
+	}
+	return flows, nodePressures
+}
+
+// nodePressureStiffness returns how much the pressure of a closed node rises per kg of its own outflow stream added to
+// it, in Pa/kg. In a full vessel this is set by the compressibility of the liquid, in a vessel with a steam dome by the
+// vapour that the incoming liquid compresses.
+func nodePressureStiffness(provider PropertyProvider, node FluidNode) float64 {
+	var stream WaterProperties = OutflowProperties(provider, node)
+	var addedMass float64 = math.Max(node.Mass*0.00001, 0.001)
+	var mass float64 = node.Mass + addedMass
+	var internalEnergy float64 = (node.Mass*node.InternalEnergy + addedMass*stream.Enthalpy*1000) / mass
+	var state WaterProperties = ResolveStateUV(provider, internalEnergy/1000, node.MaxVolume/mass)
+	return (state.Pressure*1000000 - node.Pressure) / addedMass
+}
+
+// sparseMatrix is a square matrix that only stores its non-zero entries, row by row. The entries of a row are kept
+// sorted by column, so every product sums them in the same order and a step gives bit-identical results on every run.
+type sparseMatrix struct {
+	rows [][]sparseEntry
+}
+
+// sparseEntry is one stored entry of a sparseMatrix row.
+type sparseEntry struct {
+	column int
+	value  float64
+}
+
+func newSparseMatrix(size int) sparseMatrix {
+	return sparseMatrix{make([][]sparseEntry, size)}
+}
+
+func (matrix sparseMatrix) add(row int, column int, value float64) {
+	var entries []sparseEntry = matrix.rows[row]
+	var position int = sort.Search(len(entries), func(k int) bool { return entries[k].column >= column })
+	if position < len(entries) && entries[position].column == column {
+		entries[position].value += value
+		return
+	}
+	entries = append(entries, sparseEntry{})
+	copy(entries[position+1:], entries[position:])
+	entries[position] = sparseEntry{column, value}
+	matrix.rows[row] = entries
+}
+
+// get returns the entry at row and column, 0 if it is not stored.
+func (matrix sparseMatrix) get(row int, column int) float64 {
+	var entries []sparseEntry = matrix.rows[row]
+	var position int = sort.Search(len(entries), func(k int) bool { return entries[k].column >= column })
+	if position < len(entries) && entries[position].column == column {
+		return entries[position].value
+	}
+	return 0
+}
+
+func (matrix sparseMatrix) multiply(vector []float64) []float64 {
+	var result []float64 = make([]float64, len(matrix.rows))
+	for i, row := range matrix.rows {
+		for _, entry := range row {
+			result[i] += entry.value * vector[entry.column]
+		}
+	}
+	return result
+}
+
+// solve solves matrix*x = b for a symmetric positive definite matrix with the conjugate gradient method, using the
+// diagonal as preconditioner.
+func (matrix sparseMatrix) solve(b []float64) []float64 {
+	var n int = len(b)
+	var x []float64 = make([]float64, n)
+	var residual []float64 = append([]float64(nil), b...)
+	var preconditioned []float64 = make([]float64, n)
+	var dot = func(a []float64, b []float64) (sum float64) {
+		for i := range a {
+			sum += a[i] * b[i]
+		}
+		return
+	}
+	var precondition = func() {
+		for i := range residual {
+			preconditioned[i] = residual[i]
+			if diagonal := matrix.get(i, i); diagonal > 0 {
+				preconditioned[i] = residual[i] / diagonal
+			}
+		}
+	}
+
+	precondition()
+	var direction []float64 = append([]float64(nil), preconditioned...)
+	var product float64 = dot(residual, preconditioned)
+	var tolerance float64 = 1e-24 * dot(b, b)
+	for iteration := 0; iteration < 10*n+100 && dot(residual, residual) > tolerance; iteration += 1 {
+		var matrixDirection []float64 = matrix.multiply(direction)
+		var curvature float64 = dot(direction, matrixDirection)
+		if curvature <= 0 {
+			break
+		}
+		var step float64 = product / curvature
+		for i := range x {
+			x[i] += step * direction[i]
+			residual[i] -= step * matrixDirection[i]
+		}
+		precondition()
+		var nextProduct float64 = dot(residual, preconditioned)
+		for i := range direction {
+			direction[i] = preconditioned[i] + nextProduct/product*direction[i]
+		}
+		product = nextProduct
+	}
+	return x
+}
@@ -0,0 +1,327 @@
+package fluid
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closedLoopModel is a pump loop from an open tank through a header that splits the flow into two branches, into a
+// vessel full of liquid and back to the tank.
+const closedLoopModel string = `{
+	"version": 2,
+	"nodes": {
+		"Tank": {"temperature": 30, "pressure": 101325, "volume": 60, "maxVolume": 200, "bottomElevation": 10, "topElevation": 15},
+		"Vessel": {"temperature": 30, "pressure": 300000, "volume": 20, "maxVolume": 20, "closed": true, "bottomElevation": 0, "topElevation": 5}
+	},
+	"headers": {"Split": {}},
+	"pipes": {
+		"Suction": {"sourceType": "Node", "sourceId": "Tank", "destinationType": "Junction", "destinationId": "Pump",
+			"diameter": 300, "length": 15, "minorK": 1, "inletElevation": 10, "outletElevation": 0},
+		"BranchA": {"sourceType": "Header", "sourceId": "Split", "destinationType": "Node", "destinationId": "Vessel",
+			"diameter": 200, "length": 10, "minorK": 2, "inletElevation": 0, "outletElevation": 1},
+		"BranchB": {"sourceType": "Header", "sourceId": "Split", "destinationType": "Node", "destinationId": "Vessel",
+			"diameter": 150, "length": 20, "minorK": 4, "inletElevation": 0, "outletElevation": 2},
+		"Return": {"sourceType": "Node", "sourceId": "Vessel", "destinationType": "Node", "destinationId": "Tank",
+			"diameter": 250, "length": 25, "minorK": 3, "inletElevation": 4, "outletElevation": 14}
+	},
+	"pumps": {
+		"Pump": {"sourceType": "Junction", "sourceId": "Suction", "destinationType": "Header", "destinationId": "Split",
+			"diameter": 300, "inletElevation": 0, "outletElevation": 0, "ratedFlow": 0.15, "ratedHead": 40,
+			"ratedSpeed": 1500, "ratedDensity": 1000, "inertia": 5, "speedDemand": 1500, "speed": 0, "running": true}
+	}
+}`
+
+// newTestNetwork returns the initialized network of a JSON plant model.
+func newTestNetwork(t *testing.T, model string) *FluidNetwork {
+	t.Helper()
+	var plant, err = ParsePlantModel([]byte(model))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var network *FluidNetwork = plant.Network()
+	if err := network.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+// totalMass returns the mass of all nodes of the network in kg.
+func totalMass(network *FluidNetwork) (mass float64) {
+	for _, node := range network.Nodes {
+		mass += node.Mass
+	}
+	return
+}
+
+// totalEnergy returns the energy of all nodes of the network in J, as they account it.
+func totalEnergy(network *FluidNetwork) (energy float64) {
+	for _, node := range network.Nodes {
+		energy += node.Mass * nodeSpecificEnergy(node)
+	}
+	return
+}
+
+// newDefaultNetwork returns the initialized network of the built-in plant.
+func newDefaultNetwork(t *testing.T) *FluidNetwork {
+	t.Helper()
+	var network *FluidNetwork = DefaultPlantModel().Network()
+	if err := network.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+func TestClosedLoopConservesMass(t *testing.T) {
+	for _, deltaTime := range []time.Duration{100 * time.Millisecond, time.Second} {
+		t.Run(deltaTime.String(), func(t *testing.T) {
+			var network *FluidNetwork = newTestNetwork(t, closedLoopModel)
+			var initial float64 = totalMass(network)
+			var steps int = int(60 * time.Second / deltaTime)
+			for i := 0; i < steps; i += 1 {
+				network.SimulateFlow(deltaTime)
+			}
+			if drift := math.Abs(totalMass(network)-initial) / initial; drift > 1e-9 {
+				t.Errorf("total mass changed by %.3g of %.0f kg", drift, initial)
+			}
+			var pump float64 = network.Pumps["Pump"].MassFlowRate
+			var branches float64 = network.Pipes["BranchA"].MassFlowRate + network.Pipes["BranchB"].MassFlowRate
+			if pump <= 0 || math.Abs(pump-branches) > 1e-6*pump {
+				t.Errorf("pump delivers %.3f kg/s into the header, branches take %.3f kg/s", pump, branches)
+			}
+			if ret := network.Pipes["Return"].MassFlowRate; math.Abs(ret-pump) > 0.01*pump {
+				t.Errorf("return flow %.3f kg/s, want the pump flow %.3f kg/s through the full vessel", ret, pump)
+			}
+		})
+	}
+}
+
+func TestMomentumLagsAPressureStep(t *testing.T) {
+	// with laminar friction the drop is R·m, so a step in dP drives the flow of a pipe at rest along the first-order
+	// response m(t) = dP/R·(1 - exp(-t·R/(L/A)))
+	var stream WaterProperties = WaterProperties{Density: 1000, DynamicViscosity: 0.5}
+	var pipe FluidPipe = FluidPipe{PipeDiameter: 50, PipeLength: 100, FrictionModel: Laminar}
+	var diameter float64 = pipe.PipeDiameter / 1000
+	var resistance float64 = 32 * pipe.PipeLength * stream.DynamicViscosity / (stream.Density * pipeArea(pipe.PipeDiameter) * diameter * diameter)
+	var deltaP float64 = 10000
+	var timeConstant float64 = pipe.inertia() / resistance
+	var deltaTimeSeconds float64 = timeConstant / 1000
+	var pressureDrop = func(massFlowRate float64) float64 { return pipe.PressureDrop(massFlowRate, stream) }
+
+	var massFlowRate float64
+	for i := 1; i <= 3000; i += 1 {
+		massFlowRate, _ = integrateMomentum(pipe.inertia(), pressureDrop, massFlowRate, deltaP, deltaTimeSeconds)
+		if i%500 == 0 {
+			var want float64 = deltaP / resistance * (1 - math.Exp(-float64(i)*deltaTimeSeconds/timeConstant))
+			if math.Abs(massFlowRate-want) > 0.001*deltaP/resistance {
+				t.Errorf("after %.2f time constants %v kg/s, want %v kg/s", float64(i)/1000, massFlowRate, want)
+			}
+		}
+	}
+}
+
+func TestFlowReversesSmoothly(t *testing.T) {
+	// turning dP around decelerates the turbulent flow through zero without chattering between the two directions
+	var stream WaterProperties = WaterProperties{Density: 1000, DynamicViscosity: 0.001}
+	var pipe FluidPipe = FluidPipe{PipeDiameter: 200, PipeLength: 50, Roughness: 0.000045, MinorKFactor: 5, FrictionModel: Churchill}
+	var pressureDrop = func(massFlowRate float64) float64 { return pipe.PressureDrop(massFlowRate, stream) }
+	var massFlowRate float64
+	for i := 0; i < 200; i += 1 {
+		massFlowRate, _ = integrateMomentum(pipe.inertia(), pressureDrop, massFlowRate, 50000, 0.1)
+	}
+	var forward float64 = massFlowRate
+	if math.Abs(pressureDrop(forward)-50000) > 50 {
+		t.Fatalf("steady flow %v kg/s loses %v Pa, want the 50000 Pa across the pipe", forward, pressureDrop(forward))
+	}
+	var signChanges int
+	for i := 0; i < 400; i += 1 {
+		var next, _ = integrateMomentum(pipe.inertia(), pressureDrop, massFlowRate, -50000, 0.1)
+		if next > massFlowRate {
+			t.Fatalf("step %d: flow rose from %v to %v kg/s against the reversed pressure difference", i, massFlowRate, next)
+		}
+		if (next < 0) != (massFlowRate < 0) {
+			signChanges += 1
+		}
+		massFlowRate = next
+	}
+	if signChanges != 1 || math.Abs(massFlowRate+forward) > 0.001*forward {
+		t.Errorf("flow changed direction %d times and settled at %v kg/s, want once and %v kg/s", signChanges, massFlowRate, -forward)
+	}
+}
+
+func TestConnectedTanksLevelOut(t *testing.T) {
+	// two open tanks at different heights, joined by a pipe that runs down from the bottom of the upper one, settle with
+	// their free surfaces at the same elevation: 16 m³ over 2 m² each put both surfaces 6.5 m up
+	var network *FluidNetwork = newTestNetwork(t, `{
+	"version": 2,
+	"nodes": {
+		"Upper": {"temperature": 30, "pressure": 101325, "volume": 10, "maxVolume": 20, "bottomElevation": 5, "topElevation": 15},
+		"Lower": {"temperature": 30, "pressure": 101325, "volume": 6, "maxVolume": 20, "bottomElevation": 0, "topElevation": 10}
+	},
+	"pipes": {
+		"Drain": {"sourceType": "Node", "sourceId": "Upper", "destinationType": "Node", "destinationId": "Lower",
+			"diameter": 300, "length": 12, "minorK": 2, "inletElevation": 5, "outletElevation": 0}
+	}
+}`)
+	for i := 0; i < 1200; i += 1 {
+		network.SimulateFlow(time.Second)
+	}
+	var upper, _ = network.Nodes["Upper"].WaterLevels()
+	var lower, _ = network.Nodes["Lower"].WaterLevels()
+	if math.Abs(upper-6.5) > 0.01 || math.Abs(lower-6.5) > 0.01 {
+		t.Errorf("free surfaces at %.3f m and %.3f m, want both at 6.5 m", upper, lower)
+	}
+	if flow := network.Pipes["Drain"].MassFlowRate; math.Abs(flow) > 2 { // the first step drains almost 300 kg/s, the levels then ring down slowly like a manometer
+		t.Errorf("%v kg/s still flows between the level tanks", flow)
+	}
+}
+
+func TestSimulateFlowIsDeterministic(t *testing.T) {
+	var run = func() *FluidNetwork {
+		var network *FluidNetwork = DefaultPlantModel().Network()
+		if err := network.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 50; i += 1 {
+			network.SimulateFlow(100 * time.Millisecond)
+		}
+		return network
+	}
+	var first, second *FluidNetwork = run(), run()
+	for _, nodeId := range sortedKeys(first.Nodes) {
+		var a, b FluidNode = first.Nodes[nodeId], second.Nodes[nodeId]
+		if a.Pressure != b.Pressure || a.Mass != b.Mass || nodeSpecificEnergy(a) != nodeSpecificEnergy(b) {
+			t.Errorf("%s: %v Pa, %v kg in one run, %v Pa, %v kg in the other", nodeId, a.Pressure, a.Mass, b.Pressure, b.Mass)
+		}
+	}
+}
+
+func TestDefaultPlantHoldsSteadyAtRest(t *testing.T) {
+	var model PlantModel = DefaultPlantModel()
+	var network *FluidNetwork = newDefaultNetwork(t)
+	for elapsed := time.Duration(0); elapsed < 20*time.Second; elapsed += 100 * time.Millisecond {
+		network.SimulateFlow(100 * time.Millisecond)
+		for _, nodeId := range sortedKeys(network.Nodes) {
+			var pressure, configured float64 = network.Nodes[nodeId].Pressure, *model.Nodes[nodeId].Pressure
+			if math.Abs(pressure-configured) > 25000 {
+				t.Fatalf("%s at %v: %v Pa, want within 25 kPa of the configured %v Pa", nodeId, elapsed, pressure, configured)
+			}
+		}
+	}
+}
+
+func TestDefaultPlantStaysStableAtLargeSteps(t *testing.T) {
+	for _, deltaTime := range []time.Duration{time.Second, 10 * time.Second, time.Minute} {
+		t.Run(deltaTime.String(), func(t *testing.T) {
+			var network *FluidNetwork = newDefaultNetwork(t)
+			var massBefore, energyBefore float64 = totalMass(network), totalEnergy(network)
+			for elapsed := time.Duration(0); elapsed < 5*time.Minute; elapsed += deltaTime {
+				network.SimulateFlow(deltaTime)
+				for _, nodeId := range sortedKeys(network.Nodes) {
+					var node FluidNode = network.Nodes[nodeId]
+					if node.Pressure < 50000 || node.Pressure > 1000000 || node.Mass <= 0 {
+						t.Fatalf("%s at %v: %v Pa, %v kg", nodeId, elapsed+deltaTime, node.Pressure, node.Mass)
+					}
+				}
+			}
+			if mass := totalMass(network); !closeTo(mass, massBefore, 1e-9) {
+				t.Errorf("mass went from %v kg to %v kg", massBefore, mass)
+			}
+			if energy := totalEnergy(network); !closeTo(energy, energyBefore, 1e-9) {
+				t.Errorf("energy went from %v J to %v J", energyBefore, energy)
+			}
+		})
+	}
+}
+
+func TestDryNodeOnlyLimitsItsOwnFlows(t *testing.T) {
+	// a second loop beside the pump loop, a puddle holding half a kilogram above a sump, drains within a few steps
+	var twoLoopModel string = strings.NewReplacer(
+		`"nodes": {`, `"nodes": {
+		"Puddle": {"temperature": 30, "pressure": 101325, "volume": 0.0005, "maxVolume": 1, "bottomElevation": 10, "topElevation": 11},
+		"Sump": {"temperature": 30, "pressure": 101325, "volume": 20, "maxVolume": 100, "bottomElevation": 0, "topElevation": 5},`,
+		`"pipes": {`, `"pipes": {
+		"Spill": {"sourceType": "Node", "sourceId": "Puddle", "destinationType": "Node", "destinationId": "Sump",
+			"diameter": 100, "length": 5, "minorK": 1, "inletElevation": 10, "outletElevation": 0},
+		"Lift": {"sourceType": "Node", "sourceId": "Sump", "destinationType": "Node", "destinationId": "Puddle",
+			"diameter": 100, "length": 12, "minorK": 1, "inletElevation": 0, "outletElevation": 10.5},`,
+	).Replace(closedLoopModel)
+
+	var network *FluidNetwork = newTestNetwork(t, twoLoopModel)
+	var reference *FluidNetwork = newTestNetwork(t, closedLoopModel)
+	var initial float64 = totalMass(network)
+	var spilled bool
+	for i := 0; i < 50; i += 1 {
+		network.SimulateFlow(100 * time.Millisecond)
+		reference.SimulateFlow(100 * time.Millisecond)
+		spilled = spilled || network.Pipes["Spill"].MassFlowRate > 0
+		for _, pipeId := range []string{"Suction", "BranchA", "BranchB", "Return"} {
+			var got, want float64 = network.Pipes[pipeId].MassFlowRate, reference.Pipes[pipeId].MassFlowRate
+			if math.Abs(got-want) > 1e-6*math.Abs(want) {
+				t.Fatalf("step %d: %s carries %.6f kg/s beside the draining puddle, %.6f kg/s on its own", i, pipeId, got, want)
+			}
+		}
+	}
+	if puddle := network.Nodes["Puddle"].Mass; !spilled || puddle > 1e-6 {
+		t.Errorf("puddle holds %v kg after spilling %v, want it drained", puddle, spilled)
+	}
+	if drift := math.Abs(totalMass(network)-initial) / initial; drift > 1e-9 {
+		t.Errorf("total mass changed by %.3g of %.0f kg", drift, initial)
+	}
+}
+
+func TestBalanceHeaders(t *testing.T) {
+	var path = func(sourceType string, sourceId string, destinationType string, destinationId string) FlowPath {
+		return FlowPath{SourceType: sourceType, SourceID: sourceId, DestinationType: destinationType, DestinationID: destinationId}
+	}
+	var tests = []struct {
+		name  string
+		paths []FlowPath
+		flows []float64
+		want  []float64
+	}{
+		{
+			"balanced header is left alone",
+			[]FlowPath{path("Node", "N1", "Header", "H1"), path("Header", "H1", "Node", "N2"), path("Header", "H1", "Node", "N3")},
+			[]float64{10, 4, 6},
+			[]float64{10, 4, 6},
+		},
+		{
+			"outflow beyond the inflow is cut back, as in a cavitating throat",
+			[]FlowPath{path("Node", "N1", "Header", "H1"), path("Node", "N2", "Header", "H1"), path("Header", "H1", "Node", "N3")},
+			[]float64{6, 2, 10},
+			[]float64{6, 2, 8},
+		},
+		{
+			"inflow beyond the outflow is cut back",
+			[]FlowPath{path("Node", "N1", "Header", "H1"), path("Node", "N2", "Header", "H1"), path("Header", "H1", "Node", "N3")},
+			[]float64{6, 2, 4},
+			[]float64{3, 1, 4},
+		},
+		{
+			"reverse flows count on the side they flow to",
+			[]FlowPath{path("Header", "H1", "Node", "N1"), path("Node", "N2", "Header", "H1")},
+			[]float64{-6, -4},
+			[]float64{-4, -4},
+		},
+		{
+			"a cut back passes on up a chain of headers",
+			[]FlowPath{path("Node", "N1", "Header", "H1"), path("Header", "H1", "Header", "H2"), path("Header", "H2", "Node", "N2")},
+			[]float64{10, 10, 8},
+			[]float64{8, 8, 8},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var network *FluidNetwork = &FluidNetwork{Headers: map[string]FluidHeader{"H1": {}, "H2": {}}, FlowPaths: test.paths}
+			var flows []float64 = network.balanceHeaders(append([]float64(nil), test.flows...))
+			for k := range flows {
+				if math.Abs(flows[k]-test.want[k]) > 1e-9 {
+					t.Errorf("flows %v, want %v", flows, test.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package fluid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- STRUCT DECLARATIONS ---
+type TopologyProblemKind string
+
+const (
+	UnknownEndpointType TopologyProblemKind = "unknown endpoint type"  // SourceType or DestinationType is not Node, Header or Junction
+	DanglingReference   TopologyProblemKind = "dangling reference"     // SourceID or DestinationID names a node, header or junction that does not exist
+	MismatchedLink      TopologyProblemKind = "mismatched link"        // two junctions disagree about being connected to each other
+	JunctionCycle       TopologyProblemKind = "junction cycle"         // a chain of pipes leads back into itself and never reaches a node or header
+	OrphanPipe          TopologyProblemKind = "orphan pipe"            // a pipe, pump or valve that no node or header feeds
+	UnconnectedNode     TopologyProblemKind = "unconnected node"       // a node or header that no pipe starts or ends at
+	DeadEndHeader       TopologyProblemKind = "dead-end header"        // a header with a single pipe, which can never carry flow
+	DuplicateID         TopologyProblemKind = "duplicate ID"           // two pipes, pumps or valves with the same ID
+	InvalidJetPumpInlet TopologyProblemKind = "invalid jet pump inlet" // a jet pump's nozzle or suction inlet is not a pipe into its throat header
+)
+
+// TopologyProblem is a single defect found in the layout of a fluid network.
+type TopologyProblem struct {
+	Kind        TopologyProblemKind
+	ElementType string // Node/Header/Pipe/Pump/Valve/JetPump
+	ID          string // e.g. HotwellToTest
+	Detail      string
+}
+
+func (problem TopologyProblem) Error() string {
+	return fmt.Sprintf("%s %q: %s: %s", strings.ToLower(problem.ElementType), problem.ID, problem.Kind, problem.Detail)
+}
+
+// TopologyError reports every problem found by a topology validation pass at once.
+type TopologyError struct {
+	Problems []TopologyProblem
+}
+
+func (topologyError *TopologyError) Error() string {
+	var lines []string = make([]string, 0, len(topologyError.Problems))
+	for _, problem := range topologyError.Problems {
+		lines = append(lines, problem.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// topologyJunction is the part of a pipe, pump or valve the topology validation looks at.
+type topologyJunction struct {
+	base FluidJunctionBase
+	kind string // Pipe/Pump/Valve/JetPump
+}
+
+// Validate checks the layout of the network and returns every problem found, or nil if the network is sound.
+func (network *FluidNetwork) Validate() []TopologyProblem {
+	var junctions, duplicates = network.topologyJunctions()
+	return append(validateTopology(network.Nodes, network.Headers, junctions, duplicates), network.validateJetPumpInlets()...)
+}
+
+// validateJetPumpInlets checks that the nozzle and the suction inlet of every jet pump are two different pipes that
+// lead into its throat header, the only junctions whose flow and area a jet pump can take its momentum from.
+func (network *FluidNetwork) validateJetPumpInlets() (problems []TopologyProblem) {
+	for _, id := range sortedKeys(network.JetPumps) {
+		var jetPump FluidJetPump = network.JetPumps[id]
+		var problem = func(format string, args ...any) {
+			problems = append(problems, TopologyProblem{InvalidJetPumpInlet, "JetPump", id, fmt.Sprintf(format, args...)})
+		}
+		for _, inlet := range []struct{ name, pipeId string }{{"drive junction", jetPump.DriveJunction}, {"suction junction", jetPump.SuctionJunction}} {
+			if inlet.pipeId == "" {
+				problem("the %s is missing", inlet.name)
+				continue
+			}
+			var pipe, ok = network.Pipes[inlet.pipeId]
+			if !ok {
+				problem("the %s %q is not a pipe", inlet.name, inlet.pipeId)
+			} else if pipe.JunctionBase.DestinationType != "Header" || pipe.JunctionBase.DestinationID != jetPump.JunctionBase.SourceID {
+				problem("the %s %q must lead into the throat header %q", inlet.name, inlet.pipeId, jetPump.JunctionBase.SourceID)
+			}
+		}
+		if jetPump.DriveJunction != "" && jetPump.DriveJunction == jetPump.SuctionJunction {
+			problem("the drive and suction junctions must be different pipes")
+		}
+	}
+	return
+}
+
+// validateTopology only looks at node and header IDs and at the junction info of the pipes, pumps and valves, so it can
+// also check models whose elements are otherwise incomplete. Pipes, pumps and valves share one ID space, as either can be named as
+// the source or destination of the other; duplicates lists the IDs used more than once.
+func validateTopology(nodes map[string]FluidNode, headers map[string]FluidHeader, junctions map[string]topologyJunction, duplicates []string) (problems []TopologyProblem) {
+	var problem = func(kind TopologyProblemKind, elementType string, id string, format string, args ...any) {
+		problems = append(problems, TopologyProblem{kind, elementType, id, fmt.Sprintf(format, args...)})
+	}
+	var endpointExists = func(endpointType string, endpointId string) bool {
+		var ok bool
+		switch endpointType {
+		case "Node":
+			_, ok = nodes[endpointId]
+		case "Header":
+			_, ok = headers[endpointId]
+		default:
+			_, ok = junctions[endpointId]
+		}
+		return ok
+	}
+	var validEndpointType = func(endpointType string) bool {
+		return endpointType == "Node" || endpointType == "Header" || endpointType == "Junction"
+	}
+	var describe = func(junctionId string) string {
+		return fmt.Sprintf("%s %q", strings.ToLower(junctions[junctionId].kind), junctionId)
+	}
+
+	for _, junctionId := range duplicates {
+		problem(DuplicateID, junctions[junctionId].kind, junctionId, "the ID is used by more than one pipe, pump, valve or jet pump")
+	}
+
+	var connectedNodes map[string]bool = make(map[string]bool)
+	var headerConnections map[string]int = make(map[string]int)
+	for _, junctionId := range sortedKeys(junctions) { // endpoint types, references and links between neighbouring junctions
+		var base FluidJunctionBase = junctions[junctionId].base
+		var kind string = junctions[junctionId].kind
+		var validSource bool = validEndpointType(base.SourceType)
+		var validDestination bool = validEndpointType(base.DestinationType)
+		if !validSource {
+			problem(UnknownEndpointType, kind, junctionId, "source type %q must be Node, Header or Junction", base.SourceType)
+		}
+		if !validDestination {
+			problem(UnknownEndpointType, kind, junctionId, "destination type %q must be Node, Header or Junction", base.DestinationType)
+		}
+		if validSource && !endpointExists(base.SourceType, base.SourceID) {
+			problem(DanglingReference, kind, junctionId, "source %s %q does not exist", strings.ToLower(base.SourceType), base.SourceID)
+			validSource = false
+		}
+		if validDestination && !endpointExists(base.DestinationType, base.DestinationID) {
+			problem(DanglingReference, kind, junctionId, "destination %s %q does not exist", strings.ToLower(base.DestinationType), base.DestinationID)
+			validDestination = false
+		}
+
+		if validSource && base.SourceType == "Node" {
+			connectedNodes[base.SourceID] = true
+		}
+		if validDestination && base.DestinationType == "Node" {
+			connectedNodes[base.DestinationID] = true
+		}
+		if validSource && base.SourceType == "Header" {
+			headerConnections[base.SourceID] += 1
+		}
+		if validDestination && base.DestinationType == "Header" {
+			headerConnections[base.DestinationID] += 1
+		}
+		if validSource && base.SourceType == "Junction" {
+			var upstream FluidJunctionBase = junctions[base.SourceID].base
+			if upstream.DestinationType != "Junction" || upstream.DestinationID != junctionId {
+				problem(MismatchedLink, kind, junctionId, "names %s as its source, but that %s leads into %s %q", describe(base.SourceID), strings.ToLower(junctions[base.SourceID].kind), upstream.DestinationType, upstream.DestinationID)
+			}
+		}
+		if validDestination && base.DestinationType == "Junction" {
+			var downstream FluidJunctionBase = junctions[base.DestinationID].base
+			if downstream.SourceType != "Junction" || downstream.SourceID != junctionId {
+				problem(MismatchedLink, kind, junctionId, "leads into %s, but that %s names %s %q as its source", describe(base.DestinationID), strings.ToLower(junctions[base.DestinationID].kind), downstream.SourceType, downstream.SourceID)
+			}
+		}
+	}
+
+	var inCycle map[string]bool = make(map[string]bool)
+	var walked map[string]bool = make(map[string]bool)
+	for _, startId := range sortedKeys(junctions) { // every junction has at most one downstream junction, so a walk either ends or loops
+		var position map[string]int = make(map[string]int)
+		var chain []string
+		var junctionId string = startId
+		for {
+			if walked[junctionId] {
+				break
+			}
+			if index, ok := position[junctionId]; ok {
+				var cycle []string = chain[index:]
+				for _, cycleJunctionId := range cycle {
+					inCycle[cycleJunctionId] = true
+				}
+				problem(JunctionCycle, junctions[junctionId].kind, junctionId, "%s -> %s", strings.Join(cycle, " -> "), junctionId)
+				break
+			}
+			position[junctionId] = len(chain)
+			chain = append(chain, junctionId)
+			var base FluidJunctionBase = junctions[junctionId].base
+			if _, ok := junctions[base.DestinationID]; base.DestinationType != "Junction" || !ok {
+				break
+			}
+			junctionId = base.DestinationID
+		}
+		for _, chainJunctionId := range chain {
+			walked[chainJunctionId] = true
+		}
+	}
+
+	var fed map[string]bool = make(map[string]bool)
+	for _, junctionId := range sortedKeys(junctions) { // junctions reachable from a node or header
+		if sourceType := junctions[junctionId].base.SourceType; sourceType != "Node" && sourceType != "Header" {
+			continue
+		}
+		for current := junctionId; !fed[current] && !inCycle[current]; {
+			fed[current] = true
+			var base FluidJunctionBase = junctions[current].base
+			if _, ok := junctions[base.DestinationID]; base.DestinationType != "Junction" || !ok {
+				break
+			}
+			current = base.DestinationID
+		}
+	}
+	for _, junctionId := range sortedKeys(junctions) {
+		if !fed[junctionId] && !inCycle[junctionId] {
+			problem(OrphanPipe, junctions[junctionId].kind, junctionId, "no node or header feeds this %s", strings.ToLower(junctions[junctionId].kind))
+		}
+	}
+
+	for _, nodeId := range sortedKeys(nodes) {
+		if !connectedNodes[nodeId] {
+			problem(UnconnectedNode, "Node", nodeId, "no pipe starts or ends at this node")
+		}
+	}
+	for _, headerId := range sortedKeys(headers) {
+		if headerConnections[headerId] == 0 {
+			problem(UnconnectedNode, "Header", headerId, "no pipe starts or ends at this header")
+		} else if headerConnections[headerId] == 1 {
+			problem(DeadEndHeader, "Header", headerId, "only one pipe is connected, a header needs at least two")
+		}
+	}
+	return
+}
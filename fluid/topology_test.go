@@ -0,0 +1,88 @@
+package fluid
+
+import (
+	"slices"
+	"testing"
+)
+
+// topologyTestNetwork returns a sound network: node A feeds header H through P1, H feeds node B through P2, and P3
+// returns from B to A.
+func topologyTestNetwork() *FluidNetwork {
+	var pipe = func(sourceType string, sourceId string, destinationType string, destinationId string) FluidPipe {
+		return FluidPipe{JunctionBase: FluidJunctionBase{SourceType: sourceType, SourceID: sourceId, DestinationType: destinationType, DestinationID: destinationId}}
+	}
+	return &FluidNetwork{
+		Nodes:   map[string]FluidNode{"A": {}, "B": {}},
+		Headers: map[string]FluidHeader{"H": {}},
+		Pipes: map[string]FluidPipe{
+			"P1": pipe("Node", "A", "Header", "H"),
+			"P2": pipe("Header", "H", "Node", "B"),
+			"P3": pipe("Node", "B", "Node", "A"),
+		},
+		Pumps:    map[string]FluidPump{},
+		Valves:   map[string]FluidValve{},
+		JetPumps: map[string]FluidJetPump{},
+	}
+}
+
+func TestValidateTopology(t *testing.T) {
+	var setPipe = func(id string, sourceType string, sourceId string, destinationType string, destinationId string) func(network *FluidNetwork) {
+		return func(network *FluidNetwork) {
+			network.Pipes[id] = FluidPipe{JunctionBase: FluidJunctionBase{SourceType: sourceType, SourceID: sourceId, DestinationType: destinationType, DestinationID: destinationId}}
+		}
+	}
+	var tests = []struct {
+		name   string
+		modify []func(network *FluidNetwork)
+		want   []string // kind and ID of every problem
+	}{
+		{"sound network", nil, nil},
+		{"unknown endpoint type", []func(*FluidNetwork){setPipe("P3", "Tank", "B", "Node", "A")},
+			[]string{"unknown endpoint type P3", "orphan pipe P3"}},
+		{"dangling destination", []func(*FluidNetwork){setPipe("P3", "Node", "B", "Node", "C")},
+			[]string{"dangling reference P3"}},
+		{"dangling source", []func(*FluidNetwork){setPipe("O1", "Junction", "X", "Node", "B")},
+			[]string{"dangling reference O1", "orphan pipe O1"}},
+		{"mismatched link", []func(*FluidNetwork){setPipe("Q1", "Node", "A", "Junction", "Q2"), setPipe("Q2", "Node", "A", "Node", "B")},
+			[]string{"mismatched link Q1"}},
+		{"junction cycle", []func(*FluidNetwork){setPipe("C1", "Junction", "C2", "Junction", "C2"), setPipe("C2", "Junction", "C1", "Junction", "C1")},
+			[]string{"junction cycle C1"}},
+		{"orphan chain", []func(*FluidNetwork){setPipe("O1", "Junction", "O2", "Junction", "O2"), setPipe("O2", "Junction", "O1", "Node", "B")},
+			[]string{"mismatched link O1", "orphan pipe O1", "orphan pipe O2"}},
+		{"unconnected node", []func(*FluidNetwork){func(network *FluidNetwork) { network.Nodes["C"] = FluidNode{} }},
+			[]string{"unconnected node C"}},
+		{"unconnected header", []func(*FluidNetwork){func(network *FluidNetwork) { network.Headers["G"] = FluidHeader{} }},
+			[]string{"unconnected node G"}},
+		{"dead-end header", []func(*FluidNetwork){func(network *FluidNetwork) { network.Headers["G"] = FluidHeader{} }, setPipe("P4", "Node", "A", "Header", "G")},
+			[]string{"dead-end header G"}},
+		{"duplicate ID", []func(*FluidNetwork){func(network *FluidNetwork) {
+			network.Pumps["P1"] = FluidPump{JunctionBase: network.Pipes["P1"].JunctionBase}
+		}},
+			[]string{"duplicate ID P1"}},
+		{"jet pump inlet", []func(*FluidNetwork){func(network *FluidNetwork) {
+			network.JetPumps["J"] = FluidJetPump{
+				JunctionBase:    FluidJunctionBase{SourceType: "Header", SourceID: "H", DestinationType: "Node", DestinationID: "B"},
+				DriveJunction:   "P3", // leads into node A, not the throat header
+				SuctionJunction: "P1",
+			}
+		}}, []string{"invalid jet pump inlet J"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var network *FluidNetwork = topologyTestNetwork()
+			for _, modify := range test.modify {
+				modify(network)
+			}
+			var got []string
+			for _, problem := range network.Validate() {
+				got = append(got, string(problem.Kind)+" "+problem.ID)
+			}
+			slices.Sort(got)
+			var want []string = slices.Clone(test.want)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("problems %q, want %q", got, want)
+			}
+		})
+	}
+}
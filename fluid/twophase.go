@@ -0,0 +1,208 @@
+package fluid
+
+import "math"
+
+// --- CONSTANT DECLARATIONS ---
+const CriticalTemperature float64 = if97CriticalT - 273.15 // °C, no liquid/vapour interface exists above it
+const CriticalPressure float64 = if97CriticalP * 1000000   // Pa
+const gravity float64 = 9.80665                            // m/s^2
+
+// ResolveStateUV finds the equilibrium state with the given specific internal energy (kJ/kg) and specific volume (m^3/kg).
+// This is the natural state pair of a sealed volume: mass and energy are conserved, and the volume is fixed, so the
+// state may lie anywhere inside or outside the saturation dome. At constant v internal energy rises monotonically with
+// pressure, so the pressure is found by a regula falsi search in ln(p).
+func ResolveStateUV(provider PropertyProvider, InternalEnergyKJKG float64, SpecificVolumeM3KG float64) WaterProperties {
+	var residual = func(logPressure float64) float64 {
+		return provider.Property(PV, math.Exp(logPressure)/1000000, SpecificVolumeM3KG, INTERNAL_ENERGY) - InternalEnergyKJKG
+	}
+	var low, high float64 = math.Log(611.657), math.Log(100000000) // Pa, triple point to the IF97 pressure limit
+	var lowValue, highValue float64 = residual(low), residual(high)
+	if (lowValue > 0) == (highValue > 0) { // out of range, clamp to the closest end
+		if math.Abs(lowValue) < math.Abs(highValue) {
+			return provider.Pv(math.Exp(low)/1000000, SpecificVolumeM3KG)
+		}
+		return provider.Pv(math.Exp(high)/1000000, SpecificVolumeM3KG)
+	}
+	var side int = 0
+	var logPressure float64 = low
+	for i := 0; i < 100; i += 1 { // Illinois variant of regula falsi, halves the weight of an end point that is kept twice
+		logPressure = (low*highValue - high*lowValue) / (highValue - lowValue)
+		var value float64 = residual(logPressure)
+		if math.Abs(high-low) < 1e-10 || value == 0 {
+			break
+		}
+		if (value > 0) == (highValue > 0) {
+			high, highValue = logPressure, value
+			if side == -1 {
+				lowValue /= 2
+			}
+			side = -1
+		} else {
+			low, lowValue = logPressure, value
+			if side == 1 {
+				highValue /= 2
+			}
+			side = 1
+		}
+		if math.Abs(value) < 1e-9*math.Max(1, math.Abs(InternalEnergyKJKG)) {
+			break
+		}
+	}
+	return provider.Pv(math.Exp(logPressure)/1000000, SpecificVolumeM3KG)
+}
+
+// IsTwoPhase reports whether the node holds a saturated mixture of liquid and vapour.
+func (node FluidNode) IsTwoPhase() bool {
+	return node.Quality > 0 && node.Quality < 1
+}
+
+// FreeVolume is the volume that incoming fluid can still occupy. In a closed node incoming liquid compresses the
+// vapour space, so only the liquid counts as occupied.
+func (node FluidNode) FreeVolume() float64 {
+	if node.Closed {
+		return node.MaxVolume - node.LiquidVolume
+	}
+	return node.MaxVolume - node.Volume
+}
+
+// OutflowProperties returns the state of the fluid leaving the node. Pipes are assumed to draw from below the water
+// line, so a two-phase node delivers saturated liquid while it has any and saturated vapour afterwards.
+func OutflowProperties(provider PropertyProvider, node FluidNode) WaterProperties {
+	if node.IsTwoPhase() {
+		if node.LiquidMass > 0 {
+			return provider.Px(node.Pressure/1000000, 0)
+		}
+		return provider.Px(node.Pressure/1000000, 1)
+	}
+	return provider.Ph(node.Pressure/1000000, node.Enthalpy/1000)
+}
+
+// ConnectionProperties returns the fluid drawn by a pipe connected to the node at the given elevation. Below the
+// swollen water level that is the liquid, see OutflowProperties, above it the vapour of the steam dome. A connection
+// above the water line of a node without any vapour is uncovered and cannot draw anything.
+func ConnectionProperties(provider PropertyProvider, node FluidNode, elevation float64) (stream WaterProperties, uncovered bool) {
+	var _, swollenLevel = node.WaterLevels()
+	if elevation < swollenLevel || node.LiquidMass <= 0 && node.VaporMass <= 0 {
+		return OutflowProperties(provider, node), false
+	}
+	if node.VaporMass <= 0 {
+		return OutflowProperties(provider, node), true
+	}
+	if node.IsTwoPhase() {
+		return provider.Px(node.Pressure/1000000, 1), false
+	}
+	return provider.Ph(node.Pressure/1000000, node.Enthalpy/1000), false
+}
+
+// CrossSection returns the horizontal area of the node in m^2, taking its walls as vertical between its bottom and top.
+func (node FluidNode) CrossSection() float64 {
+	return node.MaxVolume / (node.TopElevation - node.BottomElevation)
+}
+
+// WaterLevels returns the elevation of the collapsed water level, the height the liquid inventory alone would fill,
+// and of the swollen level, which also counts the steam bubbles held up in the boiling pool, in meters.
+func (node FluidNode) WaterLevels() (collapsedLevel float64, swollenLevel float64) {
+	var crossSection float64 = node.CrossSection()
+	var collapsedHeight float64 = node.LiquidVolume / crossSection
+	var poolVoidFraction float64 = swollenLevelVoidFraction(node, crossSection)
+	var swollenHeight float64 = min(collapsedHeight/(1-poolVoidFraction), node.TopElevation-node.BottomElevation)
+	return node.BottomElevation + collapsedHeight, node.BottomElevation + swollenHeight
+}
+
+// HydrostaticHead returns the weight of the liquid above the given elevation in the node, in Pa. The node pressure is
+// the pressure at its water surface, so the pressure at the elevation is the node pressure plus this head. Vapour
+// bubbles do not change the weight of the pool, so the collapsed level is used.
+func (node FluidNode) HydrostaticHead(elevation float64) float64 {
+	var collapsedLevel, _ = node.WaterLevels()
+	if elevation >= collapsedLevel || node.LiquidVolume <= 0 {
+		return 0
+	}
+	return node.LiquidMass / node.LiquidVolume * gravity * (collapsedLevel - elevation)
+}
+
+// applyState copies a resolved state into the node and splits its inventory into the liquid and vapour phases.
+func applyState(provider PropertyProvider, node FluidNode, state WaterProperties) FluidNode {
+	node.Pressure = state.Pressure * 1000000 // MPa to Pa
+	node.Temperature = state.Temperature
+	node.Enthalpy = state.Enthalpy * 1000
+	node.Entropy = state.Entropy * 1000
+	node.InternalEnergy = state.InternalEnergy * 1000
+	node.Quality = math.Min(math.Max(state.Quality, 0), 1)
+	node.SurfaceTension = 0
+
+	if node.IsTwoPhase() {
+		var liquidDensity float64 = provider.Property(PX, state.Pressure, 0, DENSITY)
+		var vaporDensity float64 = provider.Property(PX, state.Pressure, 1, DENSITY)
+		node.LiquidMass = node.Mass * (1 - node.Quality)
+		node.VaporMass = node.Mass * node.Quality
+		node.LiquidVolume = node.LiquidMass / liquidDensity
+		node.VaporVolume = node.VaporMass / vaporDensity
+		node.SurfaceTension = provider.Property(TX, node.Temperature, 0, SURFACE_TENSION) / 1000 // mN/m to N/m
+	} else if node.Quality == 0 {
+		node.LiquidMass, node.VaporMass = node.Mass, 0
+		node.LiquidVolume, node.VaporVolume = node.Mass/state.Density, 0
+	} else {
+		node.LiquidMass, node.VaporMass = 0, node.Mass
+		node.LiquidVolume, node.VaporVolume = 0, node.Mass/state.Density
+	}
+
+	if node.Closed {
+		node.Volume = node.MaxVolume // liquid and vapour together always fill a sealed vessel
+	} else {
+		node.Volume = node.LiquidVolume + node.VaporVolume
+	}
+	node.VoidFraction = 0
+	if node.LiquidVolume+node.VaporVolume > 0 {
+		node.VoidFraction = node.VaporVolume / (node.LiquidVolume + node.VaporVolume)
+	}
+	return node
+}
+
+// resolveNode recomputes the thermodynamic state of a node after its mass or energy changed. Closed nodes are resolved
+// from their internal energy and the specific volume of the vessel. Open nodes stay at the pressure of their boundary,
+// such as the atmosphere above a hotwell, and are resolved from it and their enthalpy. The node keeps the energy it
+// carries even where the properties clamp the state to their range, so no energy is lost to the lookup.
+func resolveNode(provider PropertyProvider, node FluidNode) FluidNode {
+	var specificEnergy float64 = nodeSpecificEnergy(node)
+	if node.Closed {
+		node = applyState(provider, node, ResolveStateUV(provider, node.InternalEnergy/1000, node.MaxVolume/node.Mass))
+	} else {
+		node = applyState(provider, node, provider.Ph(node.Pressure/1000000, node.Enthalpy/1000))
+	}
+	return setNodeSpecificEnergy(node, specificEnergy)
+}
+
+// initializeNode fills in the mass and energy of a node from its configured temperature, pressure and fluid volume.
+// A closed node that is not full starts saturated at its configured pressure: its liquid and the vapour in its
+// remaining space are both taken on the saturation line, so the temperature becomes the saturation temperature.
+func initializeNode(provider PropertyProvider, node FluidNode) FluidNode {
+	if !node.Closed || node.Volume >= node.MaxVolume || node.Temperature >= CriticalTemperature {
+		var fluid WaterProperties = provider.Pt(node.Pressure/1000000, node.Temperature)
+		node.Mass = CalculateMass(fluid.Density, node.Volume)
+		if !node.Closed {
+			return applyState(provider, node, fluid)
+		}
+		node.InternalEnergy = fluid.InternalEnergy * 1000
+		return resolveNode(provider, node)
+	}
+	var liquid, vapor WaterProperties = provider.Px(node.Pressure/1000000, 0), provider.Px(node.Pressure/1000000, 1)
+	var liquidMass, vaporMass float64 = CalculateMass(liquid.Density, node.Volume), CalculateMass(vapor.Density, node.MaxVolume-node.Volume)
+	node.Mass = liquidMass + vaporMass
+	node.InternalEnergy = (liquidMass*liquid.InternalEnergy + vaporMass*vapor.InternalEnergy) * 1000 / node.Mass
+	return resolveNode(provider, node)
+}
+
+// swollenLevelVoidFraction estimates the void fraction of the boiling pool below the water line from the rate at
+// which vapour is produced, using the Zuber-Findlay drift flux model for a pool without net liquid flow and the
+// churn-turbulent drift velocity.
+func swollenLevelVoidFraction(node FluidNode, flowArea float64) float64 {
+	if !node.IsTwoPhase() || node.VaporGenerationRate <= 0 || flowArea <= 0 {
+		return 0
+	}
+	var liquidDensity float64 = node.LiquidMass / node.LiquidVolume
+	var vaporDensity float64 = node.VaporMass / node.VaporVolume
+	var driftVelocity float64 = 1.41 * math.Pow(node.SurfaceTension*gravity*(liquidDensity-vaporDensity)/(liquidDensity*liquidDensity), 0.25)
+	var vaporFlux float64 = node.VaporGenerationRate / (vaporDensity * flowArea) // superficial vapour velocity in m/s
+	const distributionParameter float64 = 1.13
+	return vaporFlux / (distributionParameter*vaporFlux + driftVelocity)
+}
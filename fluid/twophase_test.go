@@ -0,0 +1,83 @@
+package fluid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResolveStateUV(t *testing.T) {
+	var tests = []struct {
+		name  string
+		state WaterProperties
+	}{
+		{"saturated mixture at 7 MPa", DefaultPropertyProvider().Px(7, 0.3)},
+		{"wet steam at 0.1 MPa", DefaultPropertyProvider().Px(0.1, 0.9)},
+		{"compressed liquid", DefaultPropertyProvider().Pt(7, 250)},
+		{"superheated steam", DefaultPropertyProvider().Pt(1, 300)},
+	}
+	for _, test := range tests {
+		var got WaterProperties = ResolveStateUV(DefaultPropertyProvider(), test.state.InternalEnergy, 1/test.state.Density)
+		if !closeTo(got.Pressure, test.state.Pressure, 1e-6) || math.Abs(got.Temperature-test.state.Temperature) > 1e-4 {
+			t.Errorf("%s: %v MPa and %v °C, want %v MPa and %v °C", test.name, got.Pressure, got.Temperature, test.state.Pressure, test.state.Temperature)
+		}
+		if math.Abs(got.Quality-test.state.Quality) > 1e-6 {
+			t.Errorf("%s: quality %v, want %v", test.name, got.Quality, test.state.Quality)
+		}
+	}
+}
+
+func TestClosedNodeSplitsItsPhases(t *testing.T) {
+	// 4 m³ of liquid at 7 MPa in a 10 m³ vessel of 1 m² fills the rest with saturated steam at the same pressure, and
+	// the configured temperature, half a kelvin off, gives way to the saturation temperature
+	var node FluidNode = initializeNode(DefaultPropertyProvider(), FluidNode{
+		Temperature: 285.3, Pressure: 7000000, Volume: 4, MaxVolume: 10, Closed: true, BottomElevation: 2, TopElevation: 12,
+	})
+	if !node.IsTwoPhase() {
+		t.Fatalf("quality %v, want a saturated mixture", node.Quality)
+	}
+	var saturationTemperature float64 = DefaultPropertyProvider().Px(7, 0).Temperature
+	if !closeTo(node.Pressure, 7000000, 1e-6) || math.Abs(node.Temperature-saturationTemperature) > 1e-3 {
+		t.Errorf("%v Pa at %v °C, want 7 MPa at the saturation temperature %v °C", node.Pressure, node.Temperature, saturationTemperature)
+	}
+	if math.Abs(node.LiquidMass+node.VaporMass-node.Mass) > 1e-9*node.Mass {
+		t.Errorf("liquid %v kg and vapour %v kg do not add up to %v kg", node.LiquidMass, node.VaporMass, node.Mass)
+	}
+	if math.Abs(node.LiquidVolume+node.VaporVolume-node.MaxVolume) > 1e-6*node.MaxVolume {
+		t.Errorf("liquid %v m³ and vapour %v m³ do not fill the %v m³ vessel", node.LiquidVolume, node.VaporVolume, node.MaxVolume)
+	}
+
+	// the levels follow the liquid inventory, not the total mass, and boiling swells the pool above the collapsed level
+	var collapsed, swollen = node.WaterLevels()
+	if math.Abs(collapsed-(node.BottomElevation+node.LiquidVolume)) > 1e-9 || math.Abs(collapsed-6) > 0.05 {
+		t.Errorf("collapsed level at %v m, want about 6 m from the liquid volume %v m³", collapsed, node.LiquidVolume)
+	}
+	if swollen != collapsed {
+		t.Errorf("swollen level at %v m without boiling, want the collapsed level %v m", swollen, collapsed)
+	}
+	node.VaporGenerationRate = 50
+	if _, boiling := node.WaterLevels(); boiling <= collapsed || boiling > node.TopElevation {
+		t.Errorf("swollen level at %v m while boiling, want between %v and %v m", boiling, collapsed, node.TopElevation)
+	}
+
+	// resolving the node from its own mass and energy leaves it where it is
+	var resolved FluidNode = resolveNode(DefaultPropertyProvider(), node)
+	if !closeTo(resolved.Pressure, node.Pressure, 1e-6) || math.Abs(resolved.Quality-node.Quality) > 1e-9 {
+		t.Errorf("resolved to %v Pa at quality %v, want %v Pa at %v", resolved.Pressure, resolved.Quality, node.Pressure, node.Quality)
+	}
+}
+
+func TestOpenNodeStaysAtItsPressure(t *testing.T) {
+	// a hotwell under the atmosphere warms up and boils off at 101325 Pa however much heat it takes
+	var node FluidNode = initializeNode(DefaultPropertyProvider(), FluidNode{
+		Temperature: 20, Pressure: 101325, Volume: 10, MaxVolume: 20, BottomElevation: 0, TopElevation: 2,
+	})
+	var boiling float64 = DefaultPropertyProvider().Px(0.101325, 0).Enthalpy * 1000
+	for _, enthalpy := range []float64{200000, boiling, boiling + 500000} {
+		node = resolveNode(DefaultPropertyProvider(), setNodeSpecificEnergy(node, enthalpy))
+		var want WaterProperties = DefaultPropertyProvider().Ph(0.101325, enthalpy/1000)
+		if node.Pressure != 101325 || math.Abs(node.Temperature-want.Temperature) > 1e-9 || math.Abs(node.Quality-want.Quality) > 1e-9 {
+			t.Errorf("at %v J/kg: %v Pa, %v °C, quality %v, want 101325 Pa, %v °C, quality %v",
+				enthalpy, node.Pressure, node.Temperature, node.Quality, want.Temperature, want.Quality)
+		}
+	}
+}
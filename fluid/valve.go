@@ -0,0 +1,168 @@
+package fluid
+
+import (
+	"fmt"
+	"math"
+)
+
+// --- STRUCT DECLARATIONS ---
+type ValveType string
+
+const (
+	GateValve      ValveType = "Gate"      // quick opening, most of the flow passes in the first part of the stroke
+	GlobeValve     ValveType = "Globe"     // equal percentage, for throttling service such as feedwater regulation
+	ButterflyValve ValveType = "Butterfly" // a disc turning through a quarter turn
+	CheckValve     ValveType = "Check"     // opens with forward flow and shuts against reverse flow, has no actuator
+)
+
+type ValveFailPosition string
+
+const (
+	FailAsIs   ValveFailPosition = "AsIs"   // the valve stays where it is when its actuator loses power
+	FailOpen   ValveFailPosition = "Open"   // the valve strokes fully open when its actuator loses power
+	FailClosed ValveFailPosition = "Closed" // the valve strokes shut when its actuator loses power, like an MSIV
+)
+
+// FluidValve is a valve that can be chained into a flow path like a pipe. Its loss coefficient depends on its
+// position, which an actuator strokes towards the commanded position at a fixed rate.
+type FluidValve struct {
+	JunctionBase    FluidJunctionBase // the base junction info
+	Type            ValveType
+	Diameter        float64 // nominal diameter of the valve in milimeters
+	Length          float64 // length of the flow passage through the valve in meters
+	OpenKFactor     float64 // loss coefficient of the fully open valve
+	InletElevation  float64 // elevation in meters where the valve leaves its source
+	OutletElevation float64 // elevation in meters where the valve enters its destination
+
+	StrokeTime   float64           // seconds the actuator takes for a full stroke
+	FailPosition ValveFailPosition // where the valve goes when its actuator loses power
+
+	Position          float64 // 0 closed to 1 fully open
+	CommandedPosition float64 // 0 to 1, where the actuator drives the valve
+	Failed            bool    // the actuator has lost power or air and the valve moves to its fail position
+	MassFlowRate      float64 // kg/s, positive from source to destination
+}
+
+// --- CONSTANT DECLARATIONS ---
+const valveLeakage float64 = 0.00001 // fraction of the open flow coefficient a shut valve still passes
+const valveRangeability float64 = 50 // ratio of the largest to the smallest controllable flow of an equal percentage trim
+var defaultValveOpenK map[ValveType]float64 = map[ValveType]float64{
+	GateValve:      0.15,
+	GlobeValve:     4,
+	ButterflyValve: 0.5,
+	CheckValve:     2,
+}
+
+// ValveFlowFraction returns the flow coefficient (Cv) of a valve of the given type at the given position, as a fraction
+// of its fully open value. The loss coefficient rises with the inverse square of it.
+func ValveFlowFraction(valveType ValveType, position float64) float64 {
+	position = math.Min(math.Max(position, 0), 1)
+	var fraction float64
+	switch valveType {
+	case GateValve:
+		fraction = 1 - (1-position)*(1-position)
+	case GlobeValve:
+		fraction = (math.Pow(valveRangeability, position) - 1) / (valveRangeability - 1)
+	case ButterflyValve:
+		fraction = 1 - math.Cos(position*math.Pi/2)
+	default:
+		fraction = position
+	}
+	return math.Max(fraction, valveLeakage)
+}
+
+func (valve FluidValve) base() FluidJunctionBase  { return valve.JunctionBase }
+func (valve FluidValve) kind() string             { return "Valve" }
+func (valve FluidValve) inletElevation() float64  { return valve.InletElevation }
+func (valve FluidValve) outletElevation() float64 { return valve.OutletElevation }
+func (valve FluidValve) massFlowRate() float64    { return valve.MassFlowRate }
+func (valve FluidValve) inertia() float64 {
+	return valve.Length / pipeArea(valve.Diameter)
+}
+
+// pressureDrop returns the form loss of the valve at its current position. A check valve is open to forward flow and
+// shut to reverse flow, so its loss coefficient jumps at zero flow while the pressure drop itself stays continuous.
+func (valve FluidValve) pressureDrop(massFlowRate float64, stream WaterProperties) float64 {
+	var position float64 = valve.Position
+	if valve.Type == CheckValve {
+		position = 1
+		if massFlowRate < 0 {
+			position = 0
+		}
+	}
+	var fraction float64 = ValveFlowFraction(valve.Type, position)
+	var area float64 = pipeArea(valve.Diameter)
+	return valve.OpenKFactor / (fraction * fraction) * massFlowRate * math.Abs(massFlowRate) / (2 * stream.Density * area * area)
+}
+
+// stroke moves the valve towards its target position at its stroke rate. A failed actuator drives the valve to its
+// fail position instead, or leaves it where it is.
+func (valve FluidValve) stroke(deltaTimeSeconds float64) FluidValve {
+	if valve.Type == CheckValve {
+		return valve
+	}
+	var target float64 = valve.CommandedPosition
+	if valve.Failed {
+		switch valve.FailPosition {
+		case FailOpen:
+			target = 1
+		case FailClosed:
+			target = 0
+		default:
+			return valve
+		}
+	}
+	if valve.StrokeTime <= 0 {
+		valve.Position = target
+		return valve
+	}
+	var travel float64 = deltaTimeSeconds / valve.StrokeTime
+	valve.Position += math.Min(math.Max(target-valve.Position, -travel), travel)
+	return valve
+}
+
+// strokeValves moves every valve over one time step, before the flows of the step are solved.
+func (network *FluidNetwork) strokeValves(deltaTimeSeconds float64) {
+	for valveId, valve := range network.Valves {
+		network.Valves[valveId] = valve.stroke(deltaTimeSeconds)
+	}
+}
+
+// SetValvePosition commands the actuator of a valve to the given position, 0 closed to 1 fully open.
+func (network *FluidNetwork) SetValvePosition(valveId string, position float64) error {
+	var valve, ok = network.Valves[valveId]
+	if !ok {
+		return fmt.Errorf("valve %q does not exist", valveId)
+	}
+	if valve.Type == CheckValve {
+		return fmt.Errorf("valve %q: a check valve has no actuator", valveId)
+	}
+	if position < 0 || position > 1 {
+		return fmt.Errorf("valve %q: position must be between 0 and 1, got %g", valveId, position)
+	}
+	valve.CommandedPosition = position
+	network.Valves[valveId] = valve
+	return nil
+}
+
+// FailValve takes the power or air from the actuator of a valve, which then moves to its fail position.
+func (network *FluidNetwork) FailValve(valveId string) error {
+	var valve, ok = network.Valves[valveId]
+	if !ok {
+		return fmt.Errorf("valve %q does not exist", valveId)
+	}
+	valve.Failed = true
+	network.Valves[valveId] = valve
+	return nil
+}
+
+// RestoreValve restores the power or air of the actuator of a valve, which then returns to its commanded position.
+func (network *FluidNetwork) RestoreValve(valveId string) error {
+	var valve, ok = network.Valves[valveId]
+	if !ok {
+		return fmt.Errorf("valve %q does not exist", valveId)
+	}
+	valve.Failed = false
+	network.Valves[valveId] = valve
+	return nil
+}
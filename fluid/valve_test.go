@@ -0,0 +1,184 @@
+package fluid
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// drainModel is an upper tank draining into a lower one through a valve that is replaced in the tests.
+const drainModel string = `{
+	"version": 2,
+	"nodes": {
+		"Upper": {"temperature": 30, "pressure": 101325, "volume": 50, "maxVolume": 100, "bottomElevation": 10, "topElevation": 15},
+		"Lower": {"temperature": 30, "pressure": 101325, "volume": 10, "maxVolume": 100, "bottomElevation": 0, "topElevation": 5}
+	},
+	"valves": {
+		"Drain": {"sourceType": "Node", "sourceId": "Upper", "destinationType": "Node", "destinationId": "Lower",
+			"type": "Gate", "diameter": 100, "strokeTime": 10, "inletElevation": 10, "outletElevation": 0}
+	}
+}`
+
+func TestValveFlowFraction(t *testing.T) {
+	var tests = []struct {
+		valveType ValveType
+		position  float64
+		want      float64
+	}{
+		{GateValve, 1, 1},
+		{GateValve, 0.5, 0.75},
+		{GlobeValve, 1, 1},
+		{GlobeValve, 0.5, (math.Sqrt(valveRangeability) - 1) / (valveRangeability - 1)},
+		{ButterflyValve, 1, 1},
+		{ButterflyValve, 0.5, 1 - math.Sqrt(0.5)},
+		{CheckValve, 1, 1},
+		{CheckValve, 0.5, 0.5},
+		{GateValve, 2, 1}, // positions outside 0 to 1 are clamped
+	}
+	for _, test := range tests {
+		if got := ValveFlowFraction(test.valveType, test.position); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s valve at %v: flow fraction = %v, want %v", test.valveType, test.position, got, test.want)
+		}
+	}
+
+	// a shut valve of any type still leaks, so its loss coefficient stays finite
+	for _, valveType := range []ValveType{GateValve, GlobeValve, ButterflyValve, CheckValve} {
+		for _, position := range []float64{0, -1} {
+			if got := ValveFlowFraction(valveType, position); got != valveLeakage {
+				t.Errorf("%s valve at %v: flow fraction = %v, want the leakage %v", valveType, position, got, valveLeakage)
+			}
+		}
+	}
+}
+
+func TestValveStroke(t *testing.T) {
+	var network FluidNetwork = FluidNetwork{Valves: map[string]FluidValve{
+		"Valve": {Type: GateValve, StrokeTime: 10, FailPosition: FailClosed, Position: 1, CommandedPosition: 1},
+	}}
+	if err := network.SetValvePosition("Valve", 0.2); err != nil {
+		t.Fatal(err)
+	}
+	// a full stroke takes 10 s, so the 0.8 of travel takes 8 s and the valve then stays at its target
+	var want = []struct {
+		seconds  int
+		position float64
+	}{{2, 0.8}, {4, 0.6}, {8, 0.2}, {12, 0.2}}
+	var elapsed int
+	for _, point := range want {
+		for ; elapsed < point.seconds; elapsed += 1 {
+			network.strokeValves(1)
+		}
+		if got := network.Valves["Valve"].Position; math.Abs(got-point.position) > 1e-12 {
+			t.Errorf("position %d s after the command = %v, want %v", point.seconds, got, point.position)
+		}
+	}
+
+	// a failed actuator drives the valve shut and ignores commands until its power is restored
+	if err := network.FailValve("Valve"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i += 1 {
+		network.strokeValves(1)
+	}
+	if got := network.Valves["Valve"].Position; got != 0 {
+		t.Errorf("position 5 s after the failure = %v, want the fail position 0", got)
+	}
+	if err := network.SetValvePosition("Valve", 1); err != nil {
+		t.Fatal(err)
+	}
+	network.strokeValves(1)
+	if got := network.Valves["Valve"].Position; got != 0 {
+		t.Errorf("failed valve moved to %v after a command, want it to stay at 0", got)
+	}
+	if err := network.RestoreValve("Valve"); err != nil {
+		t.Fatal(err)
+	}
+	network.strokeValves(5)
+	if got := network.Valves["Valve"].Position; math.Abs(got-0.5) > 1e-12 {
+		t.Errorf("position 5 s after the restore = %v, want 0.5 on the way to the commanded 1", got)
+	}
+
+	// without a stroke time the valve jumps, and an as-is valve stays where it failed
+	network.Valves["Valve"] = FluidValve{Type: GlobeValve, FailPosition: FailAsIs, Position: 0.3, CommandedPosition: 0.3}
+	if err := network.SetValvePosition("Valve", 0.9); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.FailValve("Valve"); err != nil {
+		t.Fatal(err)
+	}
+	network.strokeValves(1)
+	if got := network.Valves["Valve"].Position; got != 0.3 {
+		t.Errorf("failed as-is valve moved to %v, want 0.3", got)
+	}
+	if err := network.RestoreValve("Valve"); err != nil {
+		t.Fatal(err)
+	}
+	network.strokeValves(0.001)
+	if got := network.Valves["Valve"].Position; got != 0.9 {
+		t.Errorf("valve without a stroke time at %v, want 0.9", got)
+	}
+}
+
+func TestValveCommandErrors(t *testing.T) {
+	var network FluidNetwork = FluidNetwork{Valves: map[string]FluidValve{
+		"Valve": {Type: GateValve, StrokeTime: 10},
+		"Check": {Type: CheckValve},
+	}}
+	var tests = []struct {
+		name    string
+		err     error
+		message string
+	}{
+		{"missing valve", network.SetValvePosition("Missing", 0.5), `valve "Missing" does not exist`},
+		{"position above 1", network.SetValvePosition("Valve", 1.5), `valve "Valve": position must be between 0 and 1, got 1.5`},
+		{"check valve", network.SetValvePosition("Check", 0.5), `valve "Check": a check valve has no actuator`},
+		{"fail missing valve", network.FailValve("Missing"), `valve "Missing" does not exist`},
+		{"restore missing valve", network.RestoreValve("Missing"), `valve "Missing" does not exist`},
+	}
+	for _, test := range tests {
+		if test.err == nil || !strings.Contains(test.err.Error(), test.message) {
+			t.Errorf("%s: error %v, want %q", test.name, test.err, test.message)
+		}
+	}
+}
+
+func TestCheckValveBlocksReverseFlow(t *testing.T) {
+	// simulateJunctions opens a check valve to forward flow and shuts it against reverse flow
+	var stream WaterProperties = WaterProperties{Density: 1000}
+	var flowPath FlowPath = FlowPath{JunctionIDs: []string{"Check"}}
+	var network FluidNetwork = FluidNetwork{Valves: map[string]FluidValve{"Check": {Type: CheckValve, MassFlowRate: 5}}}
+	network.simulateJunctions(flowPath, stream, 0.1)
+	if got := network.Valves["Check"].Position; got != 1 {
+		t.Errorf("check valve with forward flow at %v, want open", got)
+	}
+	var valve FluidValve = network.Valves["Check"]
+	valve.MassFlowRate = -5
+	network.Valves["Check"] = valve
+	network.simulateJunctions(flowPath, stream, 0.1)
+	if got := network.Valves["Check"].Position; got != 0 {
+		t.Errorf("check valve with reverse flow at %v, want shut", got)
+	}
+
+	// draining the upper tank through a gate valve flows freely, through a check valve pointing upwards it only leaks
+	var drainFlow = func(model string) float64 {
+		var network *FluidNetwork = newTestNetwork(t, model)
+		for i := 0; i < 50; i += 1 {
+			network.SimulateFlow(100 * time.Millisecond)
+		}
+		return network.Valves["Drain"].MassFlowRate
+	}
+	var open float64 = drainFlow(drainModel)
+	var check float64 = drainFlow(strings.NewReplacer(
+		`"sourceId": "Upper"`, `"sourceId": "Lower"`,
+		`"destinationId": "Lower"`, `"destinationId": "Upper"`,
+		`"type": "Gate", "diameter": 100, "strokeTime": 10, "inletElevation": 10, "outletElevation": 0`,
+		`"type": "Check", "diameter": 100, "inletElevation": 0, "outletElevation": 10`,
+	).Replace(drainModel))
+	if open < 10 {
+		t.Fatalf("flow through the open gate valve = %v kg/s, want a free drain", open)
+	}
+	if check > 0 || -check > 0.001*open {
+		t.Errorf("reverse flow through the check valve = %v kg/s, want at most a leak of the %v kg/s forward", check, open)
+	}
+}
@@ -0,0 +1,8 @@
+// Package instrumentation shows the core the way a control panel does.
+//
+// It has four source range monitors in counts/s with counting noise, which saturate at 10⁶ counts/s, and eight
+// intermediate range monitors on a 0 to 125 scale over ten half-decade ranges, switched with
+// [Instruments.SetIRMRange]. It also has six average power range monitors in percent of rated power and a period
+// meter. Each monitor flags upscale and downscale readings. The ranges overlap, so the flux is on scale of one of them
+// from the shut down core to full power.
+package instrumentation
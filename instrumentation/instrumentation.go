@@ -0,0 +1,159 @@
+package instrumentation
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// --- STRUCT DECLARATIONS ---
+
+// SourceRangeMonitor is a fission chamber that counts neutron pulses. It covers the shut down core and the start of
+// the approach to criticality, and saturates long before the core makes measurable heat.
+type SourceRangeMonitor struct {
+	CountRate float64 // counts/s
+	Upscale   bool    // the detector is saturated, its reading no longer follows the flux
+	Downscale bool    // too few counts to monitor the core
+}
+
+// IntermediateRangeMonitor measures the flux on ten ranges of half a decade each, read on a 0 to 125 scale. The
+// operator switches ranges as the power rises, before the reading pegs upscale.
+type IntermediateRangeMonitor struct {
+	Range     int     // 1 to 10, each range covers √10 times the flux of the one below
+	Reading   float64 // 0 to 125 of the selected range
+	Upscale   bool    // time to switch up a range
+	Downscale bool    // time to switch down a range
+}
+
+// AveragePowerRangeMonitor averages the local power range monitors of the core and reads in percent of rated power.
+type AveragePowerRangeMonitor struct {
+	Power     float64 // % of rated
+	Upscale   bool
+	Downscale bool // below the power range, the intermediate range monitors should be read
+}
+
+// Instruments is the nuclear instrumentation of the core: source range, intermediate range and average power range
+// monitors, whose ranges overlap by a decade or more so the flux is on scale of one of them from the shut down core to
+// full power, and the period meter.
+type Instruments struct {
+	SRMs   map[string]SourceRangeMonitor
+	IRMs   map[string]IntermediateRangeMonitor
+	APRMs  map[string]AveragePowerRangeMonitor
+	Period float64 // s, as shown by the period meter, +Inf when the flux is steady
+
+	inversePeriod float64 // 1/s, the filtered inverse period behind the period meter
+	random        *rand.Rand
+}
+
+// --- CONSTANT DECLARATIONS ---
+var srmNames []string = []string{"A", "B", "C", "D"}
+var irmNames []string = []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+var aprmNames []string = []string{"A", "B", "C", "D", "E", "F"}
+
+const srmSensitivity float64 = 1e10       // counts/s at rated neutron density, about 100 counts/s in the shut down core
+const srmCountingTime float64 = 1         // s the count rate is averaged over, sets the counting noise
+const srmSaturation float64 = 1e6         // counts/s above which the pulses pile up
+const srmDownscale float64 = 3            // counts/s
+const irmRanges int = 10                  // number of ranges of the intermediate range monitors
+const irmTopFullScale float64 = 0.4       // neutron density at full scale of range 10
+const irmFullScale float64 = 125          // divisions
+const irmUpscale float64 = 108            // divisions
+const irmDownscale float64 = 5            // divisions
+const irmNoise float64 = 0.01             // relative standard deviation of the reading
+const aprmFullScale float64 = 125         // %
+const aprmUpscale float64 = 118           // %
+const aprmDownscale float64 = 5           // %
+const aprmNoise float64 = 0.01            // relative standard deviation of the reading, mostly from boiling in the bundles
+const periodMeterTimeConstant float64 = 3 // s the period meter filters the inverse period over
+
+// New returns the instruments of a shut down core with every intermediate range monitor on range 1. The detector noise
+// is drawn from a generator seeded with seed, so runs with the same seed read the same.
+func New(seed uint64) *Instruments {
+	var instruments *Instruments = &Instruments{
+		SRMs:   make(map[string]SourceRangeMonitor),
+		IRMs:   make(map[string]IntermediateRangeMonitor),
+		APRMs:  make(map[string]AveragePowerRangeMonitor),
+		Period: math.Inf(1),
+		random: rand.New(rand.NewPCG(seed, seed)),
+	}
+	for _, name := range srmNames {
+		instruments.SRMs[name] = SourceRangeMonitor{}
+	}
+	for _, name := range irmNames {
+		instruments.IRMs[name] = IntermediateRangeMonitor{Range: 1}
+	}
+	for _, name := range aprmNames {
+		instruments.APRMs[name] = AveragePowerRangeMonitor{}
+	}
+	return instruments
+}
+
+// rangeFullScale returns the neutron density at full scale of an intermediate range.
+func rangeFullScale(irmRange int) float64 {
+	return irmTopFullScale / math.Pow(10, float64(irmRanges-irmRange)/2)
+}
+
+// Simulate updates every reading from the neutron density relative to rated and the reactor period in s.
+func (instruments *Instruments) Simulate(neutronDensity float64, period float64, deltaTime time.Duration) {
+	var countRate float64 = srmSensitivity * neutronDensity
+	for _, name := range srmNames {
+		var srm SourceRangeMonitor = instruments.SRMs[name]
+		// counting statistics: the number of counts in the counting time is Poisson distributed
+		srm.CountRate = math.Max(countRate+math.Sqrt(countRate/srmCountingTime)*instruments.random.NormFloat64(), 0)
+		srm.CountRate = math.Min(srm.CountRate, srmSaturation)
+		srm.Upscale, srm.Downscale = srm.CountRate >= srmSaturation, srm.CountRate < srmDownscale
+		instruments.SRMs[name] = srm
+	}
+	for _, name := range irmNames {
+		var irm IntermediateRangeMonitor = instruments.IRMs[name]
+		irm.Reading = irmFullScale * neutronDensity / rangeFullScale(irm.Range) * (1 + irmNoise*instruments.random.NormFloat64())
+		irm.Reading = math.Min(math.Max(irm.Reading, 0), irmFullScale)
+		irm.Upscale, irm.Downscale = irm.Reading >= irmUpscale, irm.Reading < irmDownscale
+		instruments.IRMs[name] = irm
+	}
+	for _, name := range aprmNames {
+		var aprm AveragePowerRangeMonitor = instruments.APRMs[name]
+		aprm.Power = 100 * neutronDensity * (1 + aprmNoise*instruments.random.NormFloat64())
+		aprm.Power = math.Min(math.Max(aprm.Power, 0), aprmFullScale)
+		aprm.Upscale, aprm.Downscale = aprm.Power >= aprmUpscale, aprm.Power < aprmDownscale
+		instruments.APRMs[name] = aprm
+	}
+
+	// The period meter differentiates the logarithm of the flux over its time constant, so its reading wanders by the
+	// relative counting noise over that time; the noise fades as the count rate grows. The filter and its noise are
+	// advanced exactly, so the meter reads the same at any time step.
+	var decay float64 = math.Exp(-deltaTime.Seconds() / periodMeterTimeConstant)
+	instruments.inversePeriod = instruments.inversePeriod*decay + (1-decay)/period
+	if countRate > 0 {
+		var spread float64 = 1 / math.Sqrt(countRate*periodMeterTimeConstant) / periodMeterTimeConstant
+		instruments.inversePeriod += spread * math.Sqrt(1-decay*decay) * instruments.random.NormFloat64()
+	}
+	instruments.Period = 1 / instruments.inversePeriod
+}
+
+// SetIRMRange switches an intermediate range monitor to the given range.
+func (instruments *Instruments) SetIRMRange(name string, irmRange int) error {
+	var irm, ok = instruments.IRMs[name]
+	if !ok {
+		return fmt.Errorf("intermediate range monitor %q does not exist", name)
+	}
+	if irmRange < 1 || irmRange > irmRanges {
+		return fmt.Errorf("intermediate range monitor %q: range %d is not between 1 and %d", name, irmRange, irmRanges)
+	}
+	irm.Range = irmRange
+	instruments.IRMs[name] = irm
+	return nil
+}
+
+// HighestAPRM returns the highest reading of the given average power range monitors, or of all of them when none are
+// given, as a fraction of rated power: the neutron flux a channel of the reactor protection system trips on.
+func (instruments *Instruments) HighestAPRM(names ...string) (highest float64) {
+	if len(names) == 0 {
+		names = aprmNames
+	}
+	for _, name := range names {
+		highest = math.Max(highest, instruments.APRMs[name].Power/100)
+	}
+	return
+}
@@ -0,0 +1,158 @@
+package instrumentation
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const step time.Duration = 100 * time.Millisecond
+
+// onScale reports whether any monitor of the instruments reads the flux on scale.
+func onScale(instruments *Instruments) bool {
+	for _, srm := range instruments.SRMs {
+		if !srm.Upscale && !srm.Downscale {
+			return true
+		}
+	}
+	for _, irm := range instruments.IRMs {
+		if !irm.Upscale && !irm.Downscale {
+			return true
+		}
+	}
+	for _, aprm := range instruments.APRMs {
+		if !aprm.Upscale && !aprm.Downscale {
+			return true
+		}
+	}
+	return false
+}
+
+// TestRangeOverlap raises the flux from the shut down core to full power in small steps, switching the IRMs up a
+// range whenever they read upscale as an operator would, and checks that some monitor is always on scale.
+func TestRangeOverlap(t *testing.T) {
+	var instruments *Instruments = New(1)
+	for neutronDensity := 1e-9; neutronDensity <= 1; neutronDensity *= 1.1 {
+		instruments.Simulate(neutronDensity, math.Inf(1), step)
+		for name, irm := range instruments.IRMs {
+			if irm.Upscale && irm.Range < irmRanges {
+				if err := instruments.SetIRMRange(name, irm.Range+1); err != nil {
+					t.Fatal(err)
+				}
+				instruments.Simulate(neutronDensity, math.Inf(1), step)
+			}
+		}
+		if !onScale(instruments) {
+			t.Fatalf("no monitor on scale at neutron density %g", neutronDensity)
+		}
+	}
+}
+
+func TestIRMRangeSwitch(t *testing.T) {
+	var tests = []struct {
+		name           string
+		neutronDensity float64
+		irmRange       int
+		upscale        bool
+		downscale      bool
+	}{
+		{"mid scale", 0.5 * rangeFullScale(4), 4, false, false},
+		{"upscale", 0.95 * rangeFullScale(4), 4, true, false},
+		{"downscale", 0.01 * rangeFullScale(4), 4, false, true},
+		{"switched up from upscale", 0.95 * rangeFullScale(4), 5, false, false},
+		{"switched down from downscale", 0.01 * rangeFullScale(4), 2, false, false},
+		{"pegged above full scale", 10 * rangeFullScale(4), 4, true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var instruments *Instruments = New(1)
+			if err := instruments.SetIRMRange("A", test.irmRange); err != nil {
+				t.Fatal(err)
+			}
+			instruments.Simulate(test.neutronDensity, math.Inf(1), step)
+			var irm IntermediateRangeMonitor = instruments.IRMs["A"]
+			if irm.Upscale != test.upscale || irm.Downscale != test.downscale {
+				t.Errorf("reading %.1f on range %d: upscale %v, downscale %v; want %v, %v",
+					irm.Reading, irm.Range, irm.Upscale, irm.Downscale, test.upscale, test.downscale)
+			}
+			if irm.Reading < 0 || irm.Reading > irmFullScale {
+				t.Errorf("reading %.1f is off the 0 to %g scale", irm.Reading, irmFullScale)
+			}
+		})
+	}
+
+	var instruments *Instruments = New(1)
+	for _, irmRange := range []int{0, 11} {
+		if err := instruments.SetIRMRange("A", irmRange); err == nil {
+			t.Errorf("range %d accepted", irmRange)
+		}
+	}
+	if err := instruments.SetIRMRange("Z", 1); err == nil {
+		t.Error("a monitor that does not exist accepted a range")
+	}
+}
+
+func TestPeriodMeter(t *testing.T) {
+	var tests = []struct {
+		name      string
+		period    float64
+		tolerance float64 // 1/s the inverse of the reading may be off the inverse period
+	}{
+		{"steady flux", math.Inf(1), 0.001},
+		{"rising flux", 60, 0.001},
+		{"fast rising flux", 10, 0.005},
+		{"falling flux", -80, 0.001},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var instruments *Instruments = New(1)
+			for i := 0; i < 300; i += 1 { // 30 s, ten time constants of the meter
+				instruments.Simulate(0.5, test.period, step)
+			}
+			if math.Abs(1/instruments.Period-1/test.period) > test.tolerance {
+				t.Errorf("period meter reads %.1f s, want %v s", instruments.Period, test.period)
+			}
+		})
+	}
+}
+
+func TestPeriodMeterIndependentOfTimeStep(t *testing.T) {
+	var coarse, fine *Instruments = New(1), New(1)
+	for i := 0; i < 10; i += 1 {
+		coarse.Simulate(0.5, 30, time.Second)
+	}
+	for i := 0; i < 1000; i += 1 {
+		fine.Simulate(0.5, 30, 10*time.Millisecond)
+	}
+	if math.Abs(coarse.Period-fine.Period) > 0.05*30 {
+		t.Errorf("period meter reads %.2f s at 1 s steps and %.2f s at 10 ms steps", coarse.Period, fine.Period)
+	}
+}
+
+func TestDeterministicNoise(t *testing.T) {
+	var first, second *Instruments = New(7), New(7)
+	for i := 0; i < 10; i += 1 {
+		first.Simulate(1e-7, math.Inf(1), step)
+		second.Simulate(1e-7, math.Inf(1), step)
+	}
+	if first.SRMs["A"].CountRate != second.SRMs["A"].CountRate || first.Period != second.Period {
+		t.Error("instruments with the same seed read differently")
+	}
+	var third *Instruments = New(8)
+	for i := 0; i < 10; i += 1 {
+		third.Simulate(1e-7, math.Inf(1), step)
+	}
+	if first.SRMs["A"].CountRate == third.SRMs["A"].CountRate {
+		t.Error("instruments with different seeds read the same")
+	}
+}
+
+func TestSRMSaturation(t *testing.T) {
+	var instruments *Instruments = New(1)
+	instruments.Simulate(1e-3, math.Inf(1), step)
+	for name, srm := range instruments.SRMs {
+		if !srm.Upscale || srm.CountRate != srmSaturation {
+			t.Errorf("SRM %s reads %g counts/s at 1e-3 of rated, want saturated at %g", name, srm.CountRate, srmSaturation)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Package protection models the reactor protection system, which scrams the reactor when a plant signal stays beyond
+// its setpoint.
+//
+// The system watches the APRMs and the vessel pressure. When the protection section of a plant model provides their
+// setpoints or sensors, it also watches the vessel water level, the closure of the MSIVs and turbine stop valves and
+// the drywell pressure. Each of its four channels trips once a signal has stayed beyond its setpoint for the trip
+// delay, and the reactor scrams when a channel in each of the two trip systems has tripped (one-out-of-two taken
+// twice).
+//
+// The scram latches with its first-out cause. [ProtectionSystem.Reset] is refused while a trip is still present.
+package protection
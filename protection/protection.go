@@ -0,0 +1,229 @@
+package protection
+
+import (
+	"fmt"
+	"time"
+)
+
+// --- STRUCT DECLARATIONS ---
+type TripParameter string
+
+const (
+	HighNeutronFlux     TripParameter = "HighNeutronFlux"     // APRM flux above its setpoint
+	HighVesselPressure  TripParameter = "HighVesselPressure"  // reactor vessel pressure above its setpoint
+	LowWaterLevel       TripParameter = "LowWaterLevel"       // vessel water level below its setpoint (level 3)
+	HighWaterLevel      TripParameter = "HighWaterLevel"      // vessel water level above its setpoint (level 8)
+	MSIVClosure         TripParameter = "MSIVClosure"         // a main steam isolation valve closed below its setpoint
+	HighDrywellPressure TripParameter = "HighDrywellPressure" // drywell pressure above its setpoint, a sign of a leak
+	TurbineTrip         TripParameter = "TurbineTrip"         // a turbine stop valve closed below its setpoint
+	ManualScram         TripParameter = "ManualScram"         // the operator pushed the scram buttons
+)
+
+// Signals are the plant conditions the protection system monitors. The neutron flux is measured separately for every
+// channel by the APRMs assigned to it; a channel without a reading of its own sees no flux.
+type Signals struct {
+	NeutronFlux              map[string]float64 // highest reading of the APRMs of each channel as a fraction of rated power
+	VesselPressure           float64            // Pa
+	WaterLevel               float64            // m above the bottom of the vessel
+	MSIVPosition             float64            // of the least open MSIV, 0 closed to 1 open
+	DrywellPressure          float64            // Pa
+	TurbineStopValvePosition float64            // of the least open stop valve, 0 closed to 1 open
+}
+
+// Trip is one trip function: its parameter trips a channel once the signal has been beyond the setpoint for longer
+// than the delay.
+type Trip struct {
+	Parameter TripParameter
+	Setpoint  float64 // in the unit of the signal
+	High      bool    // trip above the setpoint, below it otherwise
+	Delay     float64 // s the signal must stay beyond the setpoint before the channel trips
+	Enabled   bool    // the plant has the sensors of this trip
+}
+
+// Channel is one of the four logic channels. Every channel monitors every trip function with its own timers.
+type Channel struct {
+	Bypassed bool                      // taken out of service, it cannot trip
+	Timers   map[TripParameter]float64 // s each signal has been beyond its setpoint
+	Trips    []TripParameter           // the trips of the channel during the last step
+}
+
+// ScramEvent records one scram of the protection system.
+type ScramEvent struct {
+	Time     time.Duration // simulation time of the scram
+	FirstOut TripParameter // the trip that completed the scram logic
+}
+
+// ProtectionSystem is the reactor protection system. Its four channels A1, A2, B1 and B2 form two trip systems: a trip
+// system trips when either of its channels trips, and the reactor scrams when both trip systems have tripped
+// (one-out-of-two taken twice), so a single failed channel can neither scram the reactor nor prevent a scram. The scram
+// is latched until it is reset.
+type ProtectionSystem struct {
+	Trips    []Trip
+	Channels map[string]Channel
+
+	TripSystemA bool // a half scram of trip system A during the last step
+	TripSystemB bool
+	Scrammed    bool          // the scram is latched
+	FirstOut    TripParameter // the cause of the latched scram
+	Events      []ScramEvent
+	Manual      bool // the manual scram buttons are pressed
+}
+
+// --- CONSTANT DECLARATIONS ---
+var channelNames []string = []string{"A1", "A2", "B1", "B2"}
+
+// DefaultTrips returns the trip functions of a BWR with their usual setpoints. The level, MSIV, drywell and turbine
+// trips are disabled until the plant provides the signals for them.
+func DefaultTrips() []Trip {
+	return []Trip{
+		{Parameter: HighNeutronFlux, Setpoint: 1.18, High: true, Delay: 0, Enabled: true},
+		{Parameter: HighVesselPressure, Setpoint: 7480000, High: true, Delay: 0.1, Enabled: true},
+		{Parameter: LowWaterLevel, High: false, Delay: 1},
+		{Parameter: HighWaterLevel, High: true, Delay: 1},
+		{Parameter: MSIVClosure, Setpoint: 0.9, High: false, Delay: 0.1},
+		{Parameter: HighDrywellPressure, Setpoint: 113000, High: true, Delay: 0.5},
+		{Parameter: TurbineTrip, Setpoint: 0.9, High: false, Delay: 0.05},
+	}
+}
+
+// New returns a reset protection system with the given trip functions.
+func New(trips []Trip) *ProtectionSystem {
+	var system *ProtectionSystem = &ProtectionSystem{Trips: trips, Channels: make(map[string]Channel)}
+	for _, name := range channelNames {
+		system.Channels[name] = Channel{Timers: make(map[TripParameter]float64)}
+	}
+	return system
+}
+
+// signal returns the value of the signal a trip parameter monitors in the given channel.
+func (signals Signals) signal(parameter TripParameter, channel string) float64 {
+	switch parameter {
+	case HighNeutronFlux:
+		return signals.NeutronFlux[channel]
+	case HighVesselPressure:
+		return signals.VesselPressure
+	case LowWaterLevel, HighWaterLevel:
+		return signals.WaterLevel
+	case MSIVClosure:
+		return signals.MSIVPosition
+	case HighDrywellPressure:
+		return signals.DrywellPressure
+	case TurbineTrip:
+		return signals.TurbineStopValvePosition
+	}
+	return 0
+}
+
+// Simulate evaluates every channel against the signals for one time step and latches a scram when the logic is
+// satisfied. It returns true while the scram is latched.
+func (system *ProtectionSystem) Simulate(signals Signals, now time.Duration, deltaTime time.Duration) bool {
+	var systemTrips map[string][]TripParameter = map[string][]TripParameter{}
+	for _, name := range channelNames {
+		var channel Channel = system.Channels[name]
+		channel.Trips = nil
+		for _, trip := range system.Trips {
+			var value float64 = signals.signal(trip.Parameter, name)
+			var beyond bool = trip.Enabled && !channel.Bypassed && ((trip.High && value > trip.Setpoint) || (!trip.High && value < trip.Setpoint))
+			if !beyond {
+				channel.Timers[trip.Parameter] = 0
+				continue
+			}
+			channel.Timers[trip.Parameter] += deltaTime.Seconds()
+			if channel.Timers[trip.Parameter] >= trip.Delay {
+				channel.Trips = append(channel.Trips, trip.Parameter)
+			}
+		}
+		if system.Manual {
+			channel.Timers[ManualScram] += deltaTime.Seconds()
+			channel.Trips = append(channel.Trips, ManualScram)
+		} else {
+			channel.Timers[ManualScram] = 0
+		}
+		systemTrips[name[:1]] = append(systemTrips[name[:1]], channel.Trips...)
+		system.Channels[name] = channel
+	}
+
+	var wasA, wasB bool = system.TripSystemA, system.TripSystemB
+	system.TripSystemA, system.TripSystemB = len(systemTrips["A"]) > 0, len(systemTrips["B"]) > 0
+	if system.TripSystemA && system.TripSystemB && !system.Scrammed {
+		// the first out is a trip of the trip system that completed the logic, or of either when both tripped together
+		var completing []string = []string{"A", "B"}
+		if wasA && !wasB {
+			completing = []string{"B"}
+		} else if wasB && !wasA {
+			completing = []string{"A"}
+		}
+		system.Scrammed = true
+		system.FirstOut = system.firstOut(completing)
+		system.Events = append(system.Events, ScramEvent{Time: now, FirstOut: system.FirstOut})
+	}
+	return system.Scrammed
+}
+
+// firstOut returns the trip of the given trip systems whose signal crossed its setpoint first, the one a channel has
+// timed longest. Trips that crossed in the same step are ranked in the order of system.Trips, the manual scram last.
+func (system *ProtectionSystem) firstOut(tripSystems []string) TripParameter {
+	var rank = func(parameter TripParameter) int {
+		for i, trip := range system.Trips {
+			if trip.Parameter == parameter {
+				return i
+			}
+		}
+		return len(system.Trips)
+	}
+	var first TripParameter
+	var longest float64 = -1
+	for _, tripSystem := range tripSystems {
+		for _, name := range channelNames {
+			if name[:1] != tripSystem {
+				continue
+			}
+			for _, parameter := range system.Channels[name].Trips {
+				var timer float64 = system.Channels[name].Timers[parameter]
+				if timer > longest || (timer == longest && rank(parameter) < rank(first)) {
+					first, longest = parameter, timer
+				}
+			}
+		}
+	}
+	return first
+}
+
+// Scram trips both trip systems by hand, as the manual scram buttons do.
+func (system *ProtectionSystem) Scram() {
+	system.Manual = true
+}
+
+// CanReset returns an error while any channel is still tripped, since the scram would latch again at once.
+func (system *ProtectionSystem) CanReset() error {
+	for _, name := range channelNames {
+		for _, trip := range system.Channels[name].Trips {
+			if trip != ManualScram {
+				return fmt.Errorf("channel %s is still tripped by %s", name, trip)
+			}
+		}
+	}
+	return nil
+}
+
+// Reset unlatches the scram. It fails while any channel is still tripped, see CanReset.
+func (system *ProtectionSystem) Reset() error {
+	if err := system.CanReset(); err != nil {
+		return err
+	}
+	system.Manual = false
+	system.Scrammed = false
+	system.FirstOut = ""
+	return nil
+}
+
+// BypassChannel takes a channel out of service or puts it back.
+func (system *ProtectionSystem) BypassChannel(name string, bypassed bool) error {
+	var channel, ok = system.Channels[name]
+	if !ok {
+		return fmt.Errorf("channel %q does not exist", name)
+	}
+	channel.Bypassed = bypassed
+	system.Channels[name] = channel
+	return nil
+}
@@ -0,0 +1,193 @@
+package protection
+
+import (
+	"testing"
+	"time"
+)
+
+const step time.Duration = 100 * time.Millisecond
+
+// normalSignals returns the signals of a plant at rated power with every trip clear.
+func normalSignals() Signals {
+	return Signals{
+		NeutronFlux:              map[string]float64{"A1": 1, "A2": 1, "B1": 1, "B2": 1},
+		VesselPressure:           7000000,
+		MSIVPosition:             1,
+		TurbineStopValvePosition: 1,
+	}
+}
+
+// withFlux returns the signals with the flux of the given channels raised above the setpoint.
+func withFlux(signals Signals, channels ...string) Signals {
+	var flux map[string]float64 = map[string]float64{}
+	for channel, value := range signals.NeutronFlux {
+		flux[channel] = value
+	}
+	for _, channel := range channels {
+		flux[channel] = 1.25
+	}
+	signals.NeutronFlux = flux
+	return signals
+}
+
+func TestOneOutOfTwoTakenTwice(t *testing.T) {
+	var tests = []struct {
+		name     string
+		bypassed []string
+		tripped  []string
+		halfA    bool
+		halfB    bool
+		scrammed bool
+	}{
+		{"nothing tripped", nil, nil, false, false, false},
+		{"one channel tripped does not scram", nil, []string{"A1"}, true, false, false},
+		{"both channels of one trip system do not scram", nil, []string{"B1", "B2"}, false, true, false},
+		{"one channel of each trip system scrams", nil, []string{"A2", "B1"}, true, true, true},
+		{"all channels scram", nil, []string{"A1", "A2", "B1", "B2"}, true, true, true},
+		{"bypass of A1 with A2 tripped still half-scrams A", []string{"A1"}, []string{"A2"}, true, false, false},
+		{"bypass of A1 with A2 and B2 tripped scrams", []string{"A1"}, []string{"A2", "B2"}, true, true, true},
+		{"a bypassed channel cannot trip", []string{"A1"}, []string{"A1", "B1"}, false, true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var system *ProtectionSystem = New(DefaultTrips())
+			for _, name := range test.bypassed {
+				if err := system.BypassChannel(name, true); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var scrammed bool = system.Simulate(withFlux(normalSignals(), test.tripped...), 0, step)
+			if system.TripSystemA != test.halfA || system.TripSystemB != test.halfB || scrammed != test.scrammed {
+				t.Errorf("trip systems A %v B %v, scrammed %v; want A %v B %v, scrammed %v",
+					system.TripSystemA, system.TripSystemB, scrammed, test.halfA, test.halfB, test.scrammed)
+			}
+		})
+	}
+}
+
+func TestTripDelay(t *testing.T) {
+	var system *ProtectionSystem = New(DefaultTrips())
+	var signals Signals = normalSignals()
+	signals.VesselPressure = 7600000 // above the setpoint, which must hold for 0.1 s
+	if system.Simulate(signals, 0, 50*time.Millisecond) {
+		t.Fatal("scrammed before the trip delay ran out")
+	}
+	if !system.Simulate(signals, 50*time.Millisecond, 50*time.Millisecond) {
+		t.Fatal("no scram once the pressure had been high for the trip delay")
+	}
+	if system.FirstOut != HighVesselPressure {
+		t.Errorf("first out %q, want %q", system.FirstOut, HighVesselPressure)
+	}
+
+	// a signal that returns below the setpoint restarts the delay
+	system = New(DefaultTrips())
+	system.Simulate(signals, 0, 50*time.Millisecond)
+	system.Simulate(normalSignals(), 50*time.Millisecond, 50*time.Millisecond)
+	if system.Simulate(signals, 100*time.Millisecond, 50*time.Millisecond) {
+		t.Error("scrammed although the pressure never stayed high for the trip delay")
+	}
+}
+
+func TestLatchAndFirstOut(t *testing.T) {
+	var pressureHigh Signals = normalSignals()
+	pressureHigh.VesselPressure = 7600000
+	var tests = []struct {
+		name     string
+		first    Signals // half scram
+		second   Signals // completes the scram
+		firstOut TripParameter
+	}{
+		{"A first, pressure completes B", withFlux(normalSignals(), "A1"), withFlux(pressureHigh, "A1"), HighVesselPressure},
+		{"B first, pressure completes A", withFlux(normalSignals(), "B1"), withFlux(pressureHigh, "B1"), HighVesselPressure},
+		{"flux completes the scram", withFlux(normalSignals(), "A1"), withFlux(normalSignals(), "A1", "B2"), HighNeutronFlux},
+		{"both trip systems at once", normalSignals(), withFlux(normalSignals(), "A1", "B1"), HighNeutronFlux},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var system *ProtectionSystem = New(DefaultTrips())
+			if system.Simulate(test.first, 0, step) {
+				t.Fatal("scrammed on a half scram")
+			}
+			for i := 0; i < 2; i += 1 { // the pressure trip has a delay
+				system.Simulate(test.second, time.Duration(i+1)*step, step)
+			}
+			if !system.Scrammed || system.FirstOut != test.firstOut {
+				t.Fatalf("scrammed %v, first out %q; want scrammed with first out %q", system.Scrammed, system.FirstOut, test.firstOut)
+			}
+			if !system.Simulate(normalSignals(), 3*step, step) {
+				t.Error("the scram did not stay latched once the signals cleared")
+			}
+			if len(system.Events) != 1 {
+				t.Errorf("%d scram events, want 1", len(system.Events))
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	var system *ProtectionSystem = New(DefaultTrips())
+	var tripped Signals = withFlux(normalSignals(), "A1", "B1")
+	system.Simulate(tripped, 0, step)
+	if err := system.Reset(); err == nil {
+		t.Fatal("reset accepted while channels are tripped")
+	}
+	if !system.Scrammed || system.FirstOut != HighNeutronFlux {
+		t.Fatal("a refused reset changed the latched scram")
+	}
+	system.Simulate(normalSignals(), step, step)
+	if err := system.Reset(); err != nil {
+		t.Fatalf("reset refused once the trips cleared: %v", err)
+	}
+	if system.Scrammed || system.FirstOut != "" {
+		t.Error("reset did not unlatch the scram")
+	}
+
+	// the manual scram buttons do not block the reset, which releases them
+	system.Scram()
+	if !system.Simulate(normalSignals(), 2*step, step) || system.FirstOut != ManualScram {
+		t.Fatalf("manual scram gave scrammed %v, first out %q", system.Scrammed, system.FirstOut)
+	}
+	if err := system.Reset(); err != nil {
+		t.Fatalf("reset refused after a manual scram: %v", err)
+	}
+	if system.Simulate(normalSignals(), 3*step, step) {
+		t.Error("scrammed again after the manual scram was reset")
+	}
+}
+
+func TestFirstOutOfASimultaneousTrip(t *testing.T) {
+	var highPressure = func(signals Signals) Signals {
+		signals.VesselPressure = 7600000
+		return signals
+	}
+	var tests = []struct {
+		name   string
+		steps  []Signals // 50 ms apart, both trip systems trip together in the last
+		manual bool      // the scram buttons are pressed before the last step
+		want   TripParameter
+	}{
+		{"the pressure crossed first and trips with the flux once its delay runs out",
+			[]Signals{highPressure(normalSignals()), withFlux(highPressure(normalSignals()), "A1", "B1")}, false, HighVesselPressure},
+		{"the flux crossed in the step the scram buttons were pressed, the manual scram is ranked last",
+			[]Signals{withFlux(normalSignals(), "A1", "B1")}, true, HighNeutronFlux},
+		{"the manual scram joins a pressure trip that crossed earlier",
+			[]Signals{highPressure(normalSignals()), highPressure(normalSignals())}, true, HighVesselPressure},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var system *ProtectionSystem = New(DefaultTrips())
+			for i, signals := range test.steps {
+				if i == len(test.steps)-1 && test.manual {
+					system.Scram()
+				}
+				var scrammed bool = system.Simulate(signals, time.Duration(i)*50*time.Millisecond, 50*time.Millisecond)
+				if scrammed != (i == len(test.steps)-1) {
+					t.Fatalf("step %d: scrammed %v", i, scrammed)
+				}
+			}
+			if system.FirstOut != test.want {
+				t.Errorf("first out %q, want %q", system.FirstOut, test.want)
+			}
+		})
+	}
+}
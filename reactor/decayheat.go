@@ -0,0 +1,40 @@
+package reactor
+
+import "math"
+
+// --- CONSTANT DECLARATIONS ---
+const energyPerFission float64 = 200 // MeV recovered per fission, including the decay of the fission products
+
+// decayHeatAmplitudes and decayHeatConstants are the 23-group fit of ANS-5.1-1979 to the decay power of the fission
+// products of thermal fission of U-235: a fission product group j left over from a constant fission rate f gives off
+// α_j/λ_j·f·(1 - e^(-λ_j·T))·e^(-λ_j·t) MeV/s after T seconds of operation and t seconds of shutdown.
+var decayHeatAmplitudes [23]float64 = [23]float64{ // α_j in MeV/(fission·s)
+	6.5057e-01, 5.1264e-01, 2.4384e-01, 1.3850e-01, 5.5440e-02, 2.2225e-02, 3.3088e-03, 9.3015e-04,
+	8.0943e-04, 1.9567e-04, 3.2535e-05, 7.5595e-06, 2.5232e-06, 4.9948e-07, 1.8531e-07, 2.6608e-08,
+	2.2398e-09, 8.1641e-12, 8.7797e-11, 2.5131e-14, 3.2176e-16, 4.5038e-17, 7.4791e-17,
+}
+var decayHeatConstants [23]float64 = [23]float64{ // λ_j in 1/s
+	2.2138e+01, 5.1587e-01, 1.9594e-01, 1.0314e-01, 3.3656e-02, 1.1681e-02, 3.5870e-03, 1.3930e-03,
+	6.2630e-04, 1.8906e-04, 5.4988e-05, 2.0958e-05, 1.0010e-05, 2.5438e-06, 6.6361e-07, 1.2290e-07,
+	2.7213e-08, 4.3714e-09, 7.5780e-10, 2.4786e-10, 2.2384e-13, 2.4600e-14, 1.5699e-14,
+}
+
+// saturatedDecayHeatFraction returns the share of the thermal power that comes from fission product decay in a core
+// that has run at constant power forever, about 6.6%. The rest is released at the moment of fission.
+func saturatedDecayHeatFraction() (fraction float64) {
+	for j := range decayHeatAmplitudes {
+		fraction += decayHeatAmplitudes[j] / decayHeatConstants[j] / energyPerFission
+	}
+	return
+}
+
+// simulateDecayHeat advances the decay heat groups, each the power of its fission products as a fraction of rated,
+// over the given time at the given neutron density. Each group is solved exactly at constant fission rate, so the
+// decay heat stays right at any time step.
+func simulateDecayHeat(groups [23]float64, neutronDensity float64, deltaTimeSeconds float64) [23]float64 {
+	for j := range groups {
+		var source float64 = decayHeatAmplitudes[j] / energyPerFission * neutronDensity
+		groups[j] = groups[j]*math.Exp(-decayHeatConstants[j]*deltaTimeSeconds) + source*growth(decayHeatConstants[j], deltaTimeSeconds)
+	}
+	return groups
+}
@@ -0,0 +1,75 @@
+package reactor
+
+import (
+	"math"
+	"testing"
+)
+
+// saturatedDecayHeatGroups returns the decay heat groups of a core that has run at full power forever.
+func saturatedDecayHeatGroups() (groups [23]float64) {
+	for j := range groups {
+		groups[j] = decayHeatAmplitudes[j] / decayHeatConstants[j] / energyPerFission
+	}
+	return
+}
+
+func sumGroups(groups [23]float64) (total float64) {
+	for _, group := range groups {
+		total += group
+	}
+	return
+}
+
+func TestSaturatedDecayHeat(t *testing.T) {
+	if fraction := saturatedDecayHeatFraction(); fraction < 0.06 || fraction > 0.07 {
+		t.Errorf("decay heat at shutdown after infinite operation = %v, want 6 to 7%%", fraction)
+	}
+	// running long enough from a fresh core reaches the same level
+	var groups [23]float64 = simulateDecayHeat([23]float64{}, 1, 1e15)
+	if total := sumGroups(groups); math.Abs(total-saturatedDecayHeatFraction()) > 1e-3*total {
+		t.Errorf("decay heat after very long operation = %v, want %v", total, saturatedDecayHeatFraction())
+	}
+}
+
+func TestDecayHeatAfterShutdown(t *testing.T) {
+	// ANS-5.1-1979 decay power of U-235 after infinite operation, as a fraction of the operating power
+	var tests = []struct {
+		name      string
+		seconds   float64
+		low, high float64
+	}{
+		{"1 s", 1, 0.055, 0.065},
+		{"1 min", 60, 0.032, 0.040},
+		{"1 h", 3600, 0.011, 0.015},
+		{"1 day", 86400, 0.004, 0.0065},
+	}
+	for _, test := range tests {
+		var fraction float64 = sumGroups(simulateDecayHeat(saturatedDecayHeatGroups(), 0, test.seconds))
+		if fraction < test.low || fraction > test.high {
+			t.Errorf("decay heat %s after shutdown = %.4f, want %.4f to %.4f", test.name, fraction, test.low, test.high)
+		}
+	}
+}
+
+func TestDecayHeatAfterFiniteOperation(t *testing.T) {
+	// α_j/λ_j·(1 - e^(-λ_j·T))·e^(-λ_j·t) after T of operation and t of shutdown, integrated in steps of 1 s
+	var operation, shutdown float64 = 3600, 600
+	var groups [23]float64
+	for i := 0; i < int(operation); i += 1 {
+		groups = simulateDecayHeat(groups, 1, 1)
+	}
+	for i := 0; i < int(shutdown); i += 1 {
+		groups = simulateDecayHeat(groups, 0, 1)
+	}
+	var want float64
+	for j := range decayHeatAmplitudes {
+		want += decayHeatAmplitudes[j] / decayHeatConstants[j] / energyPerFission *
+			-math.Expm1(-decayHeatConstants[j]*operation) * math.Exp(-decayHeatConstants[j]*shutdown)
+	}
+	if got := sumGroups(groups); math.Abs(got-want) > 1e-9*want {
+		t.Errorf("decay heat 10 min after 1 h of operation = %v, want %v", got, want)
+	}
+	if got := sumGroups(groups); got >= sumGroups(simulateDecayHeat(saturatedDecayHeatGroups(), 0, shutdown)) {
+		t.Errorf("decay heat after 1 h of operation = %v, want less than after infinite operation", got)
+	}
+}
@@ -0,0 +1,40 @@
+// Package reactor simulates the core of a BWR: its neutron population, control rods, reactivity feedback, fission
+// product poisons, decay heat and fuel.
+//
+// # Kinetics
+//
+// The core power follows the point kinetics equations with six delayed neutron groups. They are integrated in
+// substeps of a millisecond, shortened above prompt critical, so that prompt jumps, prompt drops and the reactor
+// period come out the same at any simulation step. Reactivity is kept in Δk/k and can be read in pcm or dollars, see
+// [Reactor.ReactivityPCM] and [Reactor.ReactivityDollars].
+//
+// # Control rods
+//
+// The core has 185 control rods on a BWR core map. Each is positioned in units of 3 inches from 00, fully inserted,
+// to 48, fully withdrawn. The drives latch the rods every notch of two units, so [Reactor.MoveRod] takes the even
+// positions 00, 02, ... 48, and [Reactor.WithdrawRod] and [Reactor.InsertRod] move a rod by one notch. The drives
+// move 3 inches per second, and the worth of every rod follows an S-shaped integral worth curve weighted by its place
+// in the core. Below 10% power the rod worth minimizer blocks any pull outside the current step of the banked
+// withdrawal sequence. [Reactor.Scram] drives all rods in within 3 s and blocks rod motion until [Reactor.ResetScram].
+//
+// # Feedback and poisons
+//
+// Besides the rods, the core void fraction, the fuel temperature (Doppler) and the moderator temperature feed back on
+// the reactivity through coefficient tables in pcm per % void or per K, see [CoefficientTable]. A plant model can
+// replace the defaults with its own tables.
+//
+// Iodine-135/xenon-135 and promethium-149/samarium-149 build up with the flux and poison the core. Their chains are
+// solved exactly over each step, so the xenon peak after a trip and the restart window come out right when the
+// simulation runs hours at a time.
+//
+// # Decay heat and fuel
+//
+// The heat the core gives to the coolant includes the decay heat of the fission products, tracked from the power
+// history with the 23 groups of ANS-5.1. A tripped core keeps heating its coolant at a few percent of rated power for
+// hours.
+//
+// That heat is generated in the fuel. A representative rod of UO2 pellets, with a conductivity that depends on
+// temperature, a gas gap and Zircaloy cladding conducts it radially to the coolant, which delays it and sets the fuel
+// temperature for the Doppler feedback. The rod reports its centerline, average and cladding surface temperatures,
+// the peak linear heat generation rate and the MCPR of the hottest bundle (CISE-4), and their margins to the limits.
+package reactor
@@ -0,0 +1,93 @@
+package reactor
+
+import (
+	"math"
+	"slices"
+)
+
+// --- STRUCT DECLARATIONS ---
+
+// CoefficientTable is a reactivity coefficient that changes with the state it depends on, e.g. the void coefficient
+// that grows stronger as the core voids. The coefficient is interpolated linearly between the points and held constant
+// beyond the first and last, and the reactivity is its integral from the reference state, where it is zero.
+type CoefficientTable struct {
+	Reference    float64   // state at which the table adds no reactivity
+	Points       []float64 // states in ascending order
+	Coefficients []float64 // pcm per unit of state at each point
+}
+
+// ReactivityFeedback holds the coefficient tables through which the state of the core changes its reactivity.
+type ReactivityFeedback struct {
+	Void      CoefficientTable // over the core void fraction in %
+	Doppler   CoefficientTable // over the average fuel temperature in degrees Celsius
+	Moderator CoefficientTable // over the moderator temperature in degrees Celsius
+}
+
+// --- CONSTANT DECLARATIONS ---
+
+// DefaultReactivityFeedback returns coefficients typical of a BWR core, referenced to a cold, unvoided core at 20 °C.
+// At rated conditions the three together take about 8000 pcm out of the core.
+func DefaultReactivityFeedback() ReactivityFeedback {
+	return ReactivityFeedback{
+		Void: CoefficientTable{
+			Reference:    0,
+			Points:       []float64{0, 40, 70, 100},
+			Coefficients: []float64{-60, -100, -150, -200},
+		},
+		Doppler: CoefficientTable{
+			Reference:    20,
+			Points:       []float64{20, 500, 1000, 1500, 2500},
+			Coefficients: []float64{-4, -3, -2.4, -2, -1.6},
+		},
+		Moderator: CoefficientTable{
+			Reference:    20,
+			Points:       []float64{20, 100, 200, 286, 320},
+			Coefficients: []float64{-1, -4, -10, -16, -20},
+		},
+	}
+}
+
+// Coefficient returns the coefficient in pcm per unit of state at the given state.
+func (table CoefficientTable) Coefficient(state float64) float64 {
+	if len(table.Points) == 0 {
+		return 0
+	}
+	var i int = pointAbove(table.Points, state)
+	if i == 0 {
+		return table.Coefficients[0]
+	}
+	if i == len(table.Points) {
+		return table.Coefficients[len(table.Points)-1]
+	}
+	var fraction float64 = (state - table.Points[i-1]) / (table.Points[i] - table.Points[i-1])
+	return table.Coefficients[i-1] + fraction*(table.Coefficients[i]-table.Coefficients[i-1])
+}
+
+// Reactivity returns the reactivity in Δk/k the table adds at the given state.
+func (table CoefficientTable) Reactivity(state float64) float64 {
+	var from, to float64 = math.Min(table.Reference, state), math.Max(table.Reference, state)
+	// the coefficient is linear between the points, so the trapezoidal rule over them is exact
+	var pcm float64
+	var previous float64 = from
+	for _, point := range table.Points {
+		if point <= from || point >= to {
+			continue
+		}
+		pcm += (point - previous) * (table.Coefficient(previous) + table.Coefficient(point)) / 2
+		previous = point
+	}
+	pcm += (to - previous) * (table.Coefficient(previous) + table.Coefficient(to)) / 2
+	if state < table.Reference {
+		pcm = -pcm
+	}
+	return PCMToReactivity(pcm)
+}
+
+// pointAbove returns the index of the first point above the state.
+func pointAbove(points []float64, state float64) int {
+	var i, found = slices.BinarySearch(points, state)
+	if found {
+		i += 1
+	}
+	return i
+}
@@ -0,0 +1,74 @@
+package reactor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCoefficientTable(t *testing.T) {
+	var constant CoefficientTable = CoefficientTable{Reference: 20, Points: []float64{0}, Coefficients: []float64{-2}}
+	var ramp CoefficientTable = CoefficientTable{Reference: 0, Points: []float64{0, 10}, Coefficients: []float64{0, -10}}
+	var tests = []struct {
+		name  string
+		table CoefficientTable
+		state float64
+		pcm   float64
+	}{
+		{"at the reference", constant, 20, 0},
+		{"constant coefficient above the reference", constant, 70, -100},
+		{"constant coefficient below the reference", constant, 0, 40},
+		{"halfway up a ramp", ramp, 5, -12.5},
+		{"top of a ramp", ramp, 10, -50},
+		{"held beyond the last point", ramp, 20, -150},
+		{"held before the first point", ramp, -10, 0},
+		{"empty table", CoefficientTable{}, 50, 0},
+	}
+	for _, test := range tests {
+		if got := ReactivityToPCM(test.table.Reactivity(test.state)); math.Abs(got-test.pcm) > 1e-9 {
+			t.Errorf("%s: %v pcm, want %v pcm", test.name, got, test.pcm)
+		}
+	}
+
+	var coefficients = []struct{ state, want float64 }{{-5, 0}, {0, 0}, {5, -5}, {10, -10}, {15, -10}}
+	for _, test := range coefficients {
+		if got := ramp.Coefficient(test.state); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("ramp coefficient at %v = %v pcm per unit, want %v", test.state, got, test.want)
+		}
+	}
+}
+
+func TestDefaultFeedbackAtRatedConditions(t *testing.T) {
+	// 40 % void, fuel at 600 °C and moderator at 286 °C together hold down several thousand pcm, each of them negative
+	var feedback ReactivityFeedback = DefaultReactivityFeedback()
+	var void, doppler, moderator float64 = feedback.Void.Reactivity(40), feedback.Doppler.Reactivity(600), feedback.Moderator.Reactivity(286)
+	if void >= 0 || doppler >= 0 || moderator >= 0 {
+		t.Errorf("void %v, Doppler %v and moderator %v Δk/k, want all negative", void, doppler, moderator)
+	}
+	if total := ReactivityToPCM(void + doppler + moderator); total > -6000 || total < -10000 {
+		t.Errorf("feedback at rated conditions = %v pcm, want about -8000 pcm", total)
+	}
+}
+
+func TestDefaultFeedbackIsACopy(t *testing.T) {
+	// a reactor that edits its coefficients must not change those of the next one
+	var edited *Reactor = NewReactor(100)
+	edited.Feedback.Void.Coefficients[0] = 0
+	if got := NewReactor(100).Feedback.Void.Coefficients[0]; got != -60 {
+		t.Errorf("void coefficient of a new reactor = %v pcm per %%, want -60", got)
+	}
+}
+
+func TestVoidFeedbackReducesReactivity(t *testing.T) {
+	var cold, voided *Reactor = NewReactor(100), NewReactor(100)
+	voided.VoidFraction = 0.4
+	cold.SimulateFission(100 * time.Millisecond)
+	voided.SimulateFission(100 * time.Millisecond)
+	var want float64 = DefaultReactivityFeedback().Void.Reactivity(40)
+	if voided.VoidReactivity != want || cold.VoidReactivity != 0 {
+		t.Errorf("void reactivity %v Δk/k voided and %v cold, want %v and 0", voided.VoidReactivity, cold.VoidReactivity, want)
+	}
+	if got := voided.Reactivity - cold.Reactivity; math.Abs(got-want) > 1e-12 {
+		t.Errorf("voiding the core changed its reactivity by %v Δk/k, want %v", got, want)
+	}
+}
@@ -0,0 +1,269 @@
+package reactor
+
+import (
+	"math"
+	"time"
+)
+
+// --- STRUCT DECLARATIONS ---
+
+// FuelRod is the representative, average fuel rod of the core: a column of UO2 pellets inside a Zircaloy cladding
+// tube with a gas-filled gap between the two. It conducts the fission and decay heat radially from the pellets to
+// the coolant, which delays the heat and sets the fuel temperature the Doppler feedback sees.
+type FuelRod struct {
+	PelletRadius      float64 // m
+	GapConductance    float64 // W/(m²·K) across the pellet-cladding gap
+	CladInnerRadius   float64 // m
+	CladThickness     float64 // m
+	ActiveLength      float64 // m, heated length of the rod
+	Count             int     // rods in the core
+	FlowArea          float64 // m² of coolant channel per rod
+	HydraulicDiameter float64 // m of the coolant channel
+
+	RadialPeakingFactor float64 // power of the hottest bundle over the core average
+	AxialPeakingFactor  float64 // peak over average along the rod
+	LocalPeakingFactor  float64 // hottest rod over its bundle average
+
+	Temperatures []float64 // °C at the centre of each mesh cell, pelletCells in the pellet followed by cladCells in the cladding
+
+	CenterlineTemperature  float64 // °C
+	AverageTemperature     float64 // °C, over the pellet volume
+	CladSurfaceTemperature float64 // °C
+	HeatFlow               float64 // W from all rods into the coolant during the last step
+	LinearHeatRate         float64 // W/m, core average
+	PeakLinearHeatRate     float64 // W/m, in the hottest rod
+	MCPR                   float64 // minimum critical power ratio of the hottest bundle, +Inf without power
+}
+
+// CoreCoolant is what the fuel rods see of the coolant around them, set from the fluid state every step.
+type CoreCoolant struct {
+	Pressure                float64 // Pa
+	Flow                    float64 // kg/s through the core
+	InletSubcooling         float64 // J/kg below the saturated liquid enthalpy at the core inlet
+	LatentHeat              float64 // J/kg of evaporation
+	HeatTransferCoefficient float64 // W/(m²·K) from the cladding surface to the coolant
+}
+
+// --- CONSTANT DECLARATIONS ---
+const pelletCells int = 6
+const cladCells int = 2
+const uo2Density float64 = 10412 // kg/m^3, 95% of theoretical density
+const uo2SpecificHeat float64 = 300
+const zircaloyDensity float64 = 6550
+const zircaloySpecificHeat float64 = 330
+const ratedLinearHeatRate float64 = 12300    // W/m, core average at 100% power, sets the number of rods
+const ratedCoreFlowPerPower float64 = 3.7    // kg/s of core flow per MW of rated thermal power
+const directModeratorHeating float64 = 0.026 // share of the thermal power deposited in the coolant by gammas and neutrons
+const linearHeatRateLimit float64 = 44000    // W/m, 13.4 kW/ft
+const operatingLimitMCPR float64 = 1.25
+const coolantCriticalPressure float64 = 22064000 // Pa, critical pressure of water (IAPWS), kept here so the core does not depend on the fluid package
+
+// newFuelRod returns the average rod of a core of 10x10 BWR bundles of the given rated thermal power in MW, at a
+// uniform temperature.
+func newFuelRod(ratedThermalPower float64, temperature float64) FuelRod {
+	var rod FuelRod = FuelRod{
+		PelletRadius:        0.00438,
+		GapConductance:      6000,
+		CladInnerRadius:     0.00447,
+		CladThickness:       0.00061,
+		ActiveLength:        3.81,
+		FlowArea:            0.000103,
+		HydraulicDiameter:   0.0115,
+		RadialPeakingFactor: 1.4,
+		AxialPeakingFactor:  1.4,
+		LocalPeakingFactor:  1.15,
+		Temperatures:        make([]float64, pelletCells+cladCells),
+		MCPR:                math.Inf(1),
+	}
+	rod.Count = int(math.Round(ratedThermalPower * 1000000 * (1 - directModeratorHeating) / (ratedLinearHeatRate * rod.ActiveLength)))
+	for i := range rod.Temperatures {
+		rod.Temperatures[i] = temperature
+	}
+	rod.CenterlineTemperature, rod.AverageTemperature, rod.CladSurfaceTemperature = temperature, temperature, temperature
+	return rod
+}
+
+// UO2Conductivity returns the thermal conductivity of 95% dense UO2 in W/(m·K) at the given temperature in °C, after
+// Fink (2000): phonon conduction falling with temperature and an electronic term rising at high temperature.
+func UO2Conductivity(temperature float64) float64 {
+	var t float64 = (temperature + 273.15) / 1000
+	return 100/(7.5408+17.692*t+3.6142*t*t) + 6400/math.Pow(t, 2.5)*math.Exp(-16.35/t)
+}
+
+// ZircaloyConductivity returns the thermal conductivity of Zircaloy in W/(m·K) at the given temperature in °C
+// (MATPRO).
+func ZircaloyConductivity(temperature float64) float64 {
+	var t float64 = temperature + 273.15
+	return 7.51 + 2.09e-2*t - 1.45e-5*t*t + 7.67e-9*t*t*t
+}
+
+// simulate conducts the heat generated in the pellets, the given thermal power in W minus the part deposited directly
+// in the coolant, through the rod into the coolant at the given temperature for one time step. The conduction is
+// implicit with the conductivities of the start of the step, so it stays stable at any time step. It then updates the
+// temperatures and the thermal limits the rod reports.
+func (rod FuelRod) simulate(thermalPower float64, coolantTemperature float64, coolant CoreCoolant, deltaTime time.Duration) FuelRod {
+	if rod.Count == 0 {
+		return rod
+	}
+	var deltaTimeSeconds float64 = deltaTime.Seconds()
+	var cells int = len(rod.Temperatures)
+	var width = func(i int) float64 {
+		if i < pelletCells {
+			return rod.PelletRadius / float64(pelletCells)
+		}
+		return rod.CladThickness / float64(cladCells)
+	}
+	var inner = func(i int) float64 { // radius of the inner edge of a cell
+		if i < pelletCells {
+			return float64(i) * width(i)
+		}
+		return rod.CladInnerRadius + float64(i-pelletCells)*width(i)
+	}
+	var centre = func(i int) float64 { return inner(i) + width(i)/2 }
+	var conductivity = func(i int) float64 {
+		if i < pelletCells {
+			return UO2Conductivity(rod.Temperatures[i])
+		}
+		return ZircaloyConductivity(rod.Temperatures[i])
+	}
+	var shell = func(k float64, inner float64, outer float64) float64 { // thermal resistance of a cylindrical shell per m of rod
+		return math.Log(outer/inner) / (2 * math.Pi * k)
+	}
+
+	// per metre of rod: capacities in J/(m·K), conductances between cell centres in W/(m·K)
+	var linearHeatRate float64 = thermalPower * (1 - directModeratorHeating) / (float64(rod.Count) * rod.ActiveLength)
+	var capacities, sources, conductances []float64 = make([]float64, cells), make([]float64, cells), make([]float64, cells)
+	for i := 0; i < cells; i += 1 {
+		var area float64 = math.Pi * (math.Pow(inner(i)+width(i), 2) - math.Pow(inner(i), 2))
+		if i < pelletCells {
+			capacities[i] = uo2Density * uo2SpecificHeat * area
+			sources[i] = linearHeatRate * area / (math.Pi * rod.PelletRadius * rod.PelletRadius)
+		} else {
+			capacities[i] = zircaloyDensity * zircaloySpecificHeat * area
+		}
+	}
+	for i := 0; i < cells-1; i += 1 {
+		var resistance float64 = shell(conductivity(i), centre(i), inner(i)+width(i)) + shell(conductivity(i+1), inner(i+1), centre(i+1))
+		if i == pelletCells-1 {
+			resistance += 1 / (rod.GapConductance * 2 * math.Pi * rod.PelletRadius)
+		}
+		conductances[i] = 1 / resistance
+	}
+	var outerRadius float64 = rod.CladInnerRadius + rod.CladThickness
+	var surfaceConductance float64 // from the outermost cell centre through the cladding and the film into the coolant
+	if coolant.HeatTransferCoefficient > 0 {
+		surfaceConductance = 1 / (shell(conductivity(cells-1), centre(cells-1), outerRadius) + 1/(coolant.HeatTransferCoefficient*2*math.Pi*outerRadius))
+	}
+
+	// C_i/dt*(T_i' - T_i) = G_(i-1)*(T_(i-1)' - T_i') + G_i*(T_(i+1)' - T_i') + S_i, solved with the Thomas algorithm
+	var lower, diagonal, upper, rhs []float64 = make([]float64, cells), make([]float64, cells), make([]float64, cells), make([]float64, cells)
+	for i := 0; i < cells; i += 1 {
+		diagonal[i] = capacities[i] / deltaTimeSeconds
+		rhs[i] = capacities[i]/deltaTimeSeconds*rod.Temperatures[i] + sources[i]
+		if i > 0 {
+			lower[i] = -conductances[i-1]
+			diagonal[i] += conductances[i-1]
+		}
+		if i < cells-1 {
+			upper[i] = -conductances[i]
+			diagonal[i] += conductances[i]
+		}
+	}
+	diagonal[cells-1] += surfaceConductance
+	rhs[cells-1] += surfaceConductance * coolantTemperature
+	for i := 1; i < cells; i += 1 {
+		var factor float64 = lower[i] / diagonal[i-1]
+		diagonal[i] -= factor * upper[i-1]
+		rhs[i] -= factor * rhs[i-1]
+	}
+	rod.Temperatures[cells-1] = rhs[cells-1] / diagonal[cells-1]
+	for i := cells - 2; i >= 0; i -= 1 {
+		rod.Temperatures[i] = (rhs[i] - upper[i]*rod.Temperatures[i+1]) / diagonal[i]
+	}
+
+	var linearHeatFlow float64 = surfaceConductance * (rod.Temperatures[cells-1] - coolantTemperature)
+	rod.HeatFlow = linearHeatFlow * float64(rod.Count) * rod.ActiveLength
+	rod.CladSurfaceTemperature = coolantTemperature
+	if coolant.HeatTransferCoefficient > 0 {
+		rod.CladSurfaceTemperature += linearHeatFlow / (coolant.HeatTransferCoefficient * 2 * math.Pi * outerRadius)
+	}
+	// the centre cell generates heat evenly, so the axis sits q'''·r²/(8k) = S/(8π·k) above its average
+	rod.CenterlineTemperature = rod.Temperatures[0] + sources[0]/(8*math.Pi*conductivity(0))
+	rod.AverageTemperature = 0
+	for i := 0; i < pelletCells; i += 1 {
+		rod.AverageTemperature += rod.Temperatures[i] * capacities[i] / (uo2Density * uo2SpecificHeat * math.Pi * rod.PelletRadius * rod.PelletRadius)
+	}
+
+	rod.LinearHeatRate = thermalPower / (float64(rod.Count) * rod.ActiveLength)
+	rod.PeakLinearHeatRate = rod.LinearHeatRate * rod.RadialPeakingFactor * rod.AxialPeakingFactor * rod.LocalPeakingFactor
+	rod.MCPR = rod.criticalPowerRatio(coolant)
+	return rod
+}
+
+// criticalPowerRatio returns the critical power ratio of the hottest rod: the rod power at which the film on it would
+// dry out over the power it has now. Dryout is predicted with the CISE-4 critical quality correlation on the coolant
+// channel of the rod, heated evenly over its length and given the average flow per rod. A coolant state that is not
+// finite, or without pressure or latent heat, gives NaN, and a dryout power that cannot be bracketed gives +Inf.
+func (rod FuelRod) criticalPowerRatio(coolant CoreCoolant) float64 {
+	for _, value := range []float64{coolant.Pressure, coolant.Flow, coolant.InletSubcooling, coolant.LatentHeat} {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return math.NaN()
+		}
+	}
+	if coolant.Pressure <= 0 || coolant.LatentHeat <= 0 {
+		return math.NaN()
+	}
+	var power float64 = rod.LinearHeatRate * rod.ActiveLength * rod.RadialPeakingFactor * rod.LocalPeakingFactor
+	if power <= 0 || coolant.Flow <= 0 || coolant.Pressure >= coolantCriticalPressure {
+		return math.Inf(1)
+	}
+	var flow float64 = coolant.Flow / float64(rod.Count)
+	var massFlux float64 = rod.MassFlux(coolant.Flow)
+	var reducedPressure float64 = coolant.Pressure / coolantCriticalPressure
+	var a float64 = 1 / (1 + 1.481e-4*math.Pow(1-reducedPressure, -3)*massFlux)
+	if massFlux > 3375*math.Pow(1-reducedPressure, 3) {
+		a = (1 - reducedPressure) / math.Cbrt(massFlux/1000)
+	}
+	var b float64 = 0.199 * math.Pow(1/reducedPressure-1, 0.4) * massFlux * math.Pow(rod.HydraulicDiameter, 1.4)
+	var subcooling float64 = flow * math.Max(coolant.InletSubcooling, 0)
+
+	// exit quality rises faster with power than the critical quality does, so the dryout power is bracketed
+	var dryout = func(rodPower float64) bool {
+		var exitQuality float64 = (rodPower - subcooling) / (flow * coolant.LatentHeat)
+		var boilingLength float64 = rod.ActiveLength * math.Max(1-subcooling/rodPower, 0)
+		return exitQuality >= a*boilingLength/(boilingLength+b)
+	}
+	var low, high float64 = subcooling, math.Max(2*subcooling, power)
+	for i := 0; !dryout(high); i += 1 {
+		if i == 60 {
+			return math.Inf(1)
+		}
+		high *= 2
+	}
+	for i := 0; i < 60 && high-low > 0.0001*high; i += 1 {
+		if dryout((low + high) / 2) {
+			high = (low + high) / 2
+		} else {
+			low = (low + high) / 2
+		}
+	}
+	return (low + high) / 2 / power
+}
+
+// MassFlux returns the mass flux in kg/(m²·s) along the rods at the given core flow in kg/s.
+func (rod FuelRod) MassFlux(coreFlow float64) float64 {
+	if rod.Count == 0 {
+		return 0
+	}
+	return coreFlow / (float64(rod.Count) * rod.FlowArea)
+}
+
+// LHGRMargin returns how far the peak linear heat generation rate is below its limit, as a fraction of the limit.
+func (rod FuelRod) LHGRMargin() float64 {
+	return 1 - rod.PeakLinearHeatRate/linearHeatRateLimit
+}
+
+// MCPRMargin returns how far the MCPR is above its operating limit, as a fraction of the limit.
+func (rod FuelRod) MCPRMargin() float64 {
+	return rod.MCPR/operatingLimitMCPR - 1
+}
@@ -0,0 +1,58 @@
+package reactor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCriticalPowerRatio(t *testing.T) {
+	var rod FuelRod = newFuelRod(3000, 280)
+	rod.LinearHeatRate = ratedLinearHeatRate
+	var rated CoreCoolant = CoreCoolant{Pressure: 7000000, Flow: 3000 * ratedCoreFlowPerPower, InletSubcooling: 50000, LatentHeat: 1500000}
+
+	var tests = []struct {
+		name   string
+		modify func(coolant CoreCoolant) CoreCoolant
+		want   string // finite, nan or inf
+	}{
+		{"rated conditions", func(coolant CoreCoolant) CoreCoolant { return coolant }, "finite"},
+		{"no flow", func(coolant CoreCoolant) CoreCoolant { coolant.Flow = 0; return coolant }, "inf"},
+		{"supercritical", func(coolant CoreCoolant) CoreCoolant { coolant.Pressure = 25000000; return coolant }, "inf"},
+		{"pressure NaN", func(coolant CoreCoolant) CoreCoolant { coolant.Pressure = math.NaN(); return coolant }, "nan"},
+		{"pressure zero", func(coolant CoreCoolant) CoreCoolant { coolant.Pressure = 0; return coolant }, "nan"},
+		{"flow infinite", func(coolant CoreCoolant) CoreCoolant { coolant.Flow = math.Inf(1); return coolant }, "nan"},
+		{"latent heat zero", func(coolant CoreCoolant) CoreCoolant { coolant.LatentHeat = 0; return coolant }, "nan"},
+		{"latent heat negative", func(coolant CoreCoolant) CoreCoolant { coolant.LatentHeat = -1; return coolant }, "nan"},
+		{"subcooling NaN", func(coolant CoreCoolant) CoreCoolant { coolant.InletSubcooling = math.NaN(); return coolant }, "nan"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ratio float64 = rod.criticalPowerRatio(test.modify(rated))
+			switch test.want {
+			case "finite":
+				if math.IsNaN(ratio) || math.IsInf(ratio, 0) || ratio < 1 || ratio > 3 {
+					t.Errorf("critical power ratio = %v, want a finite value between 1 and 3", ratio)
+				}
+			case "inf":
+				if !math.IsInf(ratio, 1) {
+					t.Errorf("critical power ratio = %v, want +Inf", ratio)
+				}
+			case "nan":
+				if !math.IsNaN(ratio) {
+					t.Errorf("critical power ratio = %v, want NaN", ratio)
+				}
+			}
+		})
+	}
+}
+
+func TestCriticalPowerRatioFallsWithPower(t *testing.T) {
+	var rod FuelRod = newFuelRod(3000, 280)
+	var coolant CoreCoolant = CoreCoolant{Pressure: 7000000, Flow: 3000 * ratedCoreFlowPerPower, InletSubcooling: 50000, LatentHeat: 1500000}
+	rod.LinearHeatRate = ratedLinearHeatRate
+	var rated float64 = rod.criticalPowerRatio(coolant)
+	rod.LinearHeatRate = 1.1 * ratedLinearHeatRate
+	if overpower := rod.criticalPowerRatio(coolant); overpower >= rated {
+		t.Errorf("critical power ratio at 110%% power = %v, want below %v at 100%%", overpower, rated)
+	}
+}
@@ -0,0 +1,115 @@
+package reactor
+
+import "math"
+
+// --- STRUCT DECLARATIONS ---
+
+// DelayedNeutronData holds the kinetics parameters of the core: six delayed neutron precursor groups and the prompt
+// neutron generation time.
+type DelayedNeutronData struct {
+	Fractions      [6]float64 // β_i, fraction of the fission neutrons that are born delayed in each group
+	DecayConstants [6]float64 // λ_i in 1/s
+	GenerationTime float64    // Λ in s, mean time between the birth of a prompt neutron and the fission it causes
+}
+
+// --- CONSTANT DECLARATIONS ---
+const kineticsSubstep float64 = 0.001      // s, longest internal step of the point kinetics integration
+const promptGrowthPerSubstep float64 = 0.1 // most the prompt neutrons may grow per substep above prompt critical, in e-folds
+
+// DefaultDelayedNeutronData is Keepin's six-group data for thermal fission of U-235 with a generation time typical of
+// a BWR core.
+var DefaultDelayedNeutronData DelayedNeutronData = DelayedNeutronData{
+	Fractions:      [6]float64{0.000215, 0.001424, 0.001274, 0.002568, 0.000748, 0.000273},
+	DecayConstants: [6]float64{0.0124, 0.0305, 0.111, 0.301, 1.14, 3.01},
+	GenerationTime: 0.00004,
+}
+
+// Beta returns the total delayed neutron fraction β, the reactivity of one dollar.
+func (data DelayedNeutronData) Beta() (beta float64) {
+	for _, fraction := range data.Fractions {
+		beta += fraction
+	}
+	return
+}
+
+// PCMToReactivity converts a reactivity in pcm (per cent mille, 0.00001 Δk/k) to Δk/k.
+func PCMToReactivity(pcm float64) float64 {
+	return pcm / 100000
+}
+
+// ReactivityToPCM converts a reactivity in Δk/k to pcm.
+func ReactivityToPCM(reactivity float64) float64 {
+	return reactivity * 100000
+}
+
+// DollarsToReactivity converts a reactivity in dollars, multiples of β, to Δk/k.
+func (data DelayedNeutronData) DollarsToReactivity(dollars float64) float64 {
+	return dollars * data.Beta()
+}
+
+// ReactivityToDollars converts a reactivity in Δk/k to dollars. At one dollar the core is prompt critical.
+func (data DelayedNeutronData) ReactivityToDollars(reactivity float64) float64 {
+	return reactivity / data.Beta()
+}
+
+// equilibriumPrecursors returns the precursor concentrations in balance with a constant neutron density.
+func (data DelayedNeutronData) equilibriumPrecursors(neutronDensity float64) (precursors [6]float64) {
+	for i := range precursors {
+		precursors[i] = data.Fractions[i] * neutronDensity / (data.DecayConstants[i] * data.GenerationTime)
+	}
+	return
+}
+
+// neutronDensityRate returns dn/dt of the point kinetics equations, see integrateKinetics.
+func (data DelayedNeutronData) neutronDensityRate(neutronDensity float64, precursors [6]float64, reactivity float64, source float64) float64 {
+	var rate float64 = (reactivity-data.Beta())/data.GenerationTime*neutronDensity + source
+	for i := range precursors {
+		rate += data.DecayConstants[i] * precursors[i]
+	}
+	return rate
+}
+
+// integrateKinetics advances the point kinetics equations
+//
+//	dn/dt   = (ρ - β)/Λ · n + Σ λ_i·C_i + S
+//	dC_i/dt = β_i/Λ · n - λ_i·C_i
+//
+// over the given time at constant reactivity and source, and also returns the average neutron density over that time.
+// The prompt neutrons react on a scale of Λ/|ρ-β|, milliseconds or less, while the slowest precursors take about a
+// minute, so the equations are stiff. Over each substep the prompt term is integrated exactly with the delayed source
+// taken at the end of the substep, and the precursors with the backward Euler method; the equations are linear, so
+// the substep is solved by first eliminating the precursors. Below prompt critical this is stable at any step. Above
+// it the prompt neutrons grow by e^((ρ-β)/Λ·t), and the substeps are shortened so they grow by at most
+// promptGrowthPerSubstep e-folds in each, which keeps the coupling to the precursors accurate and the solution positive.
+// Otherwise the substeps are kineticsSubstep long, so the result does not depend on how long the caller's step is.
+func (data DelayedNeutronData) integrateKinetics(neutronDensity float64, precursors [6]float64, reactivity float64, source float64, deltaTimeSeconds float64) (float64, [6]float64, float64) {
+	var promptRate float64 = (reactivity - data.Beta()) / data.GenerationTime // 1/s
+	var longest float64 = kineticsSubstep
+	if promptRate > 0 {
+		longest = math.Min(longest, promptGrowthPerSubstep/promptRate)
+	}
+	var substeps int = int(math.Ceil(deltaTimeSeconds / longest))
+	var step float64 = deltaTimeSeconds / float64(substeps)
+	var promptGrowth float64 = math.Exp(promptRate * step)
+	var delayedWeight float64 = step // ∫ e^((ρ-β)/Λ·(step-τ)) dτ over the substep, the weight of a constant source
+	if promptRate != 0 {
+		delayedWeight = math.Expm1(promptRate*step) / promptRate
+	}
+	var average float64
+	for s := 0; s < substeps; s += 1 {
+		// C_i' = (C_i + step·β_i/Λ·n') / (1 + λ_i·step), so n' = e^((ρ-β)/Λ·step)·n + weight·(Σ λ_i·C_i' + S) is linear in n'
+		var constant float64 = promptGrowth*neutronDensity + delayedWeight*source
+		var coefficient float64 = 1
+		for i := range precursors {
+			var damping float64 = 1 + data.DecayConstants[i]*step
+			constant += delayedWeight * data.DecayConstants[i] * precursors[i] / damping
+			coefficient -= delayedWeight * data.DecayConstants[i] * step * data.Fractions[i] / (data.GenerationTime * damping)
+		}
+		neutronDensity = math.Max(constant/coefficient, 0)
+		average += neutronDensity / float64(substeps)
+		for i := range precursors {
+			precursors[i] = (precursors[i] + step*data.Fractions[i]/data.GenerationTime*neutronDensity) / (1 + data.DecayConstants[i]*step)
+		}
+	}
+	return neutronDensity, precursors, average
+}
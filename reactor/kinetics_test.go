@@ -0,0 +1,128 @@
+package reactor
+
+import (
+	"math"
+	"testing"
+)
+
+// inhourFrequency solves the inhour equation ρ = ω·Λ + Σ β_i·ω/(ω + λ_i) for the inverse stable period ω of a
+// positive reactivity below prompt critical.
+func inhourFrequency(data DelayedNeutronData, reactivity float64) float64 {
+	var low, high float64 = 0, 1 / data.GenerationTime
+	for i := 0; i < 200; i += 1 {
+		var omega float64 = (low + high) / 2
+		var rho float64 = omega * data.GenerationTime
+		for g := range data.Fractions {
+			rho += data.Fractions[g] * omega / (omega + data.DecayConstants[g])
+		}
+		if rho > reactivity {
+			high = omega
+		} else {
+			low = omega
+		}
+	}
+	return (low + high) / 2
+}
+
+func TestReactivityUnits(t *testing.T) {
+	var data DelayedNeutronData = DefaultDelayedNeutronData
+	if beta := data.Beta(); math.Abs(beta-0.006502) > 1e-9 {
+		t.Errorf("β = %v, want 0.006502", beta)
+	}
+	var tests = []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"100 pcm", PCMToReactivity(100), 0.001},
+		{"0.001 Δk/k", ReactivityToPCM(0.001), 100},
+		{"one dollar", data.DollarsToReactivity(1), data.Beta()},
+		{"β in dollars", data.ReactivityToDollars(data.Beta()), 1},
+		{"round trip", data.ReactivityToDollars(data.DollarsToReactivity(0.37)), 0.37},
+	}
+	for _, test := range tests {
+		if math.Abs(test.got-test.want) > 1e-12 {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestKinetics(t *testing.T) {
+	var data DelayedNeutronData = DefaultDelayedNeutronData
+	var beta float64 = data.Beta()
+
+	t.Run("critical core holds its power", func(t *testing.T) {
+		var density, _, _ = data.integrateKinetics(1, data.equilibriumPrecursors(1), 0, 0, 100)
+		if math.Abs(density-1) > 1e-9 {
+			t.Errorf("neutron density after 100 s = %v, want 1", density)
+		}
+	})
+
+	t.Run("prompt jump", func(t *testing.T) {
+		// after a few prompt lifetimes Λ/(β-ρ), but before the precursors change, n jumps to β/(β-ρ)
+		for _, dollars := range []float64{0.2, 0.5, -0.5} {
+			var reactivity float64 = data.DollarsToReactivity(dollars)
+			var density, _, _ = data.integrateKinetics(1, data.equilibriumPrecursors(1), reactivity, 0, 0.1)
+			var want float64 = beta / (beta - reactivity)
+			if math.Abs(density-want) > 0.03*want {
+				t.Errorf("%v $: neutron density after the prompt jump = %v, want %v", dollars, density, want)
+			}
+		}
+	})
+
+	t.Run("stable period", func(t *testing.T) {
+		for _, pcm := range []float64{50, 100, 300} {
+			var reactivity float64 = PCMToReactivity(pcm)
+			var density, precursors, _ = data.integrateKinetics(1, data.equilibriumPrecursors(1), reactivity, 0, 600)
+			var later, _, _ = data.integrateKinetics(density, precursors, reactivity, 0, 10)
+			var omega float64 = math.Log(later/density) / 10
+			var want float64 = inhourFrequency(data, reactivity)
+			if math.Abs(omega-want) > 0.01*want {
+				t.Errorf("%v pcm: stable period %v s, want %v s from the inhour equation", pcm, 1/omega, 1/want)
+			}
+		}
+	})
+
+	t.Run("source multiplication", func(t *testing.T) {
+		// a subcritical core with a source settles at n = -S·Λ/ρ
+		var reactivity, source float64 = data.DollarsToReactivity(-2), 1e-6
+		var density, _, _ = data.integrateKinetics(0, [6]float64{}, reactivity, source, 3000)
+		var want float64 = -source * data.GenerationTime / reactivity
+		if math.Abs(density-want) > 1e-3*want {
+			t.Errorf("neutron density = %v, want %v", density, want)
+		}
+	})
+
+	t.Run("independent of the step", func(t *testing.T) {
+		var reactivity float64 = PCMToReactivity(200)
+		var once, _, _ = data.integrateKinetics(1, data.equilibriumPrecursors(1), reactivity, 0, 20)
+		var density, precursors = 1.0, data.equilibriumPrecursors(1)
+		for i := 0; i < 200; i += 1 {
+			density, precursors, _ = data.integrateKinetics(density, precursors, reactivity, 0, 0.1)
+		}
+		if math.Abs(density-once) > 1e-9*once {
+			t.Errorf("neutron density after 200 steps of 0.1 s = %v, after one step of 20 s = %v", density, once)
+		}
+	})
+
+	t.Run("above prompt critical", func(t *testing.T) {
+		// the neutron density grows with the prompt period from the inhour equation, at any step of the caller
+		for _, reactivity := range []float64{0.01, 0.03, 0.05, 0.08} {
+			var omega float64 = inhourFrequency(data, reactivity)
+			for _, step := range []float64{0.001, 0.1} {
+				var density, precursors, _ = data.integrateKinetics(1, data.equilibriumPrecursors(1), reactivity, 0, 10/omega)
+				if density <= 1 {
+					t.Fatalf("ρ = %v: neutron density fell to %v", reactivity, density)
+				}
+				var later float64 = density
+				var pieces int = int(math.Ceil(2 / omega / step))
+				for i := 0; i < pieces; i += 1 {
+					later, precursors, _ = data.integrateKinetics(later, precursors, reactivity, 0, 2/omega/float64(pieces))
+				}
+				if growth, want := later/density, math.Exp(2); math.Abs(growth-want) > 0.01*want {
+					t.Errorf("ρ = %v, steps of %v s: growth over two prompt periods = %v, want %v", reactivity, step, growth, want)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package reactor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEquilibriumPoisons(t *testing.T) {
+	var hour float64 = 3600
+	for _, neutronDensity := range []float64{0.5, 1} {
+		var poisons FissionProductPoisons = FissionProductPoisons{}.simulate(neutronDensity, 20000*hour)
+		var flux float64 = ratedThermalFlux * neutronDensity
+		var fissionRate float64 = xenonCrossSection * flux / neutronsPerFission // scaled like the concentrations
+
+		var tests = []struct {
+			name string
+			got  float64
+			want float64
+		}{
+			{"iodine", poisons.Iodine, iodineYield * fissionRate / iodineDecay},
+			{"xenon", poisons.Xenon, (iodineYield + xenonYield) * fissionRate / (xenonDecay + xenonCrossSection*flux)},
+			{"samarium", poisons.Samarium, promethiumYield / neutronsPerFission}, // the same at any power
+		}
+		for _, test := range tests {
+			if math.Abs(test.got-test.want) > 1e-6*test.want {
+				t.Errorf("%v%% power: equilibrium %s = %v, want %v", 100*neutronDensity, test.name, test.got, test.want)
+			}
+		}
+	}
+
+	// full power equilibrium xenon is worth a few per cent Δk/k
+	var xenon, _ = FissionProductPoisons{}.simulate(1, 2000*hour).Reactivity()
+	if xenon > -0.015 || xenon < -0.035 {
+		t.Errorf("equilibrium xenon reactivity = %v, want between -0.035 and -0.015", xenon)
+	}
+}
+
+func TestXenonAfterShutdown(t *testing.T) {
+	var hour float64 = 3600
+	var equilibrium FissionProductPoisons = FissionProductPoisons{}.simulate(1, 2000*hour)
+
+	// without flux the xenon follows X(t) = X0·e^(-λX·t) + I0·λI/(λX-λI)·(e^(-λI·t) - e^(-λX·t))
+	var peak, peakTime float64
+	for step := 1; step <= 48; step += 1 {
+		var elapsed float64 = float64(step) * hour
+		var want float64 = equilibrium.Xenon*math.Exp(-xenonDecay*elapsed) +
+			equilibrium.Iodine*iodineDecay/(xenonDecay-iodineDecay)*(math.Exp(-iodineDecay*elapsed)-math.Exp(-xenonDecay*elapsed))
+		var got float64 = equilibrium.simulate(0, elapsed).Xenon
+		if math.Abs(got-want) > 1e-9*want {
+			t.Errorf("xenon %d h after shutdown = %v, want %v", step, got, want)
+		}
+		if got > peak {
+			peak, peakTime = got, elapsed
+		}
+	}
+	if peakTime < 8*hour || peakTime > 13*hour || peak < equilibrium.Xenon {
+		t.Errorf("xenon peaks %v h after shutdown at %v times its equilibrium, want a peak above equilibrium 8 to 13 h after", peakTime/hour, peak/equilibrium.Xenon)
+	}
+}
+
+func TestPoisonsIndependentOfStep(t *testing.T) {
+	var hour float64 = 3600
+	var once FissionProductPoisons = FissionProductPoisons{}.simulate(1, 30*hour)
+	var stepped FissionProductPoisons
+	for i := 0; i < 30*3600; i += 1 {
+		stepped = stepped.simulate(1, 1)
+	}
+	for name, pair := range map[string][2]float64{
+		"iodine":     {stepped.Iodine, once.Iodine},
+		"xenon":      {stepped.Xenon, once.Xenon},
+		"promethium": {stepped.Promethium, once.Promethium},
+		"samarium":   {stepped.Samarium, once.Samarium},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9*pair[1] {
+			t.Errorf("%s after 30 h in steps of 1 s = %v, in one step = %v", name, pair[0], pair[1])
+		}
+	}
+}
+
+func TestDecayChainWithoutDecay(t *testing.T) {
+	// with nothing decaying or removed the chain only accumulates its sources
+	var parent, daughter = decayChain(1, 2, 0, 3, 4, 0, 5)
+	if parent != 11 || daughter != 23 {
+		t.Errorf("decay chain = %v, %v, want 11, 23", parent, daughter)
+	}
+}
@@ -1,36 +1,193 @@
 package reactor
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
 // --- CONSTANT DECLARATIONS ---
-const MaxNeutrons int64 = 100000000000 // The amount of neutrons in the reactor core at 100% thermal power.
-
-// --- VARIABLE DECLARATIONS ---
-var CurrentNeutrons int64 = 0
-var IdleNeutrons int64 = 1000 // Reactor needs some starting neutrons to start.
-var OldNeutrons int64 = 0     // The amount of neutrons in the previous event loop iteration.
+const excessReactivity float64 = 0.12     // Δk/k of the cold core with all rods withdrawn
+const totalRodWorth float64 = 0.3         // Δk/k the rods take away when fully inserted
+const sourceNeutronDensity float64 = 1e-8 // neutron density the startup sources hold in the shut down core, relative to rated
 
 // --- STRUCT DECLARATIONS ---
 type Reactor struct {
-	RodsPulled float64
+	RatedThermalPower float64 // MW at 100% thermal power
+	Rods              ControlRods
+	Kinetics          DelayedNeutronData
+	Feedback          ReactivityFeedback
+
+	VoidFraction         float64     // vapour volume fraction of the coolant in the core, set from the fluid state every step
+	ModeratorTemperature float64     // degrees Celsius, set from the fluid state every step
+	Coolant              CoreCoolant // set from the fluid state every step
+	Fuel                 FuelRod
+
+	NeutronDensity      float64               // relative to rated, 1 at 100% thermal power
+	Precursors          [6]float64            // delayed neutron precursor concentrations, in neutron density units
+	DecayHeat           [23]float64           // fission product decay power of each ANS-5.1 group as a fraction of rated
+	SourceStrength      float64               // neutron density per second the startup sources add, lets a shut down core be monitored and restarted
+	ExternalReactivity  float64               // Δk/k inserted from outside the core model, e.g. by a test or an instructor
+	VoidReactivity      float64               // Δk/k the core void fraction added during the last step
+	DopplerReactivity   float64               // Δk/k the fuel temperature added during the last step
+	ModeratorReactivity float64               // Δk/k the moderator temperature added during the last step
+	Poisons             FissionProductPoisons // I-135/Xe-135 and Pm-149/Sm-149 in the core
+	XenonReactivity     float64               // Δk/k the xenon added during the last step
+	SamariumReactivity  float64               // Δk/k the samarium added during the last step
+	Reactivity          float64               // Δk/k, total reactivity during the last step
+	Period              float64               // s, the time the neutron density takes to grow by a factor of e. Negative while it falls, +Inf when steady
+}
+
+// NewReactor returns a reactor core with the given rated thermal power in MW in its startup configuration: all rods
+// inserted and the neutron density at the level the startup sources hold it at.
+func NewReactor(ratedThermalPower float64) *Reactor {
+	var reactor *Reactor = &Reactor{
+		RatedThermalPower: ratedThermalPower,
+		Rods:              newControlRods(),
+		Kinetics:          DefaultDelayedNeutronData,
+		Feedback:          DefaultReactivityFeedback(),
+		Period:            math.Inf(1),
+	}
+	// cold and unvoided, the state the default coefficients are referenced to
+	reactor.ModeratorTemperature = reactor.Feedback.Moderator.Reference
+	reactor.Fuel = newFuelRod(ratedThermalPower, reactor.ModeratorTemperature)
+	reactor.Coolant = CoreCoolant{
+		Pressure:                101325,
+		Flow:                    ratedCoreFlowPerPower * ratedThermalPower,
+		LatentHeat:              2257000,
+		HeatTransferCoefficient: 30000,
+	}
+	reactor.Reactivity = reactor.RodReactivity()
+	reactor.SourceStrength = sourceNeutronDensity * -reactor.Reactivity / reactor.Kinetics.GenerationTime
+	reactor.NeutronDensity = sourceNeutronDensity
+	// The source keeps the subcritical core at n = S·Λ/-ρ, and the precursors are in balance with it.
+	reactor.Precursors = reactor.Kinetics.equilibriumPrecursors(reactor.NeutronDensity)
+	return reactor
 }
 
-// --- STRUCT INITIALIZATIONS ---
-var ReactorState Reactor
+// RodReactivity returns the reactivity in Δk/k the control rods leave in the core at their current positions.
+func (reactor *Reactor) RodReactivity() float64 {
+	return excessReactivity + reactor.Rods.reactivity()
+}
 
-func SetupReactor() {
-	ReactorState.RodsPulled = 0.5
+// RodsPulled returns the average withdrawn fraction of the control rods, 0 with all rods inserted and 1 with all rods
+// withdrawn.
+func (reactor *Reactor) RodsPulled() float64 {
+	return reactor.Rods.withdrawnFraction()
 }
 
-func SimulateFission() {
-	var FissionFactors float64 = 2 * (0.2 + ReactorState.RodsPulled*0.8)
-	OldNeutrons = CurrentNeutrons
-	CurrentNeutrons = int64(math.Round(FissionFactors * (float64(OldNeutrons) + float64(IdleNeutrons))))
-	if CurrentNeutrons > MaxNeutrons {
-		ReactorState.RodsPulled = 0 //scram
+// MoveRod starts driving a control rod to the given position, an even one from 00 fully inserted to 48 fully
+// withdrawn where the drive can latch the rod. It fails while the reactor is scrammed and when the rod worth minimizer
+// blocks the move.
+func (reactor *Reactor) MoveRod(id string, position int) error {
+	if _, ok := reactor.Rods.Rods[id]; !ok {
+		return fmt.Errorf("rod %q does not exist", id)
+	}
+	if position < 0 || position > fullyWithdrawn {
+		return fmt.Errorf("rod %q: position %02d is outside 00 to %02d", id, position, fullyWithdrawn)
+	}
+	if position%notchLength != 0 {
+		return fmt.Errorf("rod %q: position %02d is between two notches, the drive only latches at even positions", id, position)
 	}
+	if reactor.Rods.Scrammed {
+		return fmt.Errorf("rod %q: rod block, the scram has not been reset", id)
+	}
+	if err := reactor.Rods.checkMove(id, position, reactor.CalculateThermalPower()); err != nil {
+		return err
+	}
+	var rod ControlRod = reactor.Rods.Rods[id]
+	rod.Target = position
+	reactor.Rods.Rods[id] = rod
+	return nil
+}
+
+// WithdrawRod withdraws a control rod by one notch from where it is headed.
+func (reactor *Reactor) WithdrawRod(id string) error {
+	return reactor.MoveRod(id, reactor.Rods.Rods[id].Target+notchLength)
+}
+
+// InsertRod inserts a control rod by one notch from where it is headed.
+func (reactor *Reactor) InsertRod(id string) error {
+	return reactor.MoveRod(id, reactor.Rods.Rods[id].Target-notchLength)
+}
+
+// Scram drives every control rod fully in at scram speed.
+func (reactor *Reactor) Scram() {
+	reactor.Rods.Scrammed = true
+}
+
+// ResetScram allows the control rods to be moved again once the scram has inserted them all.
+func (reactor *Reactor) ResetScram() error {
+	var withdrawn int
+	for _, rod := range reactor.Rods.Rods {
+		if rod.Position > 0 {
+			withdrawn += 1
+		}
+	}
+	if withdrawn > 0 {
+		return fmt.Errorf("%d rods are not fully inserted yet", withdrawn)
+	}
+	reactor.Rods.Scrammed = false
+	return nil
+}
+
+// SimulateFission works out the reactivity from the rods and the state of the core, then advances the neutron density
+// and the delayed neutron precursors over one time step with the point kinetics equations, see integrateKinetics, the
+// fission product poisons and decay heat at the average neutron density of the step, and the fuel rods with the
+// resulting thermal power. VoidFraction, ModeratorTemperature and Coolant should be set from the fluid before.
+func (reactor *Reactor) SimulateFission(deltaTime time.Duration) {
+	reactor.Rods = reactor.Rods.simulate(deltaTime.Seconds())
+	reactor.VoidReactivity = reactor.Feedback.Void.Reactivity(reactor.VoidFraction * 100)
+	reactor.DopplerReactivity = reactor.Feedback.Doppler.Reactivity(reactor.Fuel.AverageTemperature)
+	reactor.ModeratorReactivity = reactor.Feedback.Moderator.Reactivity(reactor.ModeratorTemperature)
+	reactor.XenonReactivity, reactor.SamariumReactivity = reactor.Poisons.Reactivity()
+	reactor.Reactivity = reactor.RodReactivity() + reactor.VoidReactivity + reactor.DopplerReactivity + reactor.ModeratorReactivity +
+		reactor.XenonReactivity + reactor.SamariumReactivity + reactor.ExternalReactivity
+	var averageNeutronDensity float64
+	reactor.NeutronDensity, reactor.Precursors, averageNeutronDensity = reactor.Kinetics.integrateKinetics(reactor.NeutronDensity, reactor.Precursors, reactor.Reactivity, reactor.SourceStrength, deltaTime.Seconds())
+	reactor.Poisons = reactor.Poisons.simulate(averageNeutronDensity, deltaTime.Seconds())
+	reactor.DecayHeat = simulateDecayHeat(reactor.DecayHeat, averageNeutronDensity, deltaTime.Seconds())
+	reactor.Fuel = reactor.Fuel.simulate(reactor.ThermalPower()*1000000, reactor.ModeratorTemperature, reactor.Coolant, deltaTime)
+
+	// The period is taken from the rate of change at the end of the step so it does not depend on the step length.
+	reactor.Period = math.Inf(1)
+	if rate := reactor.Kinetics.neutronDensityRate(reactor.NeutronDensity, reactor.Precursors, reactor.Reactivity, reactor.SourceStrength); rate != 0 && reactor.NeutronDensity > 0 {
+		reactor.Period = reactor.NeutronDensity / rate
+	}
+}
+
+// CalculateThermalPower returns the thermal power as a fraction of rated: the heat released at fission, which follows
+// the neutron density, plus the decay heat of the fission products, which lingers after a shutdown. At steady power the
+// two add up to the neutron density.
+func (reactor *Reactor) CalculateThermalPower() float64 {
+	return reactor.NeutronDensity*(1-saturatedDecayHeatFraction()) + reactor.DecayHeatFraction()
+}
+
+// DecayHeatFraction returns the decay power of the fission products as a fraction of rated.
+func (reactor *Reactor) DecayHeatFraction() (fraction float64) {
+	for _, group := range reactor.DecayHeat {
+		fraction += group
+	}
+	return
+}
+
+// ThermalPower returns the heat the core currently gives off to the coolant in MW, including the decay heat.
+func (reactor *Reactor) ThermalPower() float64 {
+	return reactor.CalculateThermalPower() * reactor.RatedThermalPower
+}
+
+// HeatToCoolant returns the heat in MW the coolant received during the last step: what the fuel rods conducted into it
+// plus the share of the thermal power deposited in it directly.
+func (reactor *Reactor) HeatToCoolant() float64 {
+	return reactor.Fuel.HeatFlow/1000000 + directModeratorHeating*reactor.ThermalPower()
+}
+
+// ReactivityPCM returns the total reactivity of the last step in pcm.
+func (reactor *Reactor) ReactivityPCM() float64 {
+	return ReactivityToPCM(reactor.Reactivity)
 }
 
-func CalculateThermalPower() float64 {
-	return float64(CurrentNeutrons) / float64(MaxNeutrons)
+// ReactivityDollars returns the total reactivity of the last step in dollars.
+func (reactor *Reactor) ReactivityDollars() float64 {
+	return reactor.Kinetics.ReactivityToDollars(reactor.Reactivity)
 }
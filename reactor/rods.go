@@ -0,0 +1,165 @@
+package reactor
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// --- STRUCT DECLARATIONS ---
+
+// ControlRod is one control rod and its drive. Positions are in units of 3 inches, from 00 with the rod fully inserted
+// to 48 with it fully withdrawn over the 12 feet of the core. The drive latches the rod every notch of two units, so
+// it comes to rest at the even positions 00, 02, ..., 48.
+type ControlRod struct {
+	Position float64 // 3-inch units, fractional while the drive moves
+	Target   int     // even position the drive is moving the rod to
+	Worth    float64 // Δk/k the rod takes out of the core when fully inserted, higher near the centre where the flux is
+}
+
+// SequenceStep is one step of the withdrawal sequence: its rods may be withdrawn from position From to position To,
+// and the step is done once all of them are at To.
+type SequenceStep struct {
+	Rods []string
+	From int
+	To   int
+}
+
+// ControlRods is the core map of control rods, keyed by their core coordinates such as "30-31", together with the
+// withdrawal sequence the rod worth minimizer enforces and the state of the scram.
+type ControlRods struct {
+	Rods        map[string]ControlRod
+	Sequence    []SequenceStep
+	Scrammed    bool // the scram drives every rod in and rod motion is blocked until the scram is reset
+	RWMBypassed bool // the rod worth minimizer no longer enforces the sequence
+}
+
+// --- CONSTANT DECLARATIONS ---
+const fullyWithdrawn int = 48
+const notchLength int = 2                  // position units the drive moves the rod between two latches, 6 inches
+const driveSpeed float64 = 1               // position units per second, 3 inches per second
+const scramInsertionTime float64 = 3       // s from fully withdrawn to fully inserted
+const rwmLowPowerSetpoint float64 = 0.1    // the rod worth minimizer enforces the sequence below this fraction of rated power
+const coreMapPitch int = 4                 // coordinate units between neighbouring rods
+const coreMapSize int = 15                 // rods across the square the core map is cut from
+const coreMapRadiusSquared int = 58        // in rod pitches squared, cuts 185 rods out of the square
+const rodWorthEdgeImportance float64 = 0.2 // worth of a rod at the edge of the core relative to one in the centre
+
+// newControlRods returns the core map of a 185 rod BWR core with every rod fully inserted and a banked position
+// withdrawal sequence: the rods are split into four checkerboard sets, the first two are withdrawn fully one after the
+// other, and the other two are then withdrawn in banks of two notches at a time.
+func newControlRods() ControlRods {
+	var rods ControlRods = ControlRods{Rods: make(map[string]ControlRod)}
+	var sets [4][]string
+	var setOrder [4]int = [4]int{0, 2, 3, 1} // (even, even) and (odd, odd) rods form the first checkerboard
+	var centre int = coreMapSize / 2
+	var importance map[string]float64 = make(map[string]float64)
+	var totalImportance float64
+	for i := 0; i < coreMapSize; i += 1 {
+		for j := 0; j < coreMapSize; j += 1 {
+			var distanceSquared int = (i-centre)*(i-centre) + (j-centre)*(j-centre)
+			if distanceSquared > coreMapRadiusSquared {
+				continue
+			}
+			var id string = fmt.Sprintf("%02d-%02d", 2+coreMapPitch*i, 3+coreMapPitch*j)
+			importance[id] = 1 - (1-rodWorthEdgeImportance)*float64(distanceSquared)/float64(coreMapRadiusSquared)
+			totalImportance += importance[id]
+			var set int = setOrder[2*(i%2)+j%2]
+			sets[set] = append(sets[set], id)
+		}
+	}
+	for id, weight := range importance {
+		rods.Rods[id] = ControlRod{Worth: totalRodWorth * weight / totalImportance}
+	}
+	rods.Sequence = []SequenceStep{{sets[0], 0, 48}, {sets[1], 0, 48}}
+	for _, bank := range [][2]int{{0, 4}, {4, 8}, {8, 12}, {12, 48}} {
+		rods.Sequence = append(rods.Sequence, SequenceStep{sets[2], bank[0], bank[1]}, SequenceStep{sets[3], bank[0], bank[1]})
+	}
+	return rods
+}
+
+// integralRodWorth returns the share of its worth a rod takes out of the core at the given withdrawn fraction. The
+// flux peaks in the middle of the core, so a rod is worth little at the ends of its travel and most in the middle.
+func integralRodWorth(withdrawn float64) float64 {
+	return 1 - withdrawn + math.Sin(2*math.Pi*withdrawn)/(2*math.Pi)
+}
+
+// ids returns the IDs of all rods in sorted order, so sums over the rods come out the same on every run.
+func (rods ControlRods) ids() []string {
+	return slices.Sorted(maps.Keys(rods.Rods))
+}
+
+// reactivity returns the reactivity in Δk/k the rods take out of the core.
+func (rods ControlRods) reactivity() (reactivity float64) {
+	for _, id := range rods.ids() {
+		var rod ControlRod = rods.Rods[id]
+		reactivity -= rod.Worth * integralRodWorth(rod.Position/float64(fullyWithdrawn))
+	}
+	return
+}
+
+// withdrawnFraction returns the average withdrawn fraction of all rods, 0 with all rods inserted and 1 with all rods
+// withdrawn.
+func (rods ControlRods) withdrawnFraction() float64 {
+	if len(rods.Rods) == 0 {
+		return 0
+	}
+	var total float64
+	for _, id := range rods.ids() {
+		total += rods.Rods[id].Position / float64(fullyWithdrawn)
+	}
+	return total / float64(len(rods.Rods))
+}
+
+// simulate drives every rod towards its target at drive speed, or in at scram speed during a scram.
+func (rods ControlRods) simulate(deltaTimeSeconds float64) ControlRods {
+	for id, rod := range rods.Rods {
+		if rods.Scrammed {
+			rod.Target = 0
+			rod.Position = math.Max(rod.Position-float64(fullyWithdrawn)/scramInsertionTime*deltaTimeSeconds, 0)
+		} else if difference := float64(rod.Target) - rod.Position; difference != 0 {
+			rod.Position += math.Copysign(math.Min(math.Abs(difference), driveSpeed*deltaTimeSeconds), difference)
+		}
+		rods.Rods[id] = rod
+	}
+	return rods
+}
+
+// currentStep returns the index of the first step of the sequence that is not done, or the length of the sequence
+// once all are.
+func (rods ControlRods) currentStep() int {
+	for i, step := range rods.Sequence {
+		for _, id := range step.Rods {
+			if rods.Rods[id].Target < step.To || rods.Rods[id].Position < float64(step.To) {
+				return i
+			}
+		}
+	}
+	return len(rods.Sequence)
+}
+
+// checkMove returns an error if the rod worth minimizer blocks moving the rod to the position: below its low power
+// setpoint, rods may only be withdrawn within the current step of the sequence, and inserted within the current step
+// or back into the one before it.
+func (rods ControlRods) checkMove(id string, position int, thermalPower float64) error {
+	var rod ControlRod = rods.Rods[id]
+	if rods.RWMBypassed || thermalPower >= rwmLowPowerSetpoint || position == rod.Target {
+		return nil
+	}
+	var current int = rods.currentStep()
+	var allowed []string
+	for i := max(current-1, 0); i <= min(current, len(rods.Sequence)-1); i += 1 {
+		var step SequenceStep = rods.Sequence[i]
+		var inserting bool = position < rod.Target
+		if i < current && !inserting {
+			continue // a finished step can only be undone
+		}
+		if slices.Contains(step.Rods, id) && position >= step.From && position <= step.To {
+			return nil
+		}
+		allowed = append(allowed, fmt.Sprintf("step %d: %d rods between position %02d and %02d", i+1, len(step.Rods), step.From, step.To))
+	}
+	return fmt.Errorf("rod %q: rod block, the rod worth minimizer only allows %s", id, strings.Join(allowed, " or "))
+}
@@ -0,0 +1,61 @@
+package reactor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRodNotches(t *testing.T) {
+	var reactor *Reactor = NewReactor(3000)
+	var id string = reactor.Rods.Sequence[0].Rods[0]
+
+	if err := reactor.WithdrawRod(id); err != nil {
+		t.Fatalf("WithdrawRod: %v", err)
+	}
+	if target := reactor.Rods.Rods[id].Target; target != 2 {
+		t.Errorf("target after one notch out = %02d, want 02", target)
+	}
+	for i := 0; i < 20; i += 1 {
+		reactor.Rods = reactor.Rods.simulate((100 * time.Millisecond).Seconds())
+	}
+	if position := reactor.Rods.Rods[id].Position; position != 2 {
+		t.Errorf("position after 2 s = %v, want 2 at 3 inches per second", position)
+	}
+	if err := reactor.InsertRod(id); err != nil {
+		t.Fatalf("InsertRod: %v", err)
+	}
+	if target := reactor.Rods.Rods[id].Target; target != 0 {
+		t.Errorf("target after one notch in = %02d, want 00", target)
+	}
+
+	var tests = []struct {
+		position int
+		valid    bool
+	}{
+		{0, true}, {1, false}, {12, true}, {13, false}, {48, true}, {49, false}, {-2, false},
+	}
+	for _, test := range tests {
+		if err := reactor.MoveRod(id, test.position); (err == nil) != test.valid {
+			t.Errorf("MoveRod(%02d) error = %v, want valid %v", test.position, err, test.valid)
+		}
+	}
+}
+
+func TestRodReactivityIsDeterministic(t *testing.T) {
+	var reactor *Reactor = NewReactor(3000)
+	var i int
+	for id, rod := range reactor.Rods.Rods {
+		rod.Position = float64(i % (fullyWithdrawn + 1))
+		reactor.Rods.Rods[id] = rod
+		i += 7
+	}
+	var reactivity, pulled float64 = reactor.RodReactivity(), reactor.RodsPulled()
+	for i := 0; i < 200; i += 1 {
+		if got := reactor.RodReactivity(); got != reactivity {
+			t.Fatalf("rod reactivity %v, %v on an earlier call with the same rods", got, reactivity)
+		}
+		if got := reactor.RodsPulled(); got != pulled {
+			t.Fatalf("rods pulled %v, %v on an earlier call with the same rods", got, pulled)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package simulation ties a plant together: the fluid network of a plant model, its reactor core, the nuclear
+// instruments and the reactor protection system, advanced together by [Simulation.Step].
+//
+// A plant is described by a [PlantModel]: the fluid network of a fluid.PlantModel together with a reactor section,
+// which names the core node and the rated thermal power and may replace the reactivity coefficient tables, and a
+// protection section with the trip setpoints and the valves and nodes the trips read. [LoadPlantModel] reads one from
+// a JSON file, and [DefaultPlantModel] returns the built-in plant.
+//
+// The core heats the coolant of the core node of the model every step, and reads its void fraction and temperatures
+// back for the reactivity feedback. The built-in plant drives its core flow the way a BWR does. Two recirculation
+// loops draw water from the downcomer, and each has a variable-speed pump and a flow control valve that feed the
+// nozzles of its jet pumps. The core flow is the sum of the jet pump flows, and power is manoeuvred with
+// SetPumpSpeedDemand and SetValvePosition on the recirculation loops.
+//
+// Two APRMs are assigned to each channel of trip system A and one to each channel of trip system B, so a single failed
+// APRM can only trip one trip system. [Simulation.ManualScram] scrams by hand. [Simulation.ResetScram] resets both
+// the protection system and the rods, and is refused while a trip is still present or a rod is still out.
+package simulation
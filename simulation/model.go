@@ -0,0 +1,148 @@
+package simulation
+
+import (
+	"GoBWR/fluid"
+	"errors"
+	"fmt"
+)
+
+// --- CONSTANT DECLARATIONS ---
+const defaultRatedThermalPower float64 = 3293 // MW, the core of the built-in plant
+const defaultCoreNode string = "ReactorVessel"
+
+// --- STRUCT DECLARATIONS ---
+
+// PlantModel is the declarative description of a whole plant: the fluid network, see fluid.PlantModel, together with
+// the reactor core and its protection system. All of it is read from a single JSON model file, the sections of the
+// fluid network at the top level beside the reactor and protection sections.
+type PlantModel struct {
+	fluid.PlantModel
+	Reactor    *ReactorModel    `json:"reactor"`    // the core, a plant without it produces no fission heat
+	Protection *ProtectionModel `json:"protection"` // trip setpoints and sensors of the reactor protection system
+}
+
+// ReactorModel configures the reactor core and the node its heat is deposited in.
+type ReactorModel struct {
+	RatedThermalPower *float64 `json:"ratedThermalPower"` // MW at 100 % power
+	CoreNode          string   `json:"coreNode"`          // the node the core heats, e.g. ReactorVessel
+
+	VoidCoefficient      *CoefficientTableModel `json:"voidCoefficient"`      // pcm per % over the core void fraction in %, reactor.DefaultReactivityFeedback if omitted
+	DopplerCoefficient   *CoefficientTableModel `json:"dopplerCoefficient"`   // pcm/K over the average fuel temperature in degrees Celsius
+	ModeratorCoefficient *CoefficientTableModel `json:"moderatorCoefficient"` // pcm/K over the moderator temperature in degrees Celsius
+}
+
+// CoefficientTableModel describes a reactivity coefficient table, see reactor.CoefficientTable.
+type CoefficientTableModel struct {
+	Reference    *float64  `json:"reference"`    // state at which the table adds no reactivity
+	Points       []float64 `json:"points"`       // states in ascending order
+	Coefficients []float64 `json:"coefficients"` // pcm per unit of state at each point
+}
+
+// ProtectionModel configures the reactor protection system. The vessel is the core node of the reactor. Setpoints left
+// out keep their defaults, and the level, MSIV, drywell and turbine trips are only enabled once their setpoint or
+// sensors are given.
+type ProtectionModel struct {
+	HighNeutronFlux     *float64 `json:"highNeutronFlux"`     // fraction of rated power, 1.18 if omitted
+	HighVesselPressure  *float64 `json:"highVesselPressure"`  // Pa, 7480000 if omitted
+	LowWaterLevel       *float64 `json:"lowWaterLevel"`       // meters above the bottom of the vessel
+	HighWaterLevel      *float64 `json:"highWaterLevel"`      // meters above the bottom of the vessel
+	MSIVs               []string `json:"msivs"`               // valves whose closure trips the reactor
+	DrywellNode         string   `json:"drywellNode"`         // the node whose pressure is the drywell pressure
+	HighDrywellPressure *float64 `json:"highDrywellPressure"` // Pa, 113000 if omitted
+	TurbineStopValves   []string `json:"turbineStopValves"`   // valves whose closure is a turbine trip
+}
+
+// DefaultPlantModel returns the built-in test plant: the built-in fluid network with its core in the reactor vessel.
+func DefaultPlantModel() PlantModel {
+	var ratedThermalPower float64 = defaultRatedThermalPower
+	return PlantModel{
+		PlantModel: fluid.DefaultPlantModel(),
+		Reactor:    &ReactorModel{RatedThermalPower: &ratedThermalPower, CoreNode: defaultCoreNode},
+	}
+}
+
+// LoadPlantModel reads and validates a plant model file.
+func LoadPlantModel(path string) (PlantModel, error) {
+	return fluid.LoadModel(path, ParsePlantModel)
+}
+
+// ParsePlantModel decodes and validates a JSON plant model, see fluid.DecodeModel.
+func ParsePlantModel(data []byte) (PlantModel, error) {
+	return fluid.DecodeModel[PlantModel](data)
+}
+
+// Validate checks the fluid network of the model, see fluid.PlantModel.Validate, and the reactor and protection
+// sections against it, and reports all problems found at once.
+func (model PlantModel) Validate() error {
+	var problems []error
+	var problem = func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+	if err := model.PlantModel.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+
+	if reactor := model.Reactor; reactor != nil {
+		if reactor.RatedThermalPower == nil {
+			problem("reactor: ratedThermalPower is missing")
+		} else if *reactor.RatedThermalPower <= 0 {
+			problem("reactor: ratedThermalPower must be positive, got %g", *reactor.RatedThermalPower)
+		}
+		if reactor.CoreNode == "" {
+			problem("reactor: coreNode is missing")
+		} else if _, ok := model.Nodes[reactor.CoreNode]; !ok {
+			problem("reactor: coreNode %q does not exist", reactor.CoreNode)
+		}
+		var table = func(name string, table *CoefficientTableModel) {
+			if table == nil {
+				return
+			}
+			if table.Reference == nil {
+				problem("reactor: %s: reference is missing", name)
+			}
+			if len(table.Points) == 0 {
+				problem("reactor: %s: points are missing", name)
+			} else if len(table.Coefficients) != len(table.Points) {
+				problem("reactor: %s: %d coefficients for %d points", name, len(table.Coefficients), len(table.Points))
+			}
+			for i := 1; i < len(table.Points); i += 1 {
+				if table.Points[i] <= table.Points[i-1] {
+					problem("reactor: %s: points must be in ascending order, got %g after %g", name, table.Points[i], table.Points[i-1])
+				}
+			}
+		}
+		table("voidCoefficient", reactor.VoidCoefficient)
+		table("dopplerCoefficient", reactor.DopplerCoefficient)
+		table("moderatorCoefficient", reactor.ModeratorCoefficient)
+	}
+
+	if protection := model.Protection; protection != nil {
+		if model.Reactor == nil {
+			problem("protection: the plant has no reactor to protect")
+		}
+		var positive = func(name string, setpoint *float64) {
+			if setpoint != nil && *setpoint <= 0 {
+				problem("protection: %s must be positive, got %g", name, *setpoint)
+			}
+		}
+		positive("highNeutronFlux", protection.HighNeutronFlux)
+		positive("highVesselPressure", protection.HighVesselPressure)
+		positive("lowWaterLevel", protection.LowWaterLevel)
+		positive("highWaterLevel", protection.HighWaterLevel)
+		positive("highDrywellPressure", protection.HighDrywellPressure)
+		if protection.LowWaterLevel != nil && protection.HighWaterLevel != nil && *protection.LowWaterLevel >= *protection.HighWaterLevel {
+			problem("protection: lowWaterLevel %g must be below highWaterLevel %g", *protection.LowWaterLevel, *protection.HighWaterLevel)
+		}
+		for _, valves := range [][]string{protection.MSIVs, protection.TurbineStopValves} {
+			for _, id := range valves {
+				if _, ok := model.Valves[id]; !ok {
+					problem("protection: valve %q does not exist", id)
+				}
+			}
+		}
+		if _, ok := model.Nodes[protection.DrywellNode]; protection.DrywellNode != "" && !ok {
+			problem("protection: drywellNode %q does not exist", protection.DrywellNode)
+		}
+	}
+	return errors.Join(problems...)
+}
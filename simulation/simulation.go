@@ -0,0 +1,202 @@
+package simulation
+
+import (
+	"GoBWR/fluid"
+	"GoBWR/instrumentation"
+	"GoBWR/protection"
+	"GoBWR/reactor"
+	"fmt"
+	"math"
+	"time"
+)
+
+// --- STRUCT DECLARATIONS ---
+
+// Simulation owns all the state of one plant, so several plants can run side by side and each can be reset by
+// creating a new one.
+type Simulation struct {
+	Fluid   *fluid.FluidNetwork
+	Reactor *reactor.Reactor
+	Time    time.Duration // simulated time since the plant was created
+
+	CoreNode string // the fluid node the reactor heats, empty if the plant has no core
+
+	Instruments       *instrumentation.Instruments // nuclear instrumentation a control panel reads the core from
+	Protection        *protection.ProtectionSystem
+	MSIVs             []string // valves the protection system watches for closure
+	TurbineStopValves []string
+	DrywellNode       string // node whose pressure the protection system reads as the drywell pressure
+}
+
+// --- CONSTANT DECLARATIONS ---
+const instrumentNoiseSeed uint64 = 1 // fixed so that runs of the same plant read the same
+
+// aprmChannels assigns the APRMs to the channels of the protection system, so a single failed APRM can only trip one
+// trip system.
+var aprmChannels map[string][]string = map[string][]string{"A1": {"A", "C"}, "A2": {"B", "D"}, "B1": {"E"}, "B2": {"F"}}
+
+// New returns an initialized simulation of the built-in plant.
+func New() (*Simulation, error) {
+	return NewFromModel(DefaultPlantModel())
+}
+
+// NewFromModel returns an initialized simulation of the plant described by a model, with the default equation of
+// state of the build. The model is validated first.
+func NewFromModel(model PlantModel) (*Simulation, error) {
+	return NewFromModelWithProperties(model, fluid.DefaultPropertyProvider())
+}
+
+// NewFromModelWithProperties returns an initialized simulation of the plant described by a model, whose water and
+// steam follow the given equation of state. An invalid model is rejected with every problem found, see
+// PlantModel.Validate.
+func NewFromModelWithProperties(model PlantModel, properties fluid.PropertyProvider) (*Simulation, error) {
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+	var simulation *Simulation = &Simulation{
+		Fluid:       model.Network(),
+		Reactor:     reactor.NewReactor(0),
+		Instruments: instrumentation.New(instrumentNoiseSeed),
+		Protection:  protection.New(protection.DefaultTrips()),
+	}
+	if model.Reactor != nil {
+		simulation.Reactor = reactor.NewReactor(*model.Reactor.RatedThermalPower)
+		simulation.CoreNode = model.Reactor.CoreNode
+		coefficientTable(&simulation.Reactor.Feedback.Void, model.Reactor.VoidCoefficient)
+		coefficientTable(&simulation.Reactor.Feedback.Doppler, model.Reactor.DopplerCoefficient)
+		coefficientTable(&simulation.Reactor.Feedback.Moderator, model.Reactor.ModeratorCoefficient)
+	}
+	if model.Protection != nil {
+		simulation.configureProtection(*model.Protection)
+	}
+	simulation.Fluid.Properties = properties
+	if err := simulation.Fluid.Initialize(); err != nil {
+		return nil, err
+	}
+	return simulation, nil
+}
+
+// Step advances the whole plant by deltaTime. It fails without changing the plant if the core node was removed from
+// the fluid network after the plant was created.
+func (simulation *Simulation) Step(deltaTime time.Duration) error {
+	if _, ok := simulation.Fluid.Nodes[simulation.CoreNode]; simulation.CoreNode != "" && !ok {
+		return fmt.Errorf("reactor core: node %q does not exist", simulation.CoreNode)
+	}
+	simulation.Fluid.SimulateFlow(deltaTime)
+	simulation.Fluid.SimulateHeatTransfer(deltaTime)
+	if simulation.CoreNode != "" {
+		simulation.coupleCore()
+	}
+	simulation.Instruments.Simulate(simulation.Reactor.NeutronDensity, simulation.Reactor.Period, deltaTime)
+	if simulation.Protection.Simulate(simulation.protectionSignals(), simulation.Time, deltaTime) {
+		simulation.Reactor.Scram()
+	}
+	simulation.Reactor.SimulateFission(deltaTime)
+	if simulation.CoreNode != "" {
+		if err := simulation.Fluid.DepositHeat(simulation.CoreNode, simulation.Reactor.HeatToCoolant()*1000000, deltaTime); err != nil {
+			return fmt.Errorf("reactor core: %w", err)
+		}
+	}
+	simulation.Time += deltaTime
+	return nil
+}
+
+// ManualScram presses the manual scram buttons of the protection system, which scrams the reactor with the next step.
+func (simulation *Simulation) ManualScram() {
+	simulation.Protection.Scram()
+}
+
+// ResetScram resets the scram of the control rods and then the latched scram of the protection system. It fails while
+// a trip is still present or the rods are not fully inserted yet, and then leaves both scrammed.
+func (simulation *Simulation) ResetScram() error {
+	if err := simulation.Protection.CanReset(); err != nil {
+		return err
+	}
+	if err := simulation.Reactor.ResetScram(); err != nil {
+		return err
+	}
+	return simulation.Protection.Reset()
+}
+
+// configureProtection applies the setpoints of the model to the trips of the protection system and enables the trips
+// whose sensors the model provides.
+func (simulation *Simulation) configureProtection(model ProtectionModel) {
+	simulation.MSIVs, simulation.TurbineStopValves, simulation.DrywellNode = model.MSIVs, model.TurbineStopValves, model.DrywellNode
+	for i, trip := range simulation.Protection.Trips {
+		var setpoint *float64
+		switch trip.Parameter {
+		case protection.HighNeutronFlux:
+			setpoint = model.HighNeutronFlux
+		case protection.HighVesselPressure:
+			setpoint = model.HighVesselPressure
+		case protection.LowWaterLevel:
+			setpoint, trip.Enabled = model.LowWaterLevel, model.LowWaterLevel != nil
+		case protection.HighWaterLevel:
+			setpoint, trip.Enabled = model.HighWaterLevel, model.HighWaterLevel != nil
+		case protection.MSIVClosure:
+			trip.Enabled = len(model.MSIVs) > 0
+		case protection.HighDrywellPressure:
+			setpoint, trip.Enabled = model.HighDrywellPressure, model.DrywellNode != ""
+		case protection.TurbineTrip:
+			trip.Enabled = len(model.TurbineStopValves) > 0
+		}
+		if setpoint != nil {
+			trip.Setpoint = *setpoint
+		}
+		simulation.Protection.Trips[i] = trip
+	}
+}
+
+// protectionSignals reads the signals of the protection system from the plant. Signals the plant has no sensor for
+// read as normal.
+func (simulation *Simulation) protectionSignals() protection.Signals {
+	var signals protection.Signals = protection.Signals{NeutronFlux: make(map[string]float64), MSIVPosition: 1, TurbineStopValvePosition: 1}
+	for channel, aprms := range aprmChannels {
+		signals.NeutronFlux[channel] = simulation.Instruments.HighestAPRM(aprms...)
+	}
+	if simulation.CoreNode != "" {
+		signals.VesselPressure = simulation.Fluid.Nodes[simulation.CoreNode].Pressure
+		signals.WaterLevel, _ = simulation.Fluid.GetWaterLevel(simulation.CoreNode)
+	}
+	for _, id := range simulation.MSIVs {
+		signals.MSIVPosition = math.Min(signals.MSIVPosition, simulation.Fluid.Valves[id].Position)
+	}
+	for _, id := range simulation.TurbineStopValves {
+		signals.TurbineStopValvePosition = math.Min(signals.TurbineStopValvePosition, simulation.Fluid.Valves[id].Position)
+	}
+	if simulation.DrywellNode != "" {
+		signals.DrywellPressure = simulation.Fluid.Nodes[simulation.DrywellNode].Pressure
+	}
+	return signals
+}
+
+// coupleCore hands the state of the coolant in the core node and the core flow the jet pumps deliver to the reactor,
+// for the reactivity feedback and the heat transfer from the fuel rods.
+func (simulation *Simulation) coupleCore() {
+	var coolant fluid.FluidNode = simulation.Fluid.Nodes[simulation.CoreNode]
+	var core *reactor.Reactor = simulation.Reactor
+	core.ModeratorTemperature = coolant.Temperature
+	core.VoidFraction = coolant.VoidFraction
+	if len(simulation.Fluid.JetPumps) > 0 { // otherwise the core keeps its rated flow
+		core.Coolant.Flow = simulation.Fluid.JetPumpFlow()
+	}
+
+	var liquid, vapour fluid.WaterProperties = simulation.Fluid.Properties.Px(coolant.Pressure/1000000, 0), simulation.Fluid.Properties.Px(coolant.Pressure/1000000, 1)
+	core.Coolant.Pressure = coolant.Pressure
+	core.Coolant.LatentHeat = (vapour.Enthalpy - liquid.Enthalpy) * 1000
+	core.Coolant.InletSubcooling = max(liquid.Enthalpy*1000-coolant.Enthalpy, 0)
+	core.Coolant.HeatTransferCoefficient, _, _ = simulation.Fluid.WallHeatTransfer(simulation.CoreNode, core.Fuel.HydraulicDiameter,
+		core.Fuel.MassFlux(math.Abs(core.Coolant.Flow)), core.Fuel.CladSurfaceTemperature)
+}
+
+// coefficientTable replaces a reactivity coefficient table with the one of the model, if the model has one.
+func coefficientTable(table *reactor.CoefficientTable, model *CoefficientTableModel) {
+	if model == nil {
+		return
+	}
+	*table = reactor.CoefficientTable{
+		Reference:    *model.Reference,
+		Points:       model.Points,
+		Coefficients: model.Coefficients,
+	}
+}
@@ -0,0 +1,232 @@
+package simulation
+
+import (
+	"GoBWR/fluid"
+	"GoBWR/reactor"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResetScramWithRodsMoving(t *testing.T) {
+	var simulation, err = New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var id string = simulation.Reactor.Rods.Sequence[0].Rods[0]
+	var rod = simulation.Reactor.Rods.Rods[id]
+	rod.Position, rod.Target = 48, 48
+	simulation.Reactor.Rods.Rods[id] = rod
+
+	simulation.ManualScram()
+	if err := simulation.Step(100 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := simulation.ResetScram(); err == nil {
+		t.Fatal("reset accepted while a rod is still moving in")
+	}
+	if !simulation.Protection.Scrammed || !simulation.Protection.Manual || !simulation.Reactor.Rods.Scrammed {
+		t.Fatalf("refused reset left protection scrammed %v, manual %v and rods scrammed %v; want all still scrammed",
+			simulation.Protection.Scrammed, simulation.Protection.Manual, simulation.Reactor.Rods.Scrammed)
+	}
+
+	for i := 0; i < 40; i += 1 { // the scram inserts the rod within 3 s
+		if err := simulation.Step(100 * time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := simulation.ResetScram(); err != nil {
+		t.Fatalf("reset refused with all rods in: %v", err)
+	}
+	if simulation.Protection.Scrammed || simulation.Reactor.Rods.Scrammed {
+		t.Error("reset left the protection system or the rods scrammed")
+	}
+}
+
+func TestSingleFailedAPRMHalfScrams(t *testing.T) {
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		t.Run(name, func(t *testing.T) {
+			var simulation, err = New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id, aprm := range simulation.Instruments.APRMs {
+				aprm.Power = 100
+				if id == name {
+					aprm.Power = 125 // failed high
+				}
+				simulation.Instruments.APRMs[id] = aprm
+			}
+			if simulation.Protection.Simulate(simulation.protectionSignals(), 0, 100*time.Millisecond) {
+				t.Error("a single failed APRM scrammed the reactor")
+			}
+			if simulation.Protection.TripSystemA == simulation.Protection.TripSystemB {
+				t.Errorf("trip systems A %v and B %v, want exactly one half scram", simulation.Protection.TripSystemA, simulation.Protection.TripSystemB)
+			}
+		})
+	}
+}
+
+// twinVesselModel is a core node and an identical twin joined by a pipe at the same elevation, so no flow runs
+// between them until the core heats up.
+const twinVesselModel string = `{
+	"version": 2,
+	"nodes": {
+		"Core": {"temperature": 20, "pressure": 2339, "volume": 8, "maxVolume": 10, "closed": true, "bottomElevation": 0, "topElevation": 5},
+		"Twin": {"temperature": 20, "pressure": 2339, "volume": 8, "maxVolume": 10, "closed": true, "bottomElevation": 0, "topElevation": 5}
+	},
+	"pipes": {
+		"Tie": {"sourceType": "Node", "sourceId": "Core", "destinationType": "Node", "destinationId": "Twin",
+			"diameter": 100, "length": 5, "minorK": 1, "inletElevation": 1, "outletElevation": 1}
+	},
+	"reactor": {"ratedThermalPower": 100, "coreNode": "Core"}
+}`
+
+func TestParsePlantModelProblems(t *testing.T) {
+	var tests = []struct {
+		name     string
+		old, new string // replaced once in twinVesselModel
+		want     string // part of the error, empty for a valid model
+	}{
+		{"valid", "", "", ""},
+		{"fluid problem", `"length": 5`, `"length": -5`, `pipe "Tie": length must be positive, got -5`},
+		{"unknown field", `"coreNode": "Core"`, `"coreNode": "Core", "corNode": "Core"`, `unknown field "corNode"`},
+		{"missing core node", `, "coreNode": "Core"`, ``, "reactor: coreNode is missing"},
+		{"unknown core node", `"coreNode": "Core"`, `"coreNode": "Vessel"`, `reactor: coreNode "Vessel" does not exist`},
+		{"unsorted coefficient table", `"coreNode": "Core"`,
+			`"coreNode": "Core", "voidCoefficient": {"reference": 0, "points": [40, 0], "coefficients": [-100, -80]}`,
+			"reactor: voidCoefficient: points must be in ascending order, got 0 after 40"},
+		{"protection valve", `"coreNode": "Core"}`, `"coreNode": "Core"}, "protection": {"msivs": ["MSIV"]}`,
+			`protection: valve "MSIV" does not exist`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var model string = strings.Replace(twinVesselModel, test.old, test.new, 1)
+			var _, err = ParsePlantModel([]byte(model))
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case test.want != "" && err == nil:
+				t.Errorf("no error, want %q", test.want)
+			case test.want != "" && !strings.Contains(err.Error(), test.want):
+				t.Errorf("error %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadPlantModel(t *testing.T) {
+	var path string = filepath.Join(t.TempDir(), "twin.json")
+	if err := os.WriteFile(path, []byte(twinVesselModel), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var model, err = LoadPlantModel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Reactor == nil || model.Reactor.CoreNode != "Core" {
+		t.Errorf("reactor section %+v, want the core in node Core", model.Reactor)
+	}
+	// the fluid network alone loads from the same file
+	network, err := fluid.LoadPlantModel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Nodes) != 2 {
+		t.Errorf("%d nodes, want the 2 of the file", len(network.Nodes))
+	}
+	if _, err := LoadPlantModel(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("no error for a missing file")
+	}
+}
+
+func TestNewFromModelValidates(t *testing.T) {
+	var model PlantModel = DefaultPlantModel()
+	model.Reactor.RatedThermalPower = nil
+	if _, err := NewFromModel(model); err == nil || !strings.Contains(err.Error(), "reactor: ratedThermalPower is missing") {
+		t.Errorf("error %v, want the missing rated thermal power", err)
+	}
+}
+
+func TestStepWithoutCoreNode(t *testing.T) {
+	var simulation, err = New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(simulation.Fluid.Nodes, simulation.CoreNode)
+	if err := simulation.Step(100 * time.Millisecond); err == nil || !strings.Contains(err.Error(), `node "ReactorVessel" does not exist`) {
+		t.Errorf("error %v, want the missing core node", err)
+	}
+	if simulation.Time != 0 {
+		t.Errorf("time advanced to %v by a failed step", simulation.Time)
+	}
+}
+
+func TestCoreHeatsItsNode(t *testing.T) {
+	var model, err = ParsePlantModel([]byte(twinVesselModel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	simulation, err := NewFromModel(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if simulation.Reactor.RatedThermalPower != 100 {
+		t.Fatalf("rated thermal power = %v MW, want the 100 MW of the model", simulation.Reactor.RatedThermalPower)
+	}
+
+	// start the cold core at rated neutron density with its precursors in balance
+	var kinetics reactor.DelayedNeutronData = simulation.Reactor.Kinetics
+	simulation.Reactor.NeutronDensity = 1
+	for i := range simulation.Reactor.Precursors {
+		simulation.Reactor.Precursors[i] = kinetics.Fractions[i] / (kinetics.DecayConstants[i] * kinetics.GenerationTime)
+	}
+	simulation.Reactor.ExternalReactivity = -simulation.Reactor.RodReactivity()
+
+	var energy = func(node fluid.FluidNode) float64 { return node.Mass * node.InternalEnergy }
+	var core, twin fluid.FluidNode = simulation.Fluid.Nodes["Core"], simulation.Fluid.Nodes["Twin"]
+	var deltaTime time.Duration = 100 * time.Millisecond
+	if err := simulation.Step(deltaTime); err != nil {
+		t.Fatal(err)
+	}
+	var deposited float64 = simulation.Reactor.HeatToCoolant() * 1000000 * deltaTime.Seconds()
+	if deposited <= 0 {
+		t.Fatalf("heat to the coolant = %v J, want the core to heat it", deposited)
+	}
+	if got := energy(simulation.Fluid.Nodes["Core"]) - energy(core); math.Abs(got-deposited) > 1e-6*deposited {
+		t.Errorf("core node gained %v J, want the %v J the core gave the coolant", got, deposited)
+	}
+	if got := energy(simulation.Fluid.Nodes["Twin"]) - energy(twin); got != 0 {
+		t.Errorf("twin node gained %v J, want nothing", got)
+	}
+}
+
+func TestStepIsDeterministic(t *testing.T) {
+	var run = func() *Simulation {
+		var simulation, err = New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 50; i += 1 {
+			if err := simulation.Step(100 * time.Millisecond); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return simulation
+	}
+	var first, second *Simulation = run(), run()
+	if a, b := first.Fluid.JetPumpFlow(), second.Fluid.JetPumpFlow(); a != b {
+		t.Errorf("core flow %v kg/s in one run, %v kg/s in the other", a, b)
+	}
+	if a, b := first.Reactor.NeutronDensity, second.Reactor.NeutronDensity; a != b {
+		t.Errorf("neutron density %v in one run, %v in the other", a, b)
+	}
+	for nodeId, a := range first.Fluid.Nodes {
+		if b := second.Fluid.Nodes[nodeId]; a.Pressure != b.Pressure || a.Mass != b.Mass {
+			t.Errorf("%s: %v Pa, %v kg in one run, %v Pa, %v kg in the other", nodeId, a.Pressure, a.Mass, b.Pressure, b.Mass)
+		}
+	}
+}